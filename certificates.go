@@ -0,0 +1,88 @@
+package go_yaml_to_x509
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// CertificatesFromYaml parses YAML data and returns every certificate it defines, keyed by name.
+//
+// A document may define several certificates in a 'certificates' map, each with its own
+// merge list and config sharing the document's top-level segments:
+//
+//	segments:
+//	  defaults:
+//	    issuer:
+//	      common_name: "Example CA"
+//	certificates:
+//	  www:
+//	    merge:
+//	      - defaults
+//	    config:
+//	      subject:
+//	        common_name: "www.example.com"
+//
+// The data may also be a multi-document stream separated by '---'. Documents without a
+// 'certificates' map contribute a single certificate named after their 'name' field, or
+// their position in the stream when it is not set. Names must be unique across the stream.
+func CertificatesFromYaml(yamlData []byte, opts ...Option) (map[string]*x509.Certificate, error) {
+	return CertificatesFromYamlWithOptions(yamlData, NewOptions(opts...))
+}
+
+// CertificatesFromYamlWithOptions parses YAML data like CertificatesFromYaml, using opts
+// to control how segments are resolved.
+func CertificatesFromYamlWithOptions(yamlData []byte, opts Options) (map[string]*x509.Certificate, error) {
+	certs, _, _, err := buildStream(yamlData, opts)
+	return certs, err
+}
+
+// buildStream builds every certificate of a YAML stream, returning the certificates
+// and their specs keyed by name, and the parsed documents
+func buildStream(yamlData []byte, opts Options) (map[string]*x509.Certificate, map[string]*CertificateSpec, []*ConfigDocument, error) {
+	certs := make(map[string]*x509.Certificate)
+	allSpecs := make(map[string]*CertificateSpec)
+
+	// Use a single point in time for every relative validity period in the stream,
+	// except in seeded builds without a clock of their own
+	clock := opts.Now
+	now := opts.now()
+	opts.Now = func() time.Time { return now }
+
+	docs, err := ParseDocumentsWithOptions(yamlData, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	for index, doc := range docs {
+		specs, err := internal.ResolveDocument(doc, strconv.Itoa(index), opts.resolveOptions())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		for name, spec := range specs {
+			if _, exists := certs[name]; exists {
+				return nil, nil, nil, fmt.Errorf("certificate '%s' is defined more than once", name)
+			}
+
+			buildOpts := opts
+			if clock == nil && opts.testSeed(spec) != nil {
+				buildOpts.Now = nil
+			}
+			cert, err := BuildCertificate(spec, buildOpts)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("certificate '%s': %w", name, err)
+			}
+			if err := opts.checkPolicy(name, cert, opts.testSeed(spec)); err != nil {
+				return nil, nil, nil, err
+			}
+			certs[name] = cert
+			allSpecs[name] = spec
+		}
+	}
+
+	return certs, allSpecs, docs, nil
+}
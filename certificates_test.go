@@ -0,0 +1,146 @@
+package go_yaml_to_x509
+
+import (
+	"crypto/x509"
+	"strings"
+	"testing"
+)
+
+func TestCertificatesFromYaml_CertificatesMap(t *testing.T) {
+	yamlData := []byte(`
+segments:
+  defaults:
+    issuer:
+      common_name: "Example CA"
+    key_usage:
+      - digital_signature
+  web-server:
+    ext_key_usage:
+      - server_auth
+
+certificates:
+  www:
+    merge:
+      - defaults
+      - web-server
+    config:
+      subject:
+        common_name: "www.example.com"
+      dns_names:
+        - "www.example.com"
+  api:
+    merge:
+      - defaults
+      - web-server
+    config:
+      subject:
+        common_name: "api.example.com"
+`)
+
+	certs, err := CertificatesFromYaml(yamlData)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	if len(certs) != 2 {
+		t.Fatalf("Expected 2 certificates, got %d", len(certs))
+	}
+	if certs["www"].Subject.CommonName != "www.example.com" {
+		t.Errorf("Expected www CommonName 'www.example.com', got '%s'", certs["www"].Subject.CommonName)
+	}
+	if certs["api"].Subject.CommonName != "api.example.com" {
+		t.Errorf("Expected api CommonName 'api.example.com', got '%s'", certs["api"].Subject.CommonName)
+	}
+	for name, cert := range certs {
+		if cert.Issuer.CommonName != "Example CA" {
+			t.Errorf("Expected %s issuer from defaults segment, got '%s'", name, cert.Issuer.CommonName)
+		}
+		if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+			t.Errorf("Expected %s ExtKeyUsage from web-server segment, got %v", name, cert.ExtKeyUsage)
+		}
+	}
+}
+
+func TestCertificatesFromYaml_MultiDocumentStream(t *testing.T) {
+	yamlData := []byte(`
+name: root
+subject:
+  common_name: "Root CA"
+is_ca: true
+---
+subject:
+  common_name: "unnamed.example.com"
+---
+certificates:
+  www:
+    config:
+      subject:
+        common_name: "www.example.com"
+`)
+
+	certs, err := CertificatesFromYaml(yamlData)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML stream: %v", err)
+	}
+
+	if len(certs) != 3 {
+		t.Fatalf("Expected 3 certificates, got %d", len(certs))
+	}
+	if certs["root"] == nil || !certs["root"].IsCA {
+		t.Error("Expected CA certificate named 'root'")
+	}
+	if certs["1"] == nil || certs["1"].Subject.CommonName != "unnamed.example.com" {
+		t.Error("Expected unnamed document to be keyed by its position")
+	}
+	if certs["www"] == nil {
+		t.Error("Expected certificate 'www' from certificates map")
+	}
+}
+
+func TestCertificatesFromYaml_DuplicateName(t *testing.T) {
+	yamlData := []byte(`
+name: www
+subject:
+  common_name: "one"
+---
+certificates:
+  www:
+    config:
+      subject:
+        common_name: "two"
+`)
+
+	_, err := CertificatesFromYaml(yamlData)
+	if err == nil || err.Error() != "certificate 'www' is defined more than once" {
+		t.Errorf("Expected duplicate name error, got %v", err)
+	}
+}
+
+func TestX509FromYaml_CertificatesMap(t *testing.T) {
+	single := []byte(`
+certificates:
+  www:
+    config:
+      subject:
+        common_name: "www.example.com"
+`)
+
+	cert, err := X509FromYaml(single)
+	if err != nil {
+		t.Fatalf("Expected single entry to be returned, got %v", err)
+	}
+	if cert.Subject.CommonName != "www.example.com" {
+		t.Errorf("Expected CommonName 'www.example.com', got '%s'", cert.Subject.CommonName)
+	}
+
+	multiple := []byte(`
+certificates:
+  www: {}
+  api: {}
+`)
+
+	_, err = X509FromYaml(multiple)
+	if err == nil || !strings.Contains(err.Error(), "use CertificatesFromYaml") {
+		t.Errorf("Expected error pointing to CertificatesFromYaml, got %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+// Package cfssl renders YAML profiles for pipelines that call CloudFlare's cfssl:
+// a CSR JSON for 'cfssl gencert' and a signing configuration with one profile per
+// certificate for 'cfssl sign' and 'cfssl serve'.
+package cfssl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// Unsupported is a field of a CertificateSpec that cfssl cannot express, left out of
+// the JSON. Field is prefixed with the profile name in signing configurations.
+type Unsupported = internal.Unsupported
+
+// usages maps key usages and extended key usages to the names of cfssl's signing
+// profiles. cfssl has no names for Microsoft's code signing usages.
+var usages = map[string]string{
+	internal.KeyUsageDigitalSignature:              "digital signature",
+	internal.KeyUsageContentCommitment:             "content commitment",
+	internal.KeyUsageKeyEncipherment:               "key encipherment",
+	internal.KeyUsageDataEncipherment:              "data encipherment",
+	internal.KeyUsageKeyAgreement:                  "key agreement",
+	internal.KeyUsageCertSign:                      "cert sign",
+	internal.KeyUsageCRLSign:                       "crl sign",
+	internal.KeyUsageEncipherOnly:                  "encipher only",
+	internal.KeyUsageDecipherOnly:                  "decipher only",
+	internal.ExtKeyUsageAny:                        "any",
+	internal.ExtKeyUsageServerAuth:                 "server auth",
+	internal.ExtKeyUsageClientAuth:                 "client auth",
+	internal.ExtKeyUsageCodeSigning:                "code signing",
+	internal.ExtKeyUsageEmailProtection:            "email protection",
+	internal.ExtKeyUsageIPSECEndSystem:             "ipsec end system",
+	internal.ExtKeyUsageIPSECTunnel:                "ipsec tunnel",
+	internal.ExtKeyUsageIPSECUser:                  "ipsec user",
+	internal.ExtKeyUsageTimeStamping:               "timestamping",
+	internal.ExtKeyUsageOCSPSigning:                "ocsp signing",
+	internal.ExtKeyUsageMicrosoftServerGatedCrypto: "microsoft sgc",
+	internal.ExtKeyUsageNetscapeServerGatedCrypto:  "netscape sgc",
+}
+
+// csrRequest is cfssl's CSR JSON
+type csrRequest struct {
+	CN           string      `json:"CN,omitempty"`
+	Names        []csrName   `json:"names,omitempty"`
+	Hosts        []string    `json:"hosts,omitempty"`
+	Key          *keyRequest `json:"key,omitempty"`
+	CA           *caConfig   `json:"ca,omitempty"`
+	SerialNumber string      `json:"serialnumber,omitempty"`
+}
+
+type csrName struct {
+	C  string `json:"C,omitempty"`
+	ST string `json:"ST,omitempty"`
+	L  string `json:"L,omitempty"`
+	O  string `json:"O,omitempty"`
+	OU string `json:"OU,omitempty"`
+}
+
+type keyRequest struct {
+	Algo string `json:"algo"`
+	Size int    `json:"size,omitempty"`
+}
+
+type caConfig struct {
+	PathLength  int    `json:"pathlen,omitempty"`
+	PathLenZero bool   `json:"pathlenzero,omitempty"`
+	Expiry      string `json:"expiry,omitempty"`
+}
+
+// signingConfig is cfssl's configuration file, holding the signing profiles
+type signingConfig struct {
+	Signing signing `json:"signing"`
+}
+
+type signing struct {
+	Profiles map[string]*signingProfile `json:"profiles"`
+}
+
+type signingProfile struct {
+	Usages       []string      `json:"usages,omitempty"`
+	Expiry       string        `json:"expiry,omitempty"`
+	Backdate     string        `json:"backdate,omitempty"`
+	NotBefore    string        `json:"not_before,omitempty"`
+	NotAfter     string        `json:"not_after,omitempty"`
+	CAConstraint *caConstraint `json:"ca_constraint,omitempty"`
+}
+
+type caConstraint struct {
+	IsCA           bool `json:"is_ca"`
+	MaxPathLen     int  `json:"max_path_len,omitempty"`
+	MaxPathLenZero bool `json:"max_path_len_zero,omitempty"`
+}
+
+// CSR renders a resolved CertificateSpec as cfssl's CSR JSON: the subject, the SANs as
+// hosts, the key and, for CAs, the 'ca' section 'cfssl gencert -initca' reads. Usages
+// and validity belong to the signing profile, see Config.
+func CSR(spec *internal.CertificateSpec) ([]byte, []Unsupported, error) {
+	var unsupported []Unsupported
+	skip := func(field, reason string) {
+		unsupported = append(unsupported, Unsupported{Field: field, Reason: reason})
+	}
+
+	name := internal.ParsePkixName(spec.Subject)
+	req := csrRequest{CN: name.CommonName, SerialNumber: name.SerialNumber}
+	n := csrName{C: first(name.Country), ST: first(name.Province), L: first(name.Locality), O: first(name.Organization), OU: first(name.OrganizationalUnit)}
+	if n != (csrName{}) {
+		req.Names = []csrName{n}
+	}
+	for _, field := range []string{internal.DNStreetAddress, internal.DNPostalCode} {
+		if _, ok := spec.Subject[field]; ok {
+			skip("subject."+field, "cfssl names have no such field")
+		}
+	}
+
+	// cfssl tells IP addresses, email addresses and URIs apart from DNS names itself
+	for _, hosts := range [][]string{spec.DNSNames, spec.IPAddresses, spec.EmailAddresses, spec.URIs} {
+		req.Hosts = append(req.Hosts, hosts...)
+	}
+
+	switch spec.PublicKeyAlgorithm {
+	case internal.PubKeyAlgRSA:
+		req.Key = &keyRequest{Algo: "rsa", Size: 2048}
+	case internal.PubKeyAlgECDSA, "":
+		req.Key = &keyRequest{Algo: "ecdsa", Size: 256}
+	case internal.PubKeyAlgEd25519:
+		req.Key = &keyRequest{Algo: "ed25519"}
+	default:
+		skip("public_key_algorithm", fmt.Sprintf("cfssl cannot generate %s keys", spec.PublicKeyAlgorithm))
+	}
+
+	if spec.IsCA {
+		req.CA = &caConfig{PathLength: spec.MaxPathLen, PathLenZero: spec.MaxPathLenZero}
+		expiry, err := duration(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.CA.Expiry = expiry
+	}
+
+	if spec.PublicKey != "" {
+		skip("public_key", "cfssl generates the key of a CSR, sign an existing request with 'cfssl sign'")
+	}
+	if spec.KeyEncryption != nil {
+		skip("key_encryption", "cfssl writes keys unencrypted")
+	}
+	if spec.InsecureTestSeed != "" {
+		skip("insecure_test_seed", "cfssl generates keys at random")
+	}
+
+	out, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(out, '\n'), sortUnsupported(unsupported), nil
+}
+
+// Config renders resolved CertificateSpecs as a cfssl configuration with a signing
+// profile per spec, holding its usages, validity and CA constraint:
+//
+//	cfssl sign -ca ca.pem -ca-key ca-key.pem -config cfssl.json -profile www www.csr
+func Config(specs map[string]*internal.CertificateSpec) ([]byte, []Unsupported, error) {
+	var unsupported []Unsupported
+	config := signingConfig{Signing: signing{Profiles: make(map[string]*signingProfile, len(specs))}}
+
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		spec := specs[name]
+		skip := func(field, reason string) {
+			unsupported = append(unsupported, Unsupported{Field: name + "." + field, Reason: reason})
+		}
+
+		profile, err := signingProfileOf(spec, skip)
+		if err != nil {
+			return nil, nil, fmt.Errorf("profile '%s': %w", name, err)
+		}
+		config.Signing.Profiles[name] = profile
+	}
+
+	out, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(out, '\n'), sortUnsupported(unsupported), nil
+}
+
+func signingProfileOf(spec *internal.CertificateSpec, skip func(field, reason string)) (*signingProfile, error) {
+	profile := &signingProfile{}
+
+	for _, field := range []struct {
+		name   string
+		usages []string
+	}{
+		{"key_usage", spec.KeyUsage},
+		{"ext_key_usage", spec.ExtKeyUsage},
+	} {
+		for _, usage := range field.usages {
+			if name, ok := usages[usage]; ok {
+				profile.Usages = append(profile.Usages, name)
+			} else {
+				skip(field.name, fmt.Sprintf("cfssl has no usage for '%s'", usage))
+			}
+		}
+	}
+
+	expiry, err := duration(spec)
+	if err != nil {
+		return nil, err
+	}
+	profile.Expiry = expiry
+	if v := spec.Validity; v != nil {
+		if v.Backdate != "" {
+			backdate, err := internal.ParseDuration(v.Backdate)
+			if err != nil {
+				return nil, fmt.Errorf("validity.backdate: %w", err)
+			}
+			profile.Backdate = hours(backdate)
+		}
+		if v.Anchor != "" {
+			skip("validity.anchor", "cfssl starts the validity period when it signs")
+		}
+	}
+	// Absolute times are passed through; cfssl prefers them to the expiry
+	for _, t := range []struct {
+		field, value string
+		target       *string
+	}{
+		{"not_before", spec.NotBefore, &profile.NotBefore},
+		{"not_after", spec.NotAfter, &profile.NotAfter},
+	} {
+		if t.value == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, t.value); err != nil {
+			return nil, fmt.Errorf("invalid %s '%s', expected an RFC3339 timestamp", t.field, t.value)
+		}
+		*t.target = t.value
+	}
+
+	if spec.IsCA || spec.BasicConstraintsValid {
+		profile.CAConstraint = &caConstraint{IsCA: spec.IsCA}
+		if spec.IsCA {
+			profile.CAConstraint.MaxPathLen = spec.MaxPathLen
+			profile.CAConstraint.MaxPathLenZero = spec.MaxPathLenZero
+		}
+	}
+
+	if spec.SerialNumber != "" {
+		skip("serial_number", "cfssl picks the serial number")
+	}
+	if spec.SignatureAlgorithm != "" {
+		skip("signature_algorithm", "cfssl picks the signature algorithm from the CA key")
+	}
+	if len(spec.Issuer) > 0 {
+		skip("issuer", "the issuer name comes from the CA certificate")
+	}
+	if spec.Signer != nil {
+		skip("signer", "cfssl signs with -ca-key or a remote signer")
+	}
+	return profile, nil
+}
+
+// duration returns the validity.duration of a spec in hours, as cfssl reads them
+func duration(spec *internal.CertificateSpec) (string, error) {
+	if spec.Validity == nil || spec.Validity.Duration == "" {
+		return "", nil
+	}
+	d, err := internal.ParseDuration(spec.Validity.Duration)
+	if err != nil {
+		return "", fmt.Errorf("validity.duration: %w", err)
+	}
+	return hours(d), nil
+}
+
+// hours formats a duration like "2160h", falling back to Go's format below an hour
+func hours(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return d.String()
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func sortUnsupported(unsupported []Unsupported) []Unsupported {
+	sort.SliceStable(unsupported, func(i, j int) bool {
+		return unsupported[i].Field < unsupported[j].Field
+	})
+	return unsupported
+}
@@ -0,0 +1,217 @@
+package cfssl
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// cfsslKeyUsages and cfsslExtKeyUsages are the usage names cfssl's config package
+// accepts in signing profiles, with the values it gives them
+var cfsslKeyUsages = map[string]x509.KeyUsage{
+	"signing":            x509.KeyUsageDigitalSignature,
+	"digital signature":  x509.KeyUsageDigitalSignature,
+	"content commitment": x509.KeyUsageContentCommitment,
+	"key encipherment":   x509.KeyUsageKeyEncipherment,
+	"key agreement":      x509.KeyUsageKeyAgreement,
+	"data encipherment":  x509.KeyUsageDataEncipherment,
+	"cert sign":          x509.KeyUsageCertSign,
+	"crl sign":           x509.KeyUsageCRLSign,
+	"encipher only":      x509.KeyUsageEncipherOnly,
+	"decipher only":      x509.KeyUsageDecipherOnly,
+}
+
+var cfsslExtKeyUsages = map[string]x509.ExtKeyUsage{
+	"any":              x509.ExtKeyUsageAny,
+	"server auth":      x509.ExtKeyUsageServerAuth,
+	"client auth":      x509.ExtKeyUsageClientAuth,
+	"code signing":     x509.ExtKeyUsageCodeSigning,
+	"email protection": x509.ExtKeyUsageEmailProtection,
+	"s/mime":           x509.ExtKeyUsageEmailProtection,
+	"ipsec end system": x509.ExtKeyUsageIPSECEndSystem,
+	"ipsec tunnel":     x509.ExtKeyUsageIPSECTunnel,
+	"ipsec user":       x509.ExtKeyUsageIPSECUser,
+	"timestamping":     x509.ExtKeyUsageTimeStamping,
+	"ocsp signing":     x509.ExtKeyUsageOCSPSigning,
+	"microsoft sgc":    x509.ExtKeyUsageMicrosoftServerGatedCrypto,
+	"netscape sgc":     x509.ExtKeyUsageNetscapeServerGatedCrypto,
+}
+
+func TestCSR(t *testing.T) {
+	spec := &internal.CertificateSpec{
+		Subject: map[string]string{
+			internal.DNCommonName:   "Example CA",
+			internal.DNOrganization: "Example",
+			internal.DNCountry:      "NL",
+			internal.DNPostalCode:   "1234 AB",
+			internal.DNSerialNumber: "42",
+		},
+		Validity:           &internal.ValiditySpec{Duration: "1y"},
+		DNSNames:           []string{"ca.example.com"},
+		IPAddresses:        []string{"192.0.2.1"},
+		EmailAddresses:     []string{"pki@example.com"},
+		IsCA:               true,
+		MaxPathLen:         1,
+		PublicKeyAlgorithm: internal.PubKeyAlgRSA,
+	}
+
+	out, unsupported, err := CSR(spec)
+	if err != nil {
+		t.Fatalf("CSR: %v", err)
+	}
+
+	var req csrRequest
+	if err := json.Unmarshal(out, &req); err != nil {
+		t.Fatalf("CSR is not JSON: %v", err)
+	}
+	expected := csrRequest{
+		CN:           "Example CA",
+		Names:        []csrName{{C: "NL", O: "Example"}},
+		Hosts:        []string{"ca.example.com", "192.0.2.1", "pki@example.com"},
+		Key:          &keyRequest{Algo: "rsa", Size: 2048},
+		CA:           &caConfig{PathLength: 1, Expiry: "8760h"},
+		SerialNumber: "42",
+	}
+	if !reflect.DeepEqual(req, expected) {
+		t.Errorf("unexpected CSR\n got: %+v\nwant: %+v", req, expected)
+	}
+	if len(unsupported) != 1 || unsupported[0].Field != "subject.postal_code" {
+		t.Errorf("expected the postal code to be unsupported, got %v", unsupported)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	specs := map[string]*internal.CertificateSpec{
+		"www": {
+			Validity:           &internal.ValiditySpec{Duration: "90d", Backdate: "1h"},
+			KeyUsage:           []string{internal.KeyUsageDigitalSignature},
+			ExtKeyUsage:        []string{internal.ExtKeyUsageServerAuth, internal.ExtKeyUsageMicrosoftKernelCodeSigning},
+			SignatureAlgorithm: internal.SigAlgSHA256WithRSA,
+		},
+		"intermediate": {
+			NotBefore:             "2025-01-01T00:00:00Z",
+			NotAfter:              "2030-01-01T00:00:00Z",
+			KeyUsage:              []string{internal.KeyUsageCertSign, internal.KeyUsageCRLSign},
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			MaxPathLenZero:        true,
+		},
+	}
+
+	out, unsupported, err := Config(specs)
+	if err != nil {
+		t.Fatalf("Config: %v", err)
+	}
+
+	var config signingConfig
+	if err := json.Unmarshal(out, &config); err != nil {
+		t.Fatalf("config is not JSON: %v", err)
+	}
+	expected := map[string]*signingProfile{
+		"www": {
+			Usages:   []string{"digital signature", "server auth"},
+			Expiry:   "2160h",
+			Backdate: "1h",
+		},
+		"intermediate": {
+			Usages:       []string{"cert sign", "crl sign"},
+			NotBefore:    "2025-01-01T00:00:00Z",
+			NotAfter:     "2030-01-01T00:00:00Z",
+			CAConstraint: &caConstraint{IsCA: true, MaxPathLenZero: true},
+		},
+	}
+	if !reflect.DeepEqual(config.Signing.Profiles, expected) {
+		for name, profile := range config.Signing.Profiles {
+			t.Logf("%s: %+v", name, profile)
+		}
+		t.Error("unexpected signing profiles")
+	}
+
+	var reasons []string
+	for _, u := range unsupported {
+		reasons = append(reasons, u.String())
+	}
+	expectedReasons := []string{
+		"www.ext_key_usage: cfssl has no usage for 'microsoft_kernel_code_signing'",
+		"www.signature_algorithm: cfssl picks the signature algorithm from the CA key",
+	}
+	if !reflect.DeepEqual(reasons, expectedReasons) {
+		t.Errorf("expected %v, got %v", expectedReasons, reasons)
+	}
+}
+
+// TestConfig_Usages checks that every key usage and extended key usage exports to a
+// cfssl usage with the same x509 value, or is reported unsupported
+func TestConfig_Usages(t *testing.T) {
+	unsupportedUsages := map[string]bool{
+		internal.ExtKeyUsageMicrosoftCommercialCodeSigning: true,
+		internal.ExtKeyUsageMicrosoftKernelCodeSigning:     true,
+	}
+
+	export := func(t *testing.T, spec *internal.CertificateSpec) (*signingProfile, []Unsupported) {
+		t.Helper()
+		out, unsupported, err := Config(map[string]*internal.CertificateSpec{"p": spec})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var config signingConfig
+		if err := json.Unmarshal(out, &config); err != nil {
+			t.Fatal(err)
+		}
+		return config.Signing.Profiles["p"], unsupported
+	}
+
+	for _, usage := range internal.KeyUsages {
+		profile, unsupported := export(t, &internal.CertificateSpec{KeyUsage: []string{usage}})
+		if len(profile.Usages) != 1 || len(unsupported) != 0 {
+			t.Errorf("%s: expected one usage, got %v %v", usage, profile.Usages, unsupported)
+			continue
+		}
+		if got, expected := cfsslKeyUsages[profile.Usages[0]], internal.ParseKeyUsage([]string{usage}); got != expected {
+			t.Errorf("%s: cfssl reads '%s' as %v, expected %v", usage, profile.Usages[0], got, expected)
+		}
+	}
+
+	for _, usage := range internal.ExtKeyUsages {
+		profile, unsupported := export(t, &internal.CertificateSpec{ExtKeyUsage: []string{usage}})
+		if unsupportedUsages[usage] {
+			if len(profile.Usages) != 0 || len(unsupported) != 1 || unsupported[0].Field != "p.ext_key_usage" {
+				t.Errorf("%s: expected to be reported unsupported, got %v %v", usage, profile.Usages, unsupported)
+			}
+			continue
+		}
+		if len(profile.Usages) != 1 || len(unsupported) != 0 {
+			t.Errorf("%s: expected one usage, got %v %v", usage, profile.Usages, unsupported)
+			continue
+		}
+		got, ok := cfsslExtKeyUsages[profile.Usages[0]]
+		if expected := internal.ParseExtKeyUsage([]string{usage}); !ok || len(expected) != 1 || got != expected[0] {
+			t.Errorf("%s: cfssl reads '%s' as %v, expected %v", usage, profile.Usages[0], got, expected)
+		}
+	}
+}
+
+func TestConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec *internal.CertificateSpec
+		err  string
+	}{
+		{"invalid duration", &internal.CertificateSpec{Validity: &internal.ValiditySpec{Duration: "soon"}}, "profile 'p': validity.duration: invalid duration 'soon'"},
+		{"invalid backdate", &internal.CertificateSpec{Validity: &internal.ValiditySpec{Backdate: "-"}}, "validity.backdate: invalid duration '-'"},
+		{"invalid not_after", &internal.CertificateSpec{NotAfter: "2030"}, "invalid not_after '2030'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Config(map[string]*internal.CertificateSpec{"p": tt.spec})
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
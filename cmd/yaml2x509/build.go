@@ -0,0 +1,26 @@
+package main
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// runBuild resolves a profile and prints the merged certificate template as YAML
+func runBuild(args []string, e *env) error {
+	fs, in := newFlagSet("build", e)
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, err := loadProfile(path, in, e)
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(e.stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(p.spec); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rschoonheim/go-yaml-to-x509/kube"
+)
+
+// runCertManager converts a profile to a cert-manager Certificate manifest, printing
+// the fields cert-manager cannot express as warnings
+func runCertManager(args []string, e *env) error {
+	fs, in := newFlagSet("cert-manager", e)
+	name := fs.String("resource-name", "", "name of the Certificate resource, the certificate name when omitted")
+	namespace := fs.String("namespace", "", "namespace of the Certificate resource")
+	secretName := fs.String("secret-name", "", "Secret cert-manager stores the certificate in, the resource name when omitted")
+	issuerName := fs.String("issuer", "", "name of the cert-manager issuer that signs the certificate")
+	issuerKind := fs.String("issuer-kind", kube.IssuerKind, "kind of the issuer: Issuer, ClusterIssuer or an external issuer's kind")
+	issuerGroup := fs.String("issuer-group", "", "API group of an external issuer")
+	renewBefore := fs.Duration("renew-before", 0, "how long before expiry cert-manager renews, e.g. 720h")
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *issuerName == "" {
+		return &usageError{msg: "-issuer is required"}
+	}
+	if *renewBefore < 0 {
+		return &usageError{msg: fmt.Sprintf("-renew-before must not be negative, got %s", *renewBefore)}
+	}
+
+	p, err := loadProfile(path, in, e)
+	if err != nil {
+		return err
+	}
+
+	opts := kube.CertificateOptions{
+		Metadata:    kube.Metadata{Name: *name, Namespace: *namespace},
+		SecretName:  *secretName,
+		IssuerName:  *issuerName,
+		IssuerKind:  *issuerKind,
+		IssuerGroup: *issuerGroup,
+		RenewBefore: *renewBefore,
+	}
+	if opts.Name == "" {
+		opts.Name = p.name
+	}
+
+	manifest, unsupported, err := kube.Certificate(p.spec, opts)
+	if err != nil {
+		return err
+	}
+	for _, u := range unsupported {
+		fmt.Fprintf(e.stderr, "warning: not converted: %s\n", u)
+	}
+	_, err = e.stdout.Write(manifest)
+	return err
+}
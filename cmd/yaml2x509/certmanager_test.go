@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCertManager(t *testing.T) {
+	code, stdout, stderr := runTest(t, testProfile, "cert-manager", "-name", "www", "-var", "host=api",
+		"-namespace", "web", "-issuer", "example-ca", "-issuer-kind", "ClusterIssuer")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	for _, expected := range []string{
+		"apiVersion: cert-manager.io/v1",
+		"kind: Certificate",
+		"name: www",
+		"namespace: web",
+		"secretName: www",
+		"commonName: api.example.com",
+		"- api.example.com",
+		"duration: 2160h0m0s",
+		"- digital signature",
+		"- server auth",
+		"algorithm: ECDSA",
+		"kind: ClusterIssuer",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain '%s', got:\n%s", expected, stdout)
+		}
+	}
+	if !strings.Contains(stderr, "warning: not converted: issuer: the issuer name comes from issuerRef") {
+		t.Errorf("Expected a warning for the issuer name, got %q", stderr)
+	}
+}
+
+func TestCertManager_RequiresIssuer(t *testing.T) {
+	code, _, stderr := runTest(t, testProfile, "cert-manager", "-name", "ca")
+
+	if code != 2 || !strings.Contains(stderr, "-issuer is required") {
+		t.Errorf("Expected a usage error, got %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+)
+
+// runCSR generates a key pair and a certificate signing request for a profile
+func runCSR(args []string, e *env) error {
+	fs, in := newFlagSet("csr", e)
+	csrOut := fs.String("csr-out", "csr.pem", "path to write the certificate signing request")
+	keyOut := fs.String("key-out", "key.pem", "path to write the generated private key")
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, err := loadProfile(path, in, e)
+	if err != nil {
+		return err
+	}
+	if p.spec.PublicKey != "" {
+		return fmt.Errorf("certificate '%s' has a public_key, a request must be signed by its private key", p.name)
+	}
+
+	// Seeded requests derive the key from the certificate name and sign deterministically
+	var key crypto.Signer
+	signRand := rand.Reader
+	if seed := p.testSeed(e); seed != nil {
+		key, err = yamltox509.InsecureTestKey(p.cert.PublicKeyAlgorithm, seed, p.name)
+		signRand = nil
+	} else {
+		key, err = yamltox509.GenerateKey(p.cert.PublicKeyAlgorithm, rand.Reader)
+	}
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.CreateCertificateRequest(signRand, &x509.CertificateRequest{
+		Subject:            p.cert.Subject,
+		DNSNames:           p.cert.DNSNames,
+		EmailAddresses:     p.cert.EmailAddresses,
+		IPAddresses:        p.cert.IPAddresses,
+		URIs:               p.cert.URIs,
+		SignatureAlgorithm: p.cert.SignatureAlgorithm,
+	}, key)
+	if err != nil {
+		return err
+	}
+
+	if err := writePrivateKey(*keyOut, p, key); err != nil {
+		return err
+	}
+	if err := writePEM(*csrOut, "CERTIFICATE REQUEST", der, 0o644); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "wrote request for %s\n", p.cert.Subject)
+	return nil
+}
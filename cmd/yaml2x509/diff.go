@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// runDiff compares a deployed certificate with the profile it should conform to
+func runDiff(args []string, e *env) error {
+	fs, in := newFlagSet("diff", e)
+	asJSON := fs.Bool("json", false, "print the differences as a JSON array")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() != 2 {
+		return &usageError{msg: "expected a profile and a certificate file"}
+	}
+
+	cert, err := readCertificate(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	p, err := loadProfile(fs.Arg(0), in, e)
+	if err != nil {
+		return err
+	}
+
+	diffs, err := internal.CompareSpec(p.spec, internal.SpecFromCertificate(cert))
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		if diffs == nil {
+			diffs = []internal.Difference{}
+		}
+		encoder := json.NewEncoder(e.stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diffs); err != nil {
+			return err
+		}
+	} else {
+		for _, diff := range diffs {
+			fmt.Fprintln(e.stdout, diff)
+		}
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("certificate does not conform to profile '%s': %d differences", p.name, len(diffs))
+	}
+	if !*asJSON {
+		fmt.Fprintf(e.stdout, "certificate matches profile '%s'\n", p.name)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// issueSignedCertificate issues the 'www' test profile signed by the 'ca' profile
+// and returns the certificate path
+func issueSignedCertificate(t *testing.T, profile string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	caCert, caKey, certPath := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca-key.pem"), filepath.Join(dir, "www.pem")
+	if code, _, stderr := runTest(t, "", "issue", "-name", "ca", "-var", "host=www", "-cert-out", caCert, "-key-out", caKey, profile); code != 0 {
+		t.Fatalf("Expected exit code 0 issuing CA, got %d: %s", code, stderr)
+	}
+	if code, _, stderr := runTest(t, "", "issue", "-name", "www", "-var", "host=www", "-ca-cert", caCert, "-ca-key", caKey,
+		"-cert-out", certPath, "-key-out", filepath.Join(dir, "www-key.pem"), profile); code != 0 {
+		t.Fatalf("Expected exit code 0 issuing certificate, got %d: %s", code, stderr)
+	}
+	return certPath
+}
+
+func TestDiff_Matches(t *testing.T) {
+	profile := writeTestFile(t, "profile.yaml", testProfile)
+	certPath := issueSignedCertificate(t, profile)
+
+	code, stdout, stderr := runTest(t, "", "diff", "-name", "www", "-var", "host=www", profile, certPath)
+
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s%s", code, stdout, stderr)
+	}
+	if stdout != "certificate matches profile 'www'\n" {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+}
+
+func TestDiff_Differences(t *testing.T) {
+	profile := writeTestFile(t, "profile.yaml", testProfile)
+	certPath := issueSignedCertificate(t, profile)
+
+	code, stdout, stderr := runTest(t, "", "diff", "-name", "www", "-var", "host=api", profile, certPath)
+
+	if code != 1 {
+		t.Fatalf("Expected exit code 1, got %d: %s", code, stderr)
+	}
+	for _, expected := range []string{
+		"subject.common_name: expected 'api.example.com', got 'www.example.com'",
+		"dns_names: missing 'api.example.com'",
+		"dns_names: unexpected 'www.example.com'",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, stdout)
+		}
+	}
+	if !strings.Contains(stderr, "certificate does not conform to profile 'www': 3 differences") {
+		t.Errorf("Unexpected error output: %s", stderr)
+	}
+}
+
+func TestDiff_JSON(t *testing.T) {
+	profile := writeTestFile(t, "profile.yaml", testProfile)
+	certPath := issueSignedCertificate(t, profile)
+
+	_, stdout, _ := runTest(t, "", "diff", "-json", "-name", "www", "-var", "host=api", profile, certPath)
+
+	var diffs []internal.Difference
+	if err := json.Unmarshal([]byte(stdout), &diffs); err != nil {
+		t.Fatalf("Expected JSON output, got %v: %s", err, stdout)
+	}
+	if len(diffs) == 0 || diffs[0].Field != "subject.common_name" || diffs[0].Kind != internal.DifferenceMismatch {
+		t.Errorf("Unexpected differences: %+v", diffs)
+	}
+}
+
+func TestDiff_Usage(t *testing.T) {
+	if code, _, _ := runTest(t, "", "diff", "profile.yaml"); code != 2 {
+		t.Errorf("Expected exit code 2 without a certificate, got %d", code)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// runExplain prints every value of the resolved profile with the segment or config it comes from
+func runExplain(args []string, e *env) error {
+	fs, in := newFlagSet("explain", e)
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, err := loadProfile(path, in, e)
+	if err != nil {
+		return err
+	}
+
+	names, specs, err := internal.ResolveSources(p.doc, internal.ResolveOptions{
+		ConflictMode: internal.ConflictModeOff,
+		Vars:         in.vars,
+		UseEnv:       in.useEnv,
+		EnvPrefix:    in.envPrefix,
+		IncludeFS:    p.opts.IncludeFS,
+		Strict:       p.opts.Strict,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stdout, "certificate: %s\n", p.name)
+	fmt.Fprintf(e.stdout, "merge order: %v\n\n", names)
+
+	w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tVALUE\tSOURCE")
+	for _, origin := range internal.ExplainSources(names, specs) {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", origin.Field, origin.Value, origin.Source)
+	}
+	return w.Flush()
+}
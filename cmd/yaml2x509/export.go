@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rschoonheim/go-yaml-to-x509/cfssl"
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+	"github.com/rschoonheim/go-yaml-to-x509/openssl"
+)
+
+// Export formats
+const (
+	formatOpenSSL     = "openssl"
+	formatCFSSLCSR    = "cfssl-csr"
+	formatCFSSLConfig = "cfssl-config"
+)
+
+// runExport renders a profile as an OpenSSL configuration or cfssl JSON, printing the
+// fields the format cannot express as warnings
+func runExport(args []string, e *env) error {
+	fs, in := newFlagSet("export", e)
+	format := fs.String("format", formatOpenSSL, "output format: openssl, cfssl-csr or cfssl-config")
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *format != formatOpenSSL && *format != formatCFSSLCSR && *format != formatCFSSLConfig {
+		return &usageError{msg: fmt.Sprintf("unknown format '%s', expected %s, %s or %s", *format, formatOpenSSL, formatCFSSLCSR, formatCFSSLConfig)}
+	}
+
+	p, err := loadProfile(path, in, e)
+	if err != nil {
+		return err
+	}
+
+	var out []byte
+	var warnings []fmt.Stringer
+	switch *format {
+	case formatOpenSSL:
+		var unsupported []openssl.Unsupported
+		out, unsupported, err = openssl.Export(p.spec)
+		for _, u := range unsupported {
+			warnings = append(warnings, u)
+		}
+	case formatCFSSLCSR:
+		var unsupported []cfssl.Unsupported
+		out, unsupported, err = cfssl.CSR(p.spec)
+		for _, u := range unsupported {
+			warnings = append(warnings, u)
+		}
+	case formatCFSSLConfig:
+		var unsupported []cfssl.Unsupported
+		out, unsupported, err = cfssl.Config(map[string]*internal.CertificateSpec{p.name: p.spec})
+		for _, u := range unsupported {
+			warnings = append(warnings, u)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, w := range warnings {
+		fmt.Fprintf(e.stderr, "warning: not exported: %s\n", w)
+	}
+	_, err = e.stdout.Write(out)
+	return err
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExport_OpenSSL(t *testing.T) {
+	code, stdout, stderr := runTest(t, testProfile, "export", "-name", "www", "-var", "host=api")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, expected := range []string{
+		"CN = api.example.com",
+		"keyUsage = critical, digitalSignature",
+		"extendedKeyUsage = serverAuth",
+		"DNS.1 = api.example.com",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain '%s', got:\n%s", expected, stdout)
+		}
+	}
+	if !strings.Contains(stderr, "warning: not exported: validity: pass -days 90") {
+		t.Errorf("Expected a warning for the validity, got %q", stderr)
+	}
+
+	// The exported configuration imports back to segments
+	code, imported, stderr := runTest(t, stdout, "import")
+	if code != 0 || !strings.Contains(imported, "- api.example.com") {
+		t.Errorf("Expected the export to import, got %d: %s\n%s", code, stderr, imported)
+	}
+}
+
+func TestExport_CFSSL(t *testing.T) {
+	code, stdout, stderr := runTest(t, testProfile, "export", "-format", "cfssl-csr", "-name", "ca", "-var", "host=api")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	var csr struct {
+		CN string
+		CA struct{ Expiry string }
+	}
+	if err := json.Unmarshal([]byte(stdout), &csr); err != nil || csr.CN != "Example CA" || csr.CA.Expiry != "8760h" {
+		t.Errorf("Unexpected CSR %+v (%v):\n%s", csr, err, stdout)
+	}
+
+	code, stdout, stderr = runTest(t, testProfile, "export", "-format", "cfssl-config", "-name", "www", "-var", "host=api")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	var config struct {
+		Signing struct {
+			Profiles map[string]struct {
+				Usages []string
+				Expiry string
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(stdout), &config); err != nil {
+		t.Fatal(err)
+	}
+	www := config.Signing.Profiles["www"]
+	if strings.Join(www.Usages, ",") != "digital signature,server auth" || www.Expiry != "2160h" {
+		t.Errorf("Unexpected signing profile %+v", www)
+	}
+	if !strings.Contains(stderr, "warning: not exported: www.issuer: the issuer name comes from the CA certificate") {
+		t.Errorf("Expected a warning for the issuer, got %q", stderr)
+	}
+}
+
+func TestExport_UnknownFormat(t *testing.T) {
+	code, _, stderr := runTest(t, testProfile, "export", "-format", "pem", "-name", "ca", "-var", "host=api")
+
+	if code != 2 || !strings.Contains(stderr, "unknown format 'pem'") {
+		t.Errorf("Expected a usage error, got %d: %s", code, stderr)
+	}
+}
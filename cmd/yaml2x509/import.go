@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/rschoonheim/go-yaml-to-x509/openssl"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runImport converts an OpenSSL configuration file to a YAML document of segments,
+// printing the settings it cannot convert as warnings
+func runImport(args []string, e *env) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	data, err := readInput(path, e)
+	if err != nil {
+		return err
+	}
+	doc, unsupported, err := openssl.Import(data)
+	if err != nil {
+		return err
+	}
+	for _, u := range unsupported {
+		fmt.Fprintf(e.stderr, "warning: not converted: %s\n", u)
+	}
+
+	encoder := yaml.NewEncoder(e.stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testOpenSSLConfig = `
+[req]
+prompt = no
+distinguished_name = dn
+x509_extensions = v3_ca
+
+[dn]
+CN = Example CA
+O = Example
+
+[v3_ca]
+basicConstraints = critical, CA:TRUE
+keyUsage = critical, keyCertSign, cRLSign
+nsCertType = sslCA
+`
+
+func TestImport(t *testing.T) {
+	code, stdout, stderr := runTest(t, testOpenSSLConfig, "import")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "warning: not converted: v3_ca.nsCertType: extension has no YAML equivalent") {
+		t.Errorf("Expected a warning for nsCertType, got %q", stderr)
+	}
+
+	// The imported document issues the CA it describes
+	code, _, stderr = runTest(t, stdout, "validate")
+	if code != 0 {
+		t.Fatalf("Expected the imported document to validate, got %d: %s\n%s", code, stderr, stdout)
+	}
+	code, build, stderr := runTest(t, stdout, "build", "-name", "v3_ca")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, expected := range []string{"common_name: Example CA", "is_ca: true", "- cert_sign"} {
+		if !strings.Contains(build, expected) {
+			t.Errorf("Expected output to contain '%s', got:\n%s", expected, build)
+		}
+	}
+}
+
+func TestImport_InvalidConfig(t *testing.T) {
+	code, _, stderr := runTest(t, "[ext]\nkeyUsage = signing\n", "import")
+
+	if code != 1 || !strings.Contains(stderr, "unknown key usage 'signing'") {
+		t.Errorf("Expected an error for the unknown key usage, got %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+	"github.com/rschoonheim/go-yaml-to-x509/policy"
+)
+
+// varFlag collects repeated -var name=value flags; repeating a name builds a list
+type varFlag map[string][]string
+
+func (v varFlag) String() string {
+	return ""
+}
+
+func (v varFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got '%s'", s)
+	}
+	v[name] = append(v[name], value)
+	return nil
+}
+
+// inputFlags are the flags shared by every command that reads a profile
+type inputFlags struct {
+	name         string
+	vars         varFlag
+	useEnv       bool
+	envPrefix    string
+	conflictMode string
+	policyPath   string
+	strict       bool
+	testSeed     string
+}
+
+// newFlagSet creates a flag set with the shared input flags registered
+func newFlagSet(name string, e *env) (*flag.FlagSet, *inputFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+
+	in := &inputFlags{vars: varFlag{}}
+	fs.StringVar(&in.name, "name", "", "certificate to use when the input defines several")
+	fs.Var(in.vars, "var", "set a variable as name=value (repeat a name for a list)")
+	fs.BoolVar(&in.useEnv, "env", false, "look up variables in the environment")
+	fs.StringVar(&in.envPrefix, "env-prefix", "", "prefix for environment variable names")
+	fs.StringVar(&in.conflictMode, "conflicts", "", "conflict mode: off, warn or error")
+	fs.StringVar(&in.policyPath, "policy", "", "issuance policy (YAML) every certificate must satisfy")
+	fs.BoolVar(&in.strict, "strict", false, "reject unknown keys and values instead of ignoring them")
+	fs.StringVar(&in.testSeed, "insecure-test-seed", "", "TEST ONLY: derive keys, serials and signatures from a seed for reproducible fixtures")
+
+	return fs, in
+}
+
+// parseFlags parses command-line flags and returns the input file argument
+func parseFlags(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return "", err
+		}
+		return "", &usageError{msg: err.Error()}
+	}
+
+	switch fs.NArg() {
+	case 0:
+		return "-", nil
+	case 1:
+		return fs.Arg(0), nil
+	default:
+		return "", &usageError{msg: "expected at most one input file"}
+	}
+}
+
+// options converts the input flags to library options for the input file at path,
+// printing merge conflicts found in "warn" mode and policy warnings to stderr.
+// Includes are read relative to the input file, or the working directory for stdin.
+func (in *inputFlags) options(path string, e *env) (yamltox509.Options, error) {
+	dir := "."
+	if path != "-" {
+		dir = filepath.Dir(path)
+	}
+
+	opts := yamltox509.Options{
+		ConflictMode: in.conflictMode,
+		OnConflict: func(c yamltox509.MergeConflict) {
+			fmt.Fprintf(e.stderr, "warning: %s\n", c)
+		},
+		Vars:      in.vars,
+		UseEnv:    in.useEnv,
+		EnvPrefix: in.envPrefix,
+		IncludeFS: os.DirFS(dir),
+		Strict:    in.strict,
+		OnPolicyWarning: func(r policy.Result) {
+			fmt.Fprintf(e.stderr, "warning: policy: %s: %s (rule '%s')\n", r.Field, r.Message, r.Rule)
+		},
+	}
+
+	if in.testSeed != "" {
+		opts.InsecureTestSeed = []byte(in.testSeed)
+	}
+
+	if in.policyPath != "" {
+		p, err := policy.Load(in.policyPath)
+		if err != nil {
+			return opts, err
+		}
+		opts.Policy = p
+	}
+
+	return opts, nil
+}
+
+// readInput reads a file, or standard input for "-"
+func readInput(path string, e *env) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(e.stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// profile is a single resolved certificate selected from the input
+type profile struct {
+	name string
+	spec *yamltox509.CertificateSpec
+	cert *x509.Certificate
+	doc  *yamltox509.ConfigDocument
+	opts yamltox509.Options
+}
+
+// testSeed returns the seed the profile's keys are derived from, or nil, printing a
+// warning when there is one
+func (p *profile) testSeed(e *env) []byte {
+	seed := p.opts.InsecureTestSeed
+	if seed == nil && p.spec.InsecureTestSeed != "" {
+		seed = []byte(p.spec.InsecureTestSeed)
+	}
+	if seed != nil {
+		fmt.Fprintln(e.stderr, "warning: insecure test seed in use, the generated keys are not secret")
+	}
+	return seed
+}
+
+// loadProfile reads, resolves and builds the input, and selects a single certificate
+func loadProfile(path string, in *inputFlags, e *env) (*profile, error) {
+	data, err := readInput(path, e)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := in.options(path, e)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := yamltox509.CertificatesFromYamlWithOptions(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := selectName(certs, in.name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the document again to find the spec behind the certificate.
+	// Conflicts were already reported while building.
+	docs, err := yamltox509.ParseDocumentsWithOptions(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	resolveOpts := opts
+	resolveOpts.ConflictMode = "off"
+	for index, doc := range docs {
+		if entryDoc, ok := doc.CertificateDocument(name); ok {
+			doc = entryDoc
+		} else if doc.Certificates != nil || doc.DocumentName(strconv.Itoa(index)) != name {
+			continue
+		}
+
+		spec, err := yamltox509.ResolveConfig(doc, resolveOpts)
+		if err != nil {
+			return nil, err
+		}
+		return &profile{name: name, spec: spec, cert: certs[name], doc: doc, opts: opts}, nil
+	}
+
+	return nil, fmt.Errorf("certificate '%s' not found", name)
+}
+
+// selectName picks the requested certificate, or the only one when none is requested
+func selectName(certs map[string]*x509.Certificate, name string) (string, error) {
+	if name != "" {
+		if _, ok := certs[name]; !ok {
+			return "", fmt.Errorf("certificate '%s' not found, available: %s", name, strings.Join(sortedNames(certs), ", "))
+		}
+		return name, nil
+	}
+
+	switch len(certs) {
+	case 0:
+		return "", errors.New("input defines no certificates")
+	case 1:
+		return sortedNames(certs)[0], nil
+	default:
+		return "", fmt.Errorf("input defines %d certificates, select one with -name: %s", len(certs), strings.Join(sortedNames(certs), ", "))
+	}
+}
+
+func sortedNames(certs map[string]*x509.Certificate) []string {
+	names := make([]string, 0, len(certs))
+	for name := range certs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
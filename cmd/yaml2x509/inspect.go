@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+
+	"gopkg.in/yaml.v3"
+)
+
+// revocationListInfo is the YAML representation of a certificate revocation list
+type revocationListInfo struct {
+	Issuer             map[string]string `yaml:"issuer,omitempty"`
+	Number             string            `yaml:"number,omitempty"`
+	ThisUpdate         string            `yaml:"this_update"`
+	NextUpdate         string            `yaml:"next_update,omitempty"`
+	SignatureAlgorithm string            `yaml:"signature_algorithm,omitempty"`
+	Revoked            []revokedInfo     `yaml:"revoked,omitempty"`
+}
+
+// revokedInfo is a single entry of a certificate revocation list
+type revokedInfo struct {
+	SerialNumber   string `yaml:"serial_number"`
+	RevocationTime string `yaml:"revocation_time"`
+	ReasonCode     int    `yaml:"reason_code,omitempty"`
+}
+
+// field is a computed value printed as a YAML comment above the object
+type field struct {
+	name  string
+	value string
+}
+
+// runInspect prints every certificate, CSR and CRL in a PEM or DER file as YAML
+func runInspect(args []string, e *env) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	data, err := readInput(path, e)
+	if err != nil {
+		return err
+	}
+
+	blocks, err := decodeObjects(data)
+	if err != nil {
+		return err
+	}
+
+	for i, block := range blocks {
+		if i > 0 {
+			fmt.Fprintln(e.stdout, "---")
+		}
+		if err := inspectObject(e.stdout, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeObjects returns the PEM blocks in data, or a single untyped block when
+// data is DER encoded
+func decodeObjects(data []byte) ([]*pem.Block, error) {
+	var blocks []*pem.Block
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+
+	if len(blocks) == 0 {
+		if len(bytes.TrimSpace(data)) == 0 {
+			return nil, errors.New("no certificate, CSR or CRL found")
+		}
+		blocks = append(blocks, &pem.Block{Bytes: data})
+	}
+	return blocks, nil
+}
+
+// inspectObject writes a single PEM block, or DER data when the block has no type
+func inspectObject(w io.Writer, block *pem.Block) error {
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return err
+		}
+		return writeInspected(w, "certificate", certificateFields(cert), internal.SpecFromCertificate(cert))
+
+	case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return err
+		}
+		return writeInspected(w, "certificate request", requestFields(csr), internal.SpecFromRequest(csr))
+
+	case "X509 CRL":
+		crl, err := x509.ParseRevocationList(block.Bytes)
+		if err != nil {
+			return err
+		}
+		return writeInspected(w, "certificate revocation list", fingerprintFields(crl.Raw), revocationListSpec(crl))
+
+	case "":
+		// DER input, try each supported object in turn
+		if _, err := x509.ParseCertificate(block.Bytes); err == nil {
+			return inspectObject(w, &pem.Block{Type: "CERTIFICATE", Bytes: block.Bytes})
+		}
+		if _, err := x509.ParseCertificateRequest(block.Bytes); err == nil {
+			return inspectObject(w, &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: block.Bytes})
+		}
+		if _, err := x509.ParseRevocationList(block.Bytes); err == nil {
+			return inspectObject(w, &pem.Block{Type: "X509 CRL", Bytes: block.Bytes})
+		}
+		return errors.New("input is not a PEM or DER encoded certificate, CSR or CRL")
+
+	default:
+		return fmt.Errorf("unsupported PEM block '%s'", block.Type)
+	}
+}
+
+// writeInspected writes the computed fields as comments followed by the object as YAML
+func writeInspected(w io.Writer, kind string, fields []field, v interface{}) error {
+	fmt.Fprintf(w, "# %s\n", kind)
+	for _, f := range fields {
+		fmt.Fprintf(w, "# %s: %s\n", f.name, f.value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// certificateFields returns the computed fields of a certificate that have no place in a profile
+func certificateFields(cert *x509.Certificate) []field {
+	fields := fingerprintFields(cert.Raw)
+	fields = append(fields, field{"public_key", describePublicKey(cert.PublicKey)})
+
+	if len(cert.SubjectKeyId) > 0 {
+		fields = append(fields, field{"subject_key_id", hex.EncodeToString(cert.SubjectKeyId)})
+	}
+	if len(cert.AuthorityKeyId) > 0 {
+		fields = append(fields, field{"authority_key_id", hex.EncodeToString(cert.AuthorityKeyId)})
+	}
+
+	selfSigned := bytes.Equal(cert.RawSubject, cert.RawIssuer) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+	fields = append(fields, field{"self_signed", fmt.Sprint(selfSigned)})
+	fields = append(fields, field{"validity_days", fmt.Sprint(days(cert.NotAfter.Sub(cert.NotBefore)))})
+	fields = append(fields, field{"status", validityStatus(cert, time.Now())})
+
+	for _, oid := range cert.UnknownExtKeyUsage {
+		fields = append(fields, field{"ext_key_usage_oid", oid.String()})
+	}
+	for _, server := range cert.OCSPServer {
+		fields = append(fields, field{"ocsp_server", server})
+	}
+	for _, url := range cert.IssuingCertificateURL {
+		fields = append(fields, field{"issuing_certificate_url", url})
+	}
+	for _, point := range cert.CRLDistributionPoints {
+		fields = append(fields, field{"crl_distribution_point", point})
+	}
+
+	return fields
+}
+
+// requestFields returns the computed fields of a certificate signing request
+func requestFields(csr *x509.CertificateRequest) []field {
+	fields := fingerprintFields(csr.Raw)
+	fields = append(fields, field{"public_key", describePublicKey(csr.PublicKey)})
+	fields = append(fields, field{"signature_valid", fmt.Sprint(csr.CheckSignature() == nil)})
+	return fields
+}
+
+// fingerprintFields returns the SHA-256 and SHA-1 fingerprints of DER data
+func fingerprintFields(der []byte) []field {
+	sha256Sum := sha256.Sum256(der)
+	sha1Sum := sha1.Sum(der)
+	return []field{
+		{"sha256_fingerprint", hex.EncodeToString(sha256Sum[:])},
+		{"sha1_fingerprint", hex.EncodeToString(sha1Sum[:])},
+	}
+}
+
+// describePublicKey returns the key type and size, e.g. "RSA 2048" or "ECDSA P-256"
+func describePublicKey(key interface{}) string {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA " + k.Curve.Params().Name
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return fmt.Sprintf("%T", key)
+	}
+}
+
+// validityStatus describes whether the certificate is valid at now
+func validityStatus(cert *x509.Certificate, now time.Time) string {
+	switch {
+	case now.Before(cert.NotBefore):
+		return fmt.Sprintf("not yet valid, starts in %d days", days(cert.NotBefore.Sub(now)))
+	case now.After(cert.NotAfter):
+		return fmt.Sprintf("expired %d days ago", days(now.Sub(cert.NotAfter)))
+	default:
+		return fmt.Sprintf("valid, expires in %d days", days(cert.NotAfter.Sub(now)))
+	}
+}
+
+// days returns d in whole days, rounded up
+func days(d time.Duration) int {
+	return int(math.Ceil(d.Hours() / 24))
+}
+
+// revocationListSpec converts a revocation list to its YAML representation
+func revocationListSpec(crl *x509.RevocationList) *revocationListInfo {
+	info := &revocationListInfo{
+		Issuer:             internal.FormatPkixName(crl.Issuer),
+		ThisUpdate:         crl.ThisUpdate.UTC().Format(time.RFC3339),
+		SignatureAlgorithm: internal.FormatSignatureAlgorithm(crl.SignatureAlgorithm),
+	}
+
+	if crl.Number != nil {
+		info.Number = "0x" + crl.Number.Text(16)
+	}
+	if !crl.NextUpdate.IsZero() {
+		info.NextUpdate = crl.NextUpdate.UTC().Format(time.RFC3339)
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		info.Revoked = append(info.Revoked, revokedInfo{
+			SerialNumber:   "0x" + entry.SerialNumber.Text(16),
+			RevocationTime: entry.RevocationTime.UTC().Format(time.RFC3339),
+			ReasonCode:     entry.ReasonCode,
+		})
+	}
+
+	return info
+}
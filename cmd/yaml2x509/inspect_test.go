@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+)
+
+// issueTestCertificate issues the 'www' test profile and returns the certificate path
+func issueTestCertificate(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	code, _, stderr := runTest(t, testProfile, "issue", "-name", "www", "-var", "host=www",
+		"-cert-out", certPath, "-key-out", filepath.Join(dir, "key.pem"))
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 issuing certificate, got %d: %s", code, stderr)
+	}
+	return certPath
+}
+
+func TestInspect_Certificate(t *testing.T) {
+	certPath := issueTestCertificate(t)
+
+	code, stdout, stderr := runTest(t, "", "inspect", certPath)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	for _, expected := range []string{
+		"# certificate\n",
+		"# sha256_fingerprint: ",
+		"# public_key: ECDSA P-256\n",
+		"# self_signed: true\n",
+		"# validity_days: 90\n",
+		"common_name: www.example.com",
+		"- server_auth",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, stdout)
+		}
+	}
+
+	// The output is a profile that builds the same certificate shape
+	cert, err := yamltox509.X509FromYaml([]byte(stdout))
+	if err != nil {
+		t.Fatalf("Expected inspected output to build, got %v", err)
+	}
+	original, err := x509.ParseCertificate(readTestPEM(t, certPath, "CERTIFICATE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.SerialNumber.Cmp(original.SerialNumber) != 0 || !cert.NotAfter.Equal(original.NotAfter) ||
+		cert.KeyUsage != original.KeyUsage || cert.Subject.String() != original.Subject.String() {
+		t.Errorf("Expected rebuilt certificate to match the original, got %+v", cert)
+	}
+}
+
+func TestInspect_DER(t *testing.T) {
+	der := readTestPEM(t, issueTestCertificate(t), "CERTIFICATE")
+
+	code, stdout, stderr := runTest(t, string(der), "inspect")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.HasPrefix(stdout, "# certificate\n") {
+		t.Errorf("Expected DER certificate to be inspected, got:\n%s", stdout)
+	}
+}
+
+func TestInspect_CertificateRequest(t *testing.T) {
+	csrPath := filepath.Join(t.TempDir(), "csr.pem")
+	code, _, stderr := runTest(t, testProfile, "csr", "-name", "www", "-var", "host=api",
+		"-csr-out", csrPath, "-key-out", filepath.Join(t.TempDir(), "key.pem"))
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 creating CSR, got %d: %s", code, stderr)
+	}
+
+	code, stdout, stderr := runTest(t, "", "inspect", csrPath)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, expected := range []string{"# certificate request\n", "# signature_valid: true\n", "- api.example.com"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestInspect_RevocationListAndBundle(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Example CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	issuerDER, err := x509.CreateCertificate(rand.Reader, issuer, issuer, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err = x509.ParseCertificate(issuerDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlDER, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:     big.NewInt(7),
+		ThisUpdate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NextUpdate: time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+		RevokedCertificateEntries: []x509.RevocationListEntry{
+			{SerialNumber: big.NewInt(0xabc), RevocationTime: time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC), ReasonCode: 1},
+		},
+	}, issuer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: issuerDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: crlDER})...)
+	path := filepath.Join(t.TempDir(), "bundle.pem")
+	if err := os.WriteFile(path, bundle, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runTest(t, "", "inspect", path)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, expected := range []string{
+		"# certificate\n",
+		"\n---\n# certificate revocation list\n",
+		"number: \"0x7\"",
+		"next_update: \"2024-01-08T00:00:00Z\"",
+		"serial_number: \"0xabc\"",
+		"reason_code: 1",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestInspect_InvalidInput(t *testing.T) {
+	if code, _, stderr := runTest(t, "not a certificate", "inspect"); code != 1 || !strings.Contains(stderr, "not a PEM or DER encoded") {
+		t.Errorf("Expected exit code 1 for invalid input, got %d: %s", code, stderr)
+	}
+	if code, _, stderr := runTest(t, "", "inspect"); code != 1 || !strings.Contains(stderr, "no certificate, CSR or CRL found") {
+		t.Errorf("Expected exit code 1 for empty input, got %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+	"github.com/rschoonheim/go-yaml-to-x509/kube"
+	"github.com/rschoonheim/go-yaml-to-x509/store"
+)
+
+// runIssue issues a certificate from a profile and writes the certificate and key as PEM files
+func runIssue(args []string, e *env) error {
+	fs, in := newFlagSet("issue", e)
+	caCertPath := fs.String("ca-cert", "", "issuing CA certificate (PEM); the profile's signer or self-signed when omitted")
+	caKeyPath := fs.String("ca-key", "", "issuing CA private key (PEM)")
+	certOut := fs.String("cert-out", "cert.pem", "path to write the certificate")
+	keyOut := fs.String("key-out", "key.pem", "path to write the generated private key")
+	storePath := fs.String("store", "", "JSON lines issuance store to record the certificate in")
+	secretOut := fs.String("secret-out", "", "path to also write a kubernetes.io/tls Secret manifest")
+	secretName := fs.String("secret-name", "", "name of the Secret, the certificate name when omitted")
+	secretNamespace := fs.String("secret-namespace", "", "namespace of the Secret")
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if (*caCertPath == "") != (*caKeyPath == "") {
+		return &usageError{msg: "-ca-cert and -ca-key must be used together"}
+	}
+
+	p, err := loadProfile(path, in, e)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	req := yamltox509.IssueRequest{Template: p.cert}
+	if *caCertPath != "" {
+		if req.Parent, err = readCertificate(*caCertPath); err != nil {
+			return err
+		}
+		if req.Signer, err = readPrivateKey(*caKeyPath); err != nil {
+			return err
+		}
+	} else if req, err = yamltox509.NewIssueRequestWithOptions(ctx, p.cert, p.spec, p.opts); err != nil {
+		return err
+	}
+	if closer, ok := req.Signer.(io.Closer); ok {
+		defer closer.Close()
+	}
+	req.Profile = p.name
+	req.InsecureTestSeed = p.testSeed(e)
+
+	opts := p.opts
+	if *storePath != "" {
+		if opts.Store, err = store.OpenFileStore(*storePath); err != nil {
+			return err
+		}
+	}
+
+	issued, err := yamltox509.IssueContext(ctx, req, opts)
+	if err != nil {
+		return err
+	}
+
+	// Write the key first, so that a failure to encrypt it leaves no certificate without a key
+	if issued.PrivateKey != nil {
+		if err := writePrivateKey(*keyOut, p, issued.PrivateKey); err != nil {
+			return err
+		}
+	}
+	if err := writePEM(*certOut, "CERTIFICATE", issued.Certificate.Raw, 0o644); err != nil {
+		return err
+	}
+	if *secretOut != "" {
+		if err := writeSecret(*secretOut, kube.Metadata{Name: *secretName, Namespace: *secretNamespace}, p, req, issued); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(e.stdout, "issued %s serial %s\n", issued.Certificate.Subject, store.SerialString(issued.Certificate.SerialNumber))
+	return nil
+}
+
+// writeSecret writes the issued certificate and key as a kubernetes.io/tls Secret,
+// with the issuing CA, or the certificate itself when self-signed, as ca.crt. A
+// certificate signed by a signer without a certificate has no ca.crt.
+func writeSecret(path string, meta kube.Metadata, p *profile, req yamltox509.IssueRequest, issued *yamltox509.IssuedCertificate) error {
+	if issued.PrivateKey == nil {
+		return fmt.Errorf("-secret-out requires a generated private key, '%s' certifies a public_key", p.name)
+	}
+	if meta.Name == "" {
+		meta.Name = p.name
+	}
+	ca := req.Parent
+	if ca == nil && req.Signer == nil {
+		ca = issued.Certificate
+	}
+
+	data, err := kube.TLSSecret(meta, []*x509.Certificate{issued.Certificate}, issued.PrivateKey, ca)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rschoonheim/go-yaml-to-x509/store"
+
+	"gopkg.in/yaml.v3"
+)
+
+func readTestPEM(t *testing.T, path, blockType string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		t.Fatalf("Expected %s PEM block in %s", blockType, path)
+	}
+	return block.Bytes
+}
+
+func TestIssue_SelfSignedAndCASigned(t *testing.T) {
+	dir := t.TempDir()
+	profile := writeTestFile(t, "profile.yaml", testProfile)
+	caCert := filepath.Join(dir, "ca.pem")
+	caKey := filepath.Join(dir, "ca-key.pem")
+	storePath := filepath.Join(dir, "issued.jsonl")
+
+	code, stdout, stderr := runTest(t, "", "issue", "-name", "ca", "-var", "host=www", "-cert-out", caCert, "-key-out", caKey, profile)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 issuing CA, got %d: %s", code, stderr)
+	}
+	if !strings.HasPrefix(stdout, "issued CN=Example CA serial ") {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+
+	leafCert := filepath.Join(dir, "www.pem")
+	code, _, stderr = runTest(t, "", "issue", "-name", "www", "-var", "host=www",
+		"-ca-cert", caCert, "-ca-key", caKey, "-cert-out", leafCert, "-key-out", filepath.Join(dir, "www-key.pem"),
+		"-store", storePath, profile)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 issuing leaf, got %d: %s", code, stderr)
+	}
+
+	ca, err := x509.ParseCertificate(readTestPEM(t, caCert, "CERTIFICATE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(readTestPEM(t, leafCert, "CERTIFICATE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leaf.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("Expected leaf signed by CA, got %v", err)
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(readTestPEM(t, caKey, "PRIVATE KEY")); err != nil {
+		t.Errorf("Expected PKCS#8 private key, got %v", err)
+	}
+
+	s, err := store.OpenFileStore(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, err := s.Get(leaf.SerialNumber)
+	if err != nil || record.Profile != "www" {
+		t.Errorf("Expected leaf recorded under profile 'www', got %+v, %v", record, err)
+	}
+}
+
+func TestIssue_ProfileSigner(t *testing.T) {
+	dir := t.TempDir()
+	caCert := filepath.Join(dir, "ca.pem")
+	caKey := filepath.Join(dir, "ca-key.pem")
+
+	code, _, stderr := runTest(t, testProfile, "issue", "-name", "ca", "-var", "host=www", "-cert-out", caCert, "-key-out", caKey)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 issuing CA, got %d: %s", code, stderr)
+	}
+
+	profile := writeTestFile(t, "signed.yaml", `
+subject:
+  common_name: "www.example.com"
+signer:
+  type: file
+  certificate: "`+caCert+`"
+  path: "`+caKey+`"
+`)
+	leafCert := filepath.Join(dir, "www.pem")
+	code, _, stderr = runTest(t, "", "issue", "-cert-out", leafCert, "-key-out", filepath.Join(dir, "www-key.pem"), profile)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 issuing with the profile's signer, got %d: %s", code, stderr)
+	}
+
+	ca, err := x509.ParseCertificate(readTestPEM(t, caCert, "CERTIFICATE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(readTestPEM(t, leafCert, "CERTIFICATE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := leaf.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("Expected leaf signed by the profile's signer, got %v", err)
+	}
+
+	profile = writeTestFile(t, "unknown.yaml", "subject:\n  common_name: a\nsigner:\n  type: vault\n")
+	code, _, stderr = runTest(t, "", "issue", "-cert-out", leafCert, "-key-out", filepath.Join(dir, "www-key.pem"), profile)
+	if code != 1 || !strings.Contains(stderr, "unknown signer type 'vault'") {
+		t.Errorf("Expected unknown signer type error, got %d: %s", code, stderr)
+	}
+}
+
+func TestIssue_EncryptedKey(t *testing.T) {
+	dir := t.TempDir()
+	caCert := filepath.Join(dir, "ca.pem")
+	caKey := filepath.Join(dir, "ca-key.pem")
+	t.Setenv("TEST_CA_PASSPHRASE", "secret")
+
+	profile := writeTestFile(t, "ca.yaml", `
+subject:
+  common_name: "Example CA"
+is_ca: true
+basic_constraints_valid: true
+key_encryption:
+  passphrase:
+    env: TEST_CA_PASSPHRASE
+`)
+	code, _, stderr := runTest(t, "", "issue", "-cert-out", caCert, "-key-out", caKey, profile)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 issuing CA, got %d: %s", code, stderr)
+	}
+	readTestPEM(t, caKey, "ENCRYPTED PRIVATE KEY")
+
+	code, _, stderr = runTest(t, testProfile, "issue", "-name", "www", "-var", "host=www", "-ca-cert", caCert, "-ca-key", caKey,
+		"-cert-out", filepath.Join(dir, "www.pem"), "-key-out", filepath.Join(dir, "www-key.pem"))
+	if code != 1 || !strings.Contains(stderr, "key is encrypted, use an encrypted-pem signer") {
+		t.Errorf("Expected encrypted -ca-key to be rejected, got %d: %s", code, stderr)
+	}
+
+	profile = writeTestFile(t, "www.yaml", `
+subject:
+  common_name: "www.example.com"
+signer:
+  type: encrypted-pem
+  certificate: "`+caCert+`"
+  path: "`+caKey+`"
+  passphrase:
+    env: TEST_CA_PASSPHRASE
+`)
+	code, _, stderr = runTest(t, "", "issue", "-cert-out", filepath.Join(dir, "www.pem"), "-key-out", filepath.Join(dir, "www-key.pem"), profile)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 issuing with the encrypted CA key, got %d: %s", code, stderr)
+	}
+	readTestPEM(t, filepath.Join(dir, "www-key.pem"), "PRIVATE KEY")
+}
+
+func TestIssue_PublicKey(t *testing.T) {
+	dir := t.TempDir()
+	caCert := filepath.Join(dir, "ca.pem")
+	caKey := filepath.Join(dir, "ca-key.pem")
+	csrOut := filepath.Join(dir, "device.csr")
+
+	code, _, stderr := runTest(t, testProfile, "issue", "-name", "ca", "-var", "host=www", "-cert-out", caCert, "-key-out", caKey)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 issuing CA, got %d: %s", code, stderr)
+	}
+	code, _, stderr = runTest(t, testProfile, "csr", "-name", "www", "-var", "host=device", "-csr-out", csrOut, "-key-out", filepath.Join(dir, "device-key.pem"))
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 creating request, got %d: %s", code, stderr)
+	}
+
+	profile := writeTestFile(t, "device.yaml", `
+subject:
+  common_name: "device"
+public_key: "`+csrOut+`"
+`)
+	certOut := filepath.Join(dir, "device.pem")
+	keyOut := filepath.Join(dir, "unused-key.pem")
+	code, _, stderr = runTest(t, "", "issue", "-ca-cert", caCert, "-ca-key", caKey, "-cert-out", certOut, "-key-out", keyOut, profile)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 issuing for the request's key, got %d: %s", code, stderr)
+	}
+
+	csr, err := x509.ParseCertificateRequest(readTestPEM(t, csrOut, "CERTIFICATE REQUEST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(readTestPEM(t, certOut, "CERTIFICATE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(cert.RawSubjectPublicKeyInfo, csr.RawSubjectPublicKeyInfo) {
+		t.Error("Expected the certificate to certify the request's key")
+	}
+	if _, err := os.Stat(keyOut); !os.IsNotExist(err) {
+		t.Errorf("Expected no key file for a supplied public key, got %v", err)
+	}
+
+	code, _, stderr = runTest(t, "", "csr", "-csr-out", filepath.Join(dir, "again.csr"), "-key-out", keyOut, profile)
+	if code != 1 || !strings.Contains(stderr, "has a public_key") {
+		t.Errorf("Expected csr to reject a profile with a public_key, got %d: %s", code, stderr)
+	}
+}
+
+func TestIssue_InsecureTestSeed(t *testing.T) {
+	dir := t.TempDir()
+
+	issue := func(name string) ([]byte, []byte) {
+		certOut := filepath.Join(dir, name+".pem")
+		keyOut := filepath.Join(dir, name+"-key.pem")
+		code, _, stderr := runTest(t, testProfile, "issue", "-name", "ca", "-var", "host=www", "-insecure-test-seed", "golden",
+			"-cert-out", certOut, "-key-out", keyOut)
+		if code != 0 {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+		if !strings.Contains(stderr, "warning: insecure test seed in use") {
+			t.Errorf("Expected a test seed warning, got %q", stderr)
+		}
+		return readTestPEM(t, certOut, "CERTIFICATE"), readTestPEM(t, keyOut, "PRIVATE KEY")
+	}
+
+	cert, key := issue("first")
+	again, againKey := issue("second")
+	if !bytes.Equal(cert, again) || !bytes.Equal(key, againKey) {
+		t.Error("Expected the same certificate and key from the same seed")
+	}
+
+	csrOut := filepath.Join(dir, "www.csr")
+	code, _, stderr := runTest(t, testProfile, "csr", "-name", "www", "-var", "host=www", "-insecure-test-seed", "golden",
+		"-csr-out", csrOut, "-key-out", filepath.Join(dir, "www-key.pem"))
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	csr := readTestPEM(t, csrOut, "CERTIFICATE REQUEST")
+	runTest(t, testProfile, "csr", "-name", "www", "-var", "host=www", "-insecure-test-seed", "golden",
+		"-csr-out", csrOut, "-key-out", filepath.Join(dir, "www-key.pem"))
+	if !bytes.Equal(csr, readTestPEM(t, csrOut, "CERTIFICATE REQUEST")) {
+		t.Error("Expected the same request from the same seed")
+	}
+}
+
+func TestIssue_Secret(t *testing.T) {
+	dir := t.TempDir()
+	caCert := filepath.Join(dir, "ca.pem")
+	caKey := filepath.Join(dir, "ca-key.pem")
+	secretOut := filepath.Join(dir, "www-secret.yaml")
+
+	code, _, stderr := runTest(t, testProfile, "issue", "-name", "ca", "-var", "host=www", "-cert-out", caCert, "-key-out", caKey)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0 issuing CA, got %d: %s", code, stderr)
+	}
+	code, _, stderr = runTest(t, testProfile, "issue", "-name", "www", "-var", "host=www",
+		"-ca-cert", caCert, "-ca-key", caKey, "-cert-out", filepath.Join(dir, "www.pem"), "-key-out", filepath.Join(dir, "www-key.pem"),
+		"-secret-out", secretOut, "-secret-namespace", "web")
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	data, err := os.ReadFile(secretOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var secret struct {
+		Metadata struct{ Name, Namespace string }
+		Type     string
+		Data     map[string]string
+	}
+	if err := yaml.Unmarshal(data, &secret); err != nil {
+		t.Fatalf("Expected a YAML manifest, got %v", err)
+	}
+	if secret.Metadata.Name != "www" || secret.Metadata.Namespace != "web" || secret.Type != "kubernetes.io/tls" {
+		t.Errorf("Unexpected Secret %+v", secret)
+	}
+
+	decoded := make(map[string][]byte)
+	for key, value := range secret.Data {
+		if decoded[key], err = base64.StdEncoding.DecodeString(value); err != nil {
+			t.Fatalf("Expected %s to be base64, got %v", key, err)
+		}
+	}
+
+	pair, err := tls.X509KeyPair(decoded["tls.crt"], decoded["tls.key"])
+	if err != nil {
+		t.Fatalf("Expected tls.crt and tls.key to form a key pair, got %v", err)
+	}
+	block, _ := pem.Decode(decoded["ca.crt"])
+	if block == nil || !bytes.Equal(block.Bytes, readTestPEM(t, caCert, "CERTIFICATE")) {
+		t.Error("Expected ca.crt to hold the issuing CA")
+	}
+	if pair.Leaf.Subject.CommonName != "www.example.com" {
+		t.Errorf("Unexpected certificate %s", pair.Leaf.Subject)
+	}
+
+	code, _, stderr = runTest(t, testProfile, "issue", "-name", "ca", "-var", "host=www", "-cert-out", caCert, "-key-out", caKey,
+		"-secret-out", secretOut, "-secret-name", "Example_CA")
+	if code != 1 || !strings.Contains(stderr, "invalid name 'Example_CA'") {
+		t.Errorf("Expected an invalid Secret name to fail, got %d: %s", code, stderr)
+	}
+}
+
+func TestIssue_UnnamedDocumentProfile(t *testing.T) {
+	dir := t.TempDir()
+	profile := writeTestFile(t, "unnamed.yaml", `
+subject:
+  common_name: "unnamed.example.com"
+public_key_algorithm: ECDSA
+`)
+	storePath := filepath.Join(dir, "issued.jsonl")
+	args := []string{"issue", "-cert-out", filepath.Join(dir, "cert.pem"), "-key-out", filepath.Join(dir, "key.pem"), "-store", storePath}
+
+	// key_size is only known at issuance, so the rule applies there or not at all
+	policyPath := writeTestFile(t, "policy.yaml", `
+rules:
+  - name: strong-keys
+    profiles: ["0"]
+    field: key_size
+    min: "384"
+`)
+	code, _, stderr := runTest(t, "", append(args, "-policy", policyPath, profile)...)
+	if code != 1 || !strings.Contains(stderr, "profile '0': policy denies key_size") {
+		t.Fatalf("Expected the profile-scoped rule to deny issuance, got %d: %s", code, stderr)
+	}
+
+	code, _, stderr = runTest(t, "", append(args, profile)...)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	s, err := store.OpenFileStore(storePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := s.List(store.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Profile != "0" {
+		t.Errorf("Expected one record for profile '0', got %+v", records)
+	}
+}
+
+func TestIssue_CAFlagsTogether(t *testing.T) {
+	code, _, _ := runTest(t, testProfile, "issue", "-name", "ca", "-var", "host=www", "-ca-cert", "ca.pem")
+
+	if code != 2 {
+		t.Errorf("Expected exit code 2 for -ca-cert without -ca-key, got %d", code)
+	}
+}
+
+func TestCSR(t *testing.T) {
+	dir := t.TempDir()
+	csrPath := filepath.Join(dir, "csr.pem")
+
+	code, _, stderr := runTest(t, testProfile, "csr", "-name", "www", "-var", "host=api",
+		"-csr-out", csrPath, "-key-out", filepath.Join(dir, "key.pem"))
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	csr, err := x509.ParseCertificateRequest(readTestPEM(t, csrPath, "CERTIFICATE REQUEST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Errorf("Expected valid CSR signature, got %v", err)
+	}
+	if csr.Subject.CommonName != "api.example.com" || len(csr.DNSNames) != 1 {
+		t.Errorf("Unexpected CSR subject or SANs: %s %v", csr.Subject, csr.DNSNames)
+	}
+}
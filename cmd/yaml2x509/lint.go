@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rschoonheim/go-yaml-to-x509/lint"
+)
+
+// ruleSets maps the names accepted by -rules to the built-in rule sets
+var ruleSets = map[string]func() lint.RuleSet{
+	"default": lint.Default,
+	"profile": lint.Profile,
+	"rfc5280": lint.RFC5280,
+	"cabf":    lint.CABFBaseline,
+}
+
+// runLint checks a profile and the certificate built from it against lint rules
+func runLint(args []string, e *env) error {
+	fs, in := newFlagSet("lint", e)
+	ruleNames := fs.String("rules", "default", "comma-separated rule sets: default, profile, rfc5280 or cabf")
+	failOn := fs.String("fail-on", "error", "lowest severity that fails the command: notice, warning or error")
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	threshold, err := lint.ParseSeverity(*failOn)
+	if err != nil {
+		return &usageError{msg: err.Error()}
+	}
+
+	var rules lint.RuleSet
+	for _, name := range strings.Split(*ruleNames, ",") {
+		ruleSet, ok := ruleSets[strings.TrimSpace(name)]
+		if !ok {
+			return &usageError{msg: fmt.Sprintf("unknown rule set '%s'", name)}
+		}
+		rules = append(rules, ruleSet()...)
+	}
+
+	p, err := loadProfile(path, in, e)
+	if err != nil {
+		return err
+	}
+
+	findings := rules.Run(lint.Target{Spec: p.spec, Certificate: p.cert})
+	for _, f := range findings {
+		fmt.Fprintln(e.stdout, f)
+	}
+
+	if lint.MaxSeverity(findings) >= threshold {
+		return fmt.Errorf("profile '%s' failed linting with %d findings", p.name, len(findings))
+	}
+	fmt.Fprintf(e.stdout, "ok %s\n", p.name)
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLint_Profile(t *testing.T) {
+	code, stdout, stderr := runTest(t, testProfile, "lint", "-name", "ca", "-var", "host=www")
+
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s%s", code, stdout, stderr)
+	}
+	if stdout != "ok ca\n" {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+}
+
+func TestLint_Findings(t *testing.T) {
+	profile := `
+subject:
+  common_name: "www.example.com"
+ext_key_usage: [server_auth]
+dns_names: ["example.com"]
+validity:
+  duration: "90d"
+`
+	code, stdout, _ := runTest(t, profile, "lint")
+	if code != 0 || !strings.Contains(stdout, "warning: subject.common_name: common name 'www.example.com' is not listed") {
+		t.Errorf("Expected warning with exit code 0, got %d: %s", code, stdout)
+	}
+
+	code, _, stderr := runTest(t, profile, "lint", "-fail-on", "warning")
+	if code != 1 || !strings.Contains(stderr, "failed linting with 1 findings") {
+		t.Errorf("Expected exit code 1 with -fail-on warning, got %d: %s", code, stderr)
+	}
+
+	if code, _, _ := runTest(t, profile, "lint", "-rules", "rfc5280", "-fail-on", "warning"); code != 0 {
+		t.Errorf("Expected rfc5280 rules alone to pass, got exit code %d", code)
+	}
+}
+
+func TestLint_Usage(t *testing.T) {
+	if code, _, _ := runTest(t, testProfile, "lint", "-rules", "nist"); code != 2 {
+		t.Errorf("Expected exit code 2 for unknown rule set, got %d", code)
+	}
+	if code, _, _ := runTest(t, testProfile, "lint", "-fail-on", "fatal"); code != 2 {
+		t.Errorf("Expected exit code 2 for unknown severity, got %d", code)
+	}
+}
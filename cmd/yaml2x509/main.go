@@ -0,0 +1,112 @@
+// Command yaml2x509 builds, validates and issues X.509 certificates from YAML profiles,
+// and inspects existing certificates.
+//
+// Usage:
+//
+//	yaml2x509 <command> [flags] [file]
+//
+// Commands read the YAML profile from file, or from standard input when file is
+// omitted or "-". Exit codes are 0 on success, 1 when the command fails and 2 on
+// invalid usage.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// env holds the standard streams used by a command
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// command is a yaml2x509 subcommand
+type command struct {
+	summary string
+	run     func(args []string, e *env) error
+}
+
+// usageError marks errors caused by invalid command-line usage
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+var commands = map[string]command{
+	"build":        {"resolve a profile and print the certificate template as YAML", runBuild},
+	"issue":        {"issue a self-signed or CA-signed certificate and write PEM files", runIssue},
+	"csr":          {"generate a key and certificate signing request from a profile", runCSR},
+	"validate":     {"check that a profile resolves and builds", runValidate},
+	"lint":         {"check a profile against RFC 5280 and CA/Browser Forum rules", runLint},
+	"explain":      {"show which segment or config each value comes from", runExplain},
+	"diff":         {"compare a certificate with the profile it should conform to", runDiff},
+	"verify":       {"issue the certificates of 'verify' sections and check their chains", runVerify},
+	"cert-manager": {"convert a profile to a cert-manager Certificate resource", runCertManager},
+	"import":       {"convert an OpenSSL configuration file to YAML segments", runImport},
+	"export":       {"render a profile as an OpenSSL configuration or cfssl JSON", runExport},
+	"schema":       {"print the JSON Schema of the YAML format for editor validation", runSchema},
+	"inspect":      {"print a PEM or DER certificate, CSR or CRL in the YAML profile schema", runInspect},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
+
+// run executes the command line and returns the process exit code
+func run(args []string, e *env) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		printUsage(e.stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "yaml2x509: unknown command '%s'\n", args[0])
+		printUsage(e.stderr)
+		return 2
+	}
+
+	if err := cmd.run(args[1:], e); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(e.stderr, "yaml2x509 %s: %v\n", args[0], err)
+		var usageErr *usageError
+		if errors.As(err, &usageErr) {
+			return 2
+		}
+		return 1
+	}
+
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: yaml2x509 <command> [flags] [file]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].summary)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'yaml2x509 <command> -h' for command flags.")
+}
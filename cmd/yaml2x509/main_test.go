@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testProfile = `
+segments:
+  defaults:
+    issuer:
+      common_name: "Example CA"
+    key_usage:
+      - digital_signature
+    validity:
+      duration: "90d"
+  web-server:
+    ext_key_usage:
+      - server_auth
+
+certificates:
+  www:
+    merge: [defaults, web-server]
+    config:
+      subject:
+        common_name: "${host}.example.com"
+      dns_names:
+        - "${host}.example.com"
+  ca:
+    config:
+      subject:
+        common_name: "Example CA"
+      validity:
+        duration: "1y"
+      is_ca: true
+      basic_constraints_valid: true
+      key_usage: [cert_sign, crl_sign]
+`
+
+// runTest runs the command line with the given stdin and returns the exit code and output
+func runTest(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := run(args, &env{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr})
+	return code, stdout.String(), stderr.String()
+}
+
+// writeTestFile writes content to a file in a temporary directory
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun_Usage(t *testing.T) {
+	if code, _, stderr := runTest(t, ""); code != 2 || !strings.Contains(stderr, "Commands:") {
+		t.Errorf("Expected usage with exit code 2, got %d: %s", code, stderr)
+	}
+	if code, _, _ := runTest(t, "", "help"); code != 0 {
+		t.Errorf("Expected exit code 0 for help, got %d", code)
+	}
+	if code, _, stderr := runTest(t, "", "frobnicate"); code != 2 || !strings.Contains(stderr, "unknown command 'frobnicate'") {
+		t.Errorf("Expected unknown command with exit code 2, got %d: %s", code, stderr)
+	}
+	if code, _, _ := runTest(t, "", "build", "-h"); code != 0 {
+		t.Errorf("Expected exit code 0 for command help, got %d", code)
+	}
+	if code, _, _ := runTest(t, "", "build", "-bogus"); code != 2 {
+		t.Errorf("Expected exit code 2 for unknown flag, got %d", code)
+	}
+}
+
+func TestBuild_FromStdin(t *testing.T) {
+	code, stdout, stderr := runTest(t, testProfile, "build", "-name", "www", "-var", "host=api")
+
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	for _, expected := range []string{"common_name: api.example.com", "- server_auth", "duration: 90d"} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected output to contain '%s', got:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestBuild_RequiresName(t *testing.T) {
+	code, _, stderr := runTest(t, testProfile, "build", "-var", "host=api")
+
+	if code != 1 || !strings.Contains(stderr, "select one with -name: ca, www") {
+		t.Errorf("Expected error listing certificates, got %d: %s", code, stderr)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	path := writeTestFile(t, "profile.yaml", testProfile)
+
+	code, stdout, stderr := runTest(t, "", "validate", "-var", "host=api", path)
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if stdout != "ok ca\nok www\n" {
+		t.Errorf("Unexpected output: %q", stdout)
+	}
+
+	code, _, stderr = runTest(t, "", "validate", path)
+	if code != 1 || !strings.Contains(stderr, "undefined variable 'host'") {
+		t.Errorf("Expected undefined variable error with exit code 1, got %d: %s", code, stderr)
+	}
+}
+
+func TestValidate_Policy(t *testing.T) {
+	profile := writeTestFile(t, "profile.yaml", testProfile)
+	policy := writeTestFile(t, "policy.yaml", `
+rules:
+  - name: api-only
+    profiles: [www]
+    field: dns_names
+    allow: ["api.example.com"]
+  - name: country
+    action: warn
+    field: subject.country
+    required: true
+`)
+
+	code, _, stderr := runTest(t, "", "validate", "-var", "host=api", "-policy", policy, profile)
+	if code != 0 || !strings.Contains(stderr, "warning: policy: subject.country: a value is required (rule 'country')") {
+		t.Errorf("Expected policy warnings with exit code 0, got %d: %s", code, stderr)
+	}
+
+	code, _, stderr = runTest(t, "", "validate", "-var", "host=www", "-policy", policy, profile)
+	if code != 1 || !strings.Contains(stderr, "profile 'www': policy denies dns_names: 'www.example.com' is not allowed (rule 'api-only')") {
+		t.Errorf("Expected policy violation with exit code 1, got %d: %s", code, stderr)
+	}
+
+	code, _, stderr = runTest(t, "", "validate", "-policy", writeTestFile(t, "bad.yaml", "rules:\n  - field: serial\n    required: true\n"), profile)
+	if code != 1 || !strings.Contains(stderr, "unknown field 'serial'") {
+		t.Errorf("Expected invalid policy error with exit code 1, got %d: %s", code, stderr)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	code, stdout, stderr := runTest(t, testProfile, "explain", "-name", "www", "-var", "host=api", "-")
+
+	if code != 0 {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "merge order: [defaults web-server config]") {
+		t.Errorf("Expected merge order, got:\n%s", stdout)
+	}
+	for _, line := range [][]string{
+		{"issuer.common_name", "Example CA", "defaults"},
+		{"subject.common_name", "api.example.com", "config"},
+		{"ext_key_usage", "server_auth", "web-server"},
+	} {
+		found := false
+		for _, outputLine := range strings.Split(stdout, "\n") {
+			if strings.Join(strings.Fields(outputLine), " ") == strings.Join(line, " ") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected line %v in output:\n%s", line, stdout)
+		}
+	}
+}
+
+func TestValidate_StrictAndIncludes(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "segments.yaml"), []byte("segments:\n  tls:\n    ext_key_usage: [server_auth]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	profile := filepath.Join(dir, "profile.yaml")
+	if err := os.WriteFile(profile, []byte("include: [segments.yaml]\nmerge: [tls]\nconfig:\n  subject:\n    common_name: www\n  key_usage: [digitl_signature]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if code, stdout, stderr := runTest(t, "", "validate", profile); code != 0 || stdout != "ok 0\n" {
+		t.Errorf("Expected includes relative to the profile to resolve, got %d: %s%s", code, stdout, stderr)
+	}
+
+	code, _, stderr := runTest(t, "", "validate", "-strict", profile)
+	if code != 1 || !strings.Contains(stderr, "key_usage: unknown value 'digitl_signature'") {
+		t.Errorf("Expected strict mode to reject unknown values, got %d: %s", code, stderr)
+	}
+}
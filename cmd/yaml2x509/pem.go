@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+	"github.com/rschoonheim/go-yaml-to-x509/keyfile"
+)
+
+// writePEM writes a single PEM block to path with the given permissions
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), perm)
+}
+
+// writePrivateKey writes a key generated for the profile as a PKCS#8 PEM file readable
+// only by the owner, encrypted when the profile has a 'key_encryption' section
+func writePrivateKey(path string, p *profile, key crypto.Signer) error {
+	data, err := yamltox509.MarshalPrivateKey(p.name, key, p.spec, p.opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// readCertificate reads the first certificate from a PEM file, or a DER encoded certificate
+func readCertificate(path string) (*x509.Certificate, error) {
+	block, err := readPEM(path, "CERTIFICATE")
+	if err != nil {
+		data, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil, readErr
+		}
+		if cert, derErr := x509.ParseCertificate(data); derErr == nil {
+			return cert, nil
+		}
+		return nil, err
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// readPrivateKey reads a PKCS#8, SEC 1 EC or PKCS#1 RSA private key from a PEM file.
+// Encrypted keys are read through a profile's encrypted-pem signer instead.
+func readPrivateKey(path string) (crypto.Signer, error) {
+	key, err := keyfile.Read(path, nil)
+	if errors.Is(err, keyfile.ErrPassphraseRequired) {
+		return nil, fmt.Errorf("%s: key is encrypted, use an encrypted-pem signer in the profile", path)
+	}
+	return key, err
+}
+
+// readPEM returns the first PEM block of the given type, or the first block when blockType is empty
+func readPEM(path, blockType string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if blockType == "" || block.Type == blockType {
+			return block, nil
+		}
+	}
+
+	if blockType == "" {
+		return nil, errors.New(path + ": no PEM data found")
+	}
+	return nil, fmt.Errorf("%s: no %s PEM block found", path, blockType)
+}
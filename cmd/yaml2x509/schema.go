@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+)
+
+// runSchema prints the JSON Schema of the YAML format
+func runSchema(args []string, e *env) error {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "schema takes no arguments"}
+	}
+
+	schema, err := yamltox509.JSONSchema()
+	if err != nil {
+		return err
+	}
+	_, err = e.stdout.Write(schema)
+	return err
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+)
+
+// runValidate checks that every certificate in the input resolves and builds
+func runValidate(args []string, e *env) error {
+	fs, in := newFlagSet("validate", e)
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	data, err := readInput(path, e)
+	if err != nil {
+		return err
+	}
+
+	opts, err := in.options(path, e)
+	if err != nil {
+		return err
+	}
+	certs, err := yamltox509.CertificatesFromYamlWithOptions(data, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range sortedNames(certs) {
+		fmt.Fprintf(e.stdout, "ok %s\n", name)
+	}
+	return nil
+}
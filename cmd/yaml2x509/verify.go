@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+)
+
+// runVerify runs the 'verify' sections of the input and prints every failure
+func runVerify(args []string, e *env) error {
+	fs, in := newFlagSet("verify", e)
+	path, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	data, err := readInput(path, e)
+	if err != nil {
+		return err
+	}
+	opts, err := in.options(path, e)
+	if err != nil {
+		return err
+	}
+	results, err := yamltox509.VerifyFromYamlWithOptions(data, opts)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return errors.New("input has no 'verify' section")
+	}
+	if in.name != "" {
+		result, ok := results[in.name]
+		if !ok {
+			return fmt.Errorf("verification '%s' not found", in.name)
+		}
+		results = map[string]*yamltox509.VerificationResult{in.name: result}
+	}
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failed := 0
+	for _, name := range names {
+		result := results[name]
+		if result.OK() {
+			fmt.Fprintf(e.stdout, "ok %s\n", name)
+			continue
+		}
+		failed++
+		for _, f := range result.Failures {
+			fmt.Fprintf(e.stdout, "%s: %s\n", name, f)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d verifications failed", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const verifyProfile = `
+certificates:
+  ca:
+    config:
+      subject: {common_name: "Test CA"}
+      validity: {duration: 1y}
+      is_ca: true
+      basic_constraints_valid: true
+      key_usage: [cert_sign]
+  www:
+    config:
+      subject: {common_name: "www.example.com"}
+      validity: {duration: 30d}
+      dns_names: [www.example.com]
+      ext_key_usage: [server_auth]
+verify:
+  www:
+    certificate: www
+    issuer: ca
+    dns_name: www.example.com
+  wrong-host:
+    certificate: www
+    dns_name: api.example.com
+`
+
+func TestVerify_Results(t *testing.T) {
+	code, stdout, stderr := runTest(t, verifyProfile, "verify")
+
+	if code != 1 || !strings.Contains(stderr, "1 of 2 verifications failed") {
+		t.Fatalf("Expected exit code 1 for the failing verification, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "wrong-host: hostname_mismatch: www: dns_names: ") {
+		t.Errorf("Expected the hostname failure with its profile field, got %q", stdout)
+	}
+	if !strings.Contains(stdout, "ok www\n") {
+		t.Errorf("Expected www to verify, got %q", stdout)
+	}
+
+	code, stdout, stderr = runTest(t, verifyProfile, "verify", "-name", "www")
+	if code != 0 || stdout != "ok www\n" {
+		t.Errorf("Expected only www to run, got %d: %s%s", code, stdout, stderr)
+	}
+}
+
+func TestVerify_NoSection(t *testing.T) {
+	code, _, stderr := runTest(t, testProfile, "verify", "-var", "host=www")
+	if code != 1 || !strings.Contains(stderr, "no 'verify' section") {
+		t.Errorf("Expected exit code 1 without a verify section, got %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,41 @@
+package go_yaml_to_x509
+
+import (
+	"crypto/x509"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// Difference describes one way a certificate deviates from its profile. Kind is
+// DifferenceMissing, DifferenceExtra or DifferenceMismatch.
+type Difference = internal.Difference
+
+// Difference kinds
+const (
+	// DifferenceMissing is a value the profile requires but the certificate lacks
+	DifferenceMissing = internal.DifferenceMissing
+	// DifferenceExtra is a value the certificate has but the profile does not list
+	DifferenceExtra = internal.DifferenceExtra
+	// DifferenceMismatch is a single-valued field set to a different value
+	DifferenceMismatch = internal.DifferenceMismatch
+)
+
+// Compare resolves a YAML profile and reports how cert differs from it: missing
+// or extra key usages, SANs and DN fields, algorithm mismatches and validity drift.
+// An empty result means the certificate conforms to the profile.
+//
+// Serial numbers are not compared. The issuer and the algorithms are only compared
+// when the profile sets them, and a relative validity is compared by its length.
+func Compare(yamlData []byte, cert *x509.Certificate, opts ...Option) ([]Difference, error) {
+	return CompareWithOptions(yamlData, cert, NewOptions(opts...))
+}
+
+// CompareWithOptions compares like Compare, using opts to control how the profile is resolved
+func CompareWithOptions(yamlData []byte, cert *x509.Certificate, opts Options) ([]Difference, error) {
+	_, spec, err := resolveProfile(yamlData, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return internal.CompareSpec(spec, internal.SpecFromCertificate(cert))
+}
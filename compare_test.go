@@ -0,0 +1,94 @@
+package go_yaml_to_x509
+
+import (
+	"testing"
+	"time"
+)
+
+const compareProfile = `
+segments:
+  web-server:
+    key_usage: [digital_signature]
+    ext_key_usage: [server_auth]
+    validity:
+      duration: "90d"
+merge: [web-server]
+config:
+  subject:
+    common_name: "${host}.example.com"
+  dns_names: ["${host}.example.com"]
+`
+
+func TestCompare_Conforming(t *testing.T) {
+	issued, err := Issue(IssueRequest{Template: mustTemplate(t, `
+subject:
+  common_name: "api.example.com"
+validity:
+  duration: "90d"
+key_usage: [digital_signature]
+ext_key_usage: [server_auth]
+dns_names: ["api.example.com"]
+`)}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, err := CompareWithOptions([]byte(compareProfile), issued.Certificate, Options{Vars: map[string][]string{"host": {"api"}}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("Expected no differences, got %v", diffs)
+	}
+}
+
+func TestCompare_Drift(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	template, err := X509FromYamlWithOptions([]byte(`
+subject:
+  common_name: "api.example.com"
+validity:
+  duration: "1y"
+key_usage: [digital_signature, key_encipherment]
+dns_names: ["api.example.com", "legacy.example.com"]
+`), Options{Now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued, err := Issue(IssueRequest{Template: template}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, err := CompareWithOptions([]byte(compareProfile), issued.Certificate, Options{Vars: map[string][]string{"host": {"api"}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []Difference{
+		{Field: "validity", Kind: DifferenceMismatch, Expected: "90d", Actual: "365d"},
+		{Field: "key_usage", Kind: DifferenceExtra, Actual: "key_encipherment"},
+		{Field: "ext_key_usage", Kind: DifferenceMissing, Expected: "server_auth"},
+		{Field: "dns_names", Kind: DifferenceExtra, Actual: "legacy.example.com"},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("Expected %d differences, got %v", len(expected), diffs)
+	}
+	for i := range expected {
+		if diffs[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], diffs[i])
+		}
+	}
+}
+
+func TestCompare_UnresolvableProfile(t *testing.T) {
+	issued, err := Issue(IssueRequest{Template: mustTemplate(t, `subject: {common_name: "a"}`)}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Compare([]byte(compareProfile), issued.Certificate); err == nil {
+		t.Error("Expected error for undefined variable")
+	}
+}
@@ -1,5 +1,9 @@
 module github.com/rschoonheim/go-yaml-to-x509
 
-go 1.25
+go 1.25.0
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require (
+	github.com/miekg/pkcs11 v1.1.1
+	golang.org/x/crypto v0.54.0
+	gopkg.in/yaml.v3 v3.0.1
+)
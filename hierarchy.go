@@ -0,0 +1,253 @@
+package go_yaml_to_x509
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// hierarchy issues the certificates of a stream on demand, each signed by the
+// certificate named as its issuer or self-signed, so that the 'tls' and 'verify'
+// sections share one set of certificates and keys
+type hierarchy struct {
+	templates map[string]*x509.Certificate
+	specs     map[string]*CertificateSpec
+	opts      Options
+
+	// issuers maps a certificate to the certificate that signs it
+	issuers map[string]string
+	issued  map[string]*hierarchyCert
+
+	// closers are signers opened for issuing, closed unless a configuration uses them
+	closers []io.Closer
+	inUse   map[io.Closer]bool
+}
+
+// hierarchyCert is an issued certificate with the chain a peer is sent: the
+// certificate and its intermediates, without the self-signed root
+type hierarchyCert struct {
+	cert  *x509.Certificate
+	key   crypto.Signer
+	chain []*x509.Certificate
+	root  bool
+}
+
+func newHierarchy(templates map[string]*x509.Certificate, specs map[string]*CertificateSpec, opts Options) *hierarchy {
+	return &hierarchy{
+		templates: templates,
+		specs:     specs,
+		opts:      opts,
+		issuers:   make(map[string]string),
+		issued:    make(map[string]*hierarchyCert),
+	}
+}
+
+// streamHierarchy returns the hierarchy of a stream, with the issuers named by
+// every 'tls' and 'verify' entry, so that a certificate is issued the same way
+// whichever entry uses it first
+func streamHierarchy(docs []*ConfigDocument, templates map[string]*x509.Certificate, specs map[string]*CertificateSpec, opts Options) (*hierarchy, error) {
+	h := newHierarchy(templates, specs, opts)
+	for _, doc := range docs {
+		for name, entry := range doc.TLS {
+			if entry == nil {
+				continue
+			}
+			if err := h.setIssuer(entry.Certificate, entry.Issuer); err != nil {
+				return nil, fmt.Errorf("tls '%s': %w", name, err)
+			}
+		}
+		for name, entry := range doc.Verify {
+			if entry == nil {
+				continue
+			}
+			if err := h.setIssuer(entry.Certificate, entry.Issuer); err != nil {
+				return nil, fmt.Errorf("verify '%s': %w", name, err)
+			}
+		}
+	}
+	return h, nil
+}
+
+// setIssuer records that issuer signs certificate. A certificate has a single issuer.
+func (h *hierarchy) setIssuer(certificate, issuer string) error {
+	if certificate == "" || issuer == "" {
+		return nil
+	}
+	if existing, exists := h.issuers[certificate]; exists && existing != issuer {
+		return fmt.Errorf("certificate '%s' is issued by both '%s' and '%s'", certificate, existing, issuer)
+	}
+	h.issuers[certificate] = issuer
+	return nil
+}
+
+// has reports whether the stream defines the named certificate
+func (h *hierarchy) has(name string) bool {
+	_, ok := h.templates[name]
+	return ok
+}
+
+// issue issues the named certificate of the stream once, signed by its issuer or
+// self-signed, opening signers under ctx. visiting detects issuer cycles.
+func (h *hierarchy) issue(ctx context.Context, name string, visiting map[string]bool) (*hierarchyCert, error) {
+	if issued, ok := h.issued[name]; ok {
+		return issued, nil
+	}
+	template, ok := h.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("certificate '%s' not found", name)
+	}
+	if visiting[name] {
+		return nil, fmt.Errorf("certificate '%s' is its own issuer", name)
+	}
+
+	var parent *hierarchyCert
+	if issuer, ok := h.issuers[name]; ok {
+		if visiting == nil {
+			visiting = make(map[string]bool)
+		}
+		visiting[name] = true
+
+		var err error
+		if parent, err = h.issue(ctx, issuer, visiting); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := NewIssueRequestWithOptions(ctx, template, h.specs[name], h.opts)
+	if err != nil {
+		return nil, fmt.Errorf("certificate '%s': %w", name, err)
+	}
+	if closer, ok := req.Signer.(io.Closer); ok {
+		h.closers = append(h.closers, closer)
+	}
+	req.Profile = name
+
+	// Certificates signed by another certificate of the stream use their issuer's
+	// key, and certify their own signer's key or a generated one
+	key := req.Signer
+	if parent != nil {
+		if req.Parent != nil {
+			return nil, fmt.Errorf("certificate '%s' has a signer certificate and an issuer", name)
+		}
+		req.Parent = parent.cert
+		req.Signer = parent.key
+	}
+
+	issuedCert, err := IssueContext(ctx, req, h.opts)
+	if err != nil {
+		return nil, fmt.Errorf("certificate '%s': %w", name, err)
+	}
+	if issuedCert.PrivateKey != nil {
+		key = issuedCert.PrivateKey
+	}
+	if key == nil {
+		return nil, fmt.Errorf("certificate '%s': no private key, it certifies a public_key", name)
+	}
+
+	issued := &hierarchyCert{cert: issuedCert.Certificate, key: key, chain: []*x509.Certificate{issuedCert.Certificate}}
+	if parent != nil && !parent.root {
+		issued.chain = append(issued.chain, parent.chain...)
+	}
+	issued.root = parent == nil
+	h.issued[name] = issued
+	return issued, nil
+}
+
+// top returns the self-signed certificate at the top of a certificate's issuers
+func (h *hierarchy) top(ctx context.Context, name string) (*hierarchyCert, error) {
+	// Issuing first rejects issuer cycles
+	issued, err := h.issue(ctx, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		issuer, ok := h.issuers[name]
+		if !ok {
+			return issued, nil
+		}
+		name = issuer
+		issued = h.issued[name]
+	}
+}
+
+// pool builds a pool from certificates of the stream and PEM files; kind names the
+// references in errors, e.g. "root"
+func (h *hierarchy) pool(ctx context.Context, kind string, refs []string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, ref := range refs {
+		if h.has(ref) {
+			issued, err := h.issue(ctx, ref, nil)
+			if err != nil {
+				return nil, err
+			}
+			pool.AddCert(issued.cert)
+			continue
+		}
+
+		data, err := internal.ReadFile(h.opts.IncludeFS, ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s '%s' is neither a certificate of the stream nor a readable file: %w", kind, ref, err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%s: no certificate found", ref)
+		}
+	}
+	return pool, nil
+}
+
+// use marks a signer as presented by a configuration, keeping it open
+func (h *hierarchy) use(key crypto.Signer) {
+	closer, ok := key.(io.Closer)
+	if !ok {
+		return
+	}
+	if h.inUse == nil {
+		h.inUse = make(map[io.Closer]bool)
+	}
+	h.inUse[closer] = true
+}
+
+// close closes the signers opened for issuing that no configuration presents
+func (h *hierarchy) close() {
+	for _, closer := range h.closers {
+		if !h.inUse[closer] {
+			closer.Close()
+		}
+	}
+}
+
+// readCertificates reads every certificate of a PEM file, in order, reading a
+// relative path from fsys
+func readCertificates(fsys fs.FS, path string) ([]*x509.Certificate, error) {
+	data, err := internal.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s: no certificate found", path)
+	}
+	return certs, nil
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Difference kind constants
+const (
+	DifferenceMissing  = "missing"
+	DifferenceExtra    = "extra"
+	DifferenceMismatch = "mismatch"
+)
+
+// Difference describes one way a certificate deviates from its profile
+type Difference struct {
+	Field    string `json:"field"`
+	Kind     string `json:"kind"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+// String returns a human-readable description of the difference
+func (d Difference) String() string {
+	switch d.Kind {
+	case DifferenceMissing:
+		return fmt.Sprintf("%s: missing '%s'", d.Field, d.Expected)
+	case DifferenceExtra:
+		return fmt.Sprintf("%s: unexpected '%s'", d.Field, d.Actual)
+	default:
+		return fmt.Sprintf("%s: expected '%s', got '%s'", d.Field, d.Expected, d.Actual)
+	}
+}
+
+// CompareSpec reports how actual, a spec read from a certificate with
+// SpecFromCertificate, differs from the resolved profile spec expected.
+// Serial numbers are not compared, and the issuer and algorithms are only
+// compared when the profile sets them. A relative validity is compared by its
+// duration, anchored at the certificate's not_before.
+func CompareSpec(expected, actual *CertificateSpec) ([]Difference, error) {
+	var diffs []Difference
+
+	mismatch := func(field, want, got string) {
+		if want != got {
+			diffs = append(diffs, Difference{Field: field, Kind: DifferenceMismatch, Expected: want, Actual: got})
+		}
+	}
+
+	diffs = append(diffs, compareNames("subject", expected.Subject, actual.Subject)...)
+	if len(expected.Issuer) > 0 {
+		diffs = append(diffs, compareNames("issuer", expected.Issuer, actual.Issuer)...)
+	}
+
+	validityDiffs, err := compareValidity(expected, actual)
+	if err != nil {
+		return nil, err
+	}
+	diffs = append(diffs, validityDiffs...)
+
+	diffs = append(diffs, compareLists("key_usage", expected.KeyUsage, actual.KeyUsage)...)
+	diffs = append(diffs, compareLists("ext_key_usage", expected.ExtKeyUsage, actual.ExtKeyUsage)...)
+	diffs = append(diffs, compareLists("dns_names", expected.DNSNames, actual.DNSNames)...)
+	diffs = append(diffs, compareLists("email_addresses", expected.EmailAddresses, actual.EmailAddresses)...)
+	diffs = append(diffs, compareLists("ip_addresses", canonicalIPs(expected.IPAddresses), canonicalIPs(actual.IPAddresses))...)
+	diffs = append(diffs, compareLists("uris", expected.URIs, actual.URIs)...)
+
+	mismatch("is_ca", fmt.Sprint(expected.IsCA), fmt.Sprint(actual.IsCA))
+	mismatch("basic_constraints_valid", fmt.Sprint(expected.BasicConstraintsValid), fmt.Sprint(actual.BasicConstraintsValid))
+	if expected.BasicConstraintsValid {
+		mismatch("max_path_len", fmt.Sprint(expected.MaxPathLen), fmt.Sprint(actual.MaxPathLen))
+		mismatch("max_path_len_zero", fmt.Sprint(expected.MaxPathLenZero), fmt.Sprint(actual.MaxPathLenZero))
+	}
+
+	if expected.SignatureAlgorithm != "" {
+		mismatch("signature_algorithm", expected.SignatureAlgorithm, actual.SignatureAlgorithm)
+	}
+	if expected.PublicKeyAlgorithm != "" {
+		mismatch("public_key_algorithm", expected.PublicKeyAlgorithm, actual.PublicKeyAlgorithm)
+	}
+
+	return diffs, nil
+}
+
+// compareNames compares two distinguished name maps key by key
+func compareNames(field string, expected, actual map[string]string) []Difference {
+	var diffs []Difference
+
+	for _, key := range sortedKeys(expected) {
+		value, ok := actual[key]
+		switch {
+		case !ok:
+			diffs = append(diffs, Difference{Field: field + "." + key, Kind: DifferenceMissing, Expected: expected[key]})
+		case value != expected[key]:
+			diffs = append(diffs, Difference{Field: field + "." + key, Kind: DifferenceMismatch, Expected: expected[key], Actual: value})
+		}
+	}
+	for _, key := range sortedKeys(actual) {
+		if _, ok := expected[key]; !ok {
+			diffs = append(diffs, Difference{Field: field + "." + key, Kind: DifferenceExtra, Actual: actual[key]})
+		}
+	}
+
+	return diffs
+}
+
+// compareLists compares two lists as sets, ignoring order and duplicates
+func compareLists(field string, expected, actual []string) []Difference {
+	var diffs []Difference
+
+	expectedSet := toSet(expected)
+	actualSet := toSet(actual)
+	for _, value := range expected {
+		if !actualSet[value] {
+			diffs = append(diffs, Difference{Field: field, Kind: DifferenceMissing, Expected: value})
+			actualSet[value] = true
+		}
+	}
+	for _, value := range actual {
+		if !expectedSet[value] {
+			diffs = append(diffs, Difference{Field: field, Kind: DifferenceExtra, Actual: value})
+			expectedSet[value] = true
+		}
+	}
+
+	return diffs
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+// canonicalIPs normalises IP addresses so that equivalent spellings compare equal
+func canonicalIPs(ips []string) []string {
+	result := make([]string, len(ips))
+	for i, s := range ips {
+		if ip := net.ParseIP(s); ip != nil {
+			s = ip.String()
+		}
+		result[i] = s
+	}
+	return result
+}
+
+// compareValidity compares the validity period of the profile with the certificate's.
+// A validity anchored at "now" is compared by its length, an absolute anchor by its dates.
+func compareValidity(expected, actual *CertificateSpec) ([]Difference, error) {
+	notBefore, notAfter := expected.NotBefore, expected.NotAfter
+
+	if expected.Validity != nil {
+		actualNotBefore, err := time.Parse(time.RFC3339, actual.NotBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid not_before '%s' in certificate", actual.NotBefore)
+		}
+		actualNotAfter, err := time.Parse(time.RFC3339, actual.NotAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid not_after '%s' in certificate", actual.NotAfter)
+		}
+
+		// Anchor a relative period so that it starts where the certificate does
+		var backdate time.Duration
+		if expected.Validity.Backdate != "" {
+			if backdate, err = ParseDuration(expected.Validity.Backdate); err != nil {
+				return nil, fmt.Errorf("validity.backdate: %w", err)
+			}
+		}
+		start, end, err := expected.Validity.Period(actualNotBefore.Add(backdate))
+		if err != nil {
+			return nil, err
+		}
+
+		if expected.Validity.Anchor == "" || strings.EqualFold(expected.Validity.Anchor, ValidityAnchorNow) {
+			if want, got := end.Sub(start), actualNotAfter.Sub(actualNotBefore); want != got {
+				return []Difference{{Field: "validity", Kind: DifferenceMismatch, Expected: FormatDuration(want), Actual: FormatDuration(got)}}, nil
+			}
+			return nil, nil
+		}
+
+		notBefore = start.UTC().Format(time.RFC3339)
+		notAfter = end.UTC().Format(time.RFC3339)
+	}
+
+	var diffs []Difference
+	if notBefore != "" {
+		diffs = append(diffs, compareTime("not_before", notBefore, actual.NotBefore)...)
+	}
+	if notAfter != "" {
+		diffs = append(diffs, compareTime("not_after", notAfter, actual.NotAfter)...)
+	}
+
+	return diffs, nil
+}
+
+// compareTime compares two RFC3339 timestamps, which may use different time zones
+func compareTime(field, expected, actual string) []Difference {
+	want, err := time.Parse(time.RFC3339, expected)
+	if err != nil {
+		return []Difference{{Field: field, Kind: DifferenceMismatch, Expected: expected, Actual: actual}}
+	}
+	if got, err := time.Parse(time.RFC3339, actual); err == nil && want.Equal(got) {
+		return nil
+	}
+	return []Difference{{Field: field, Kind: DifferenceMismatch, Expected: expected, Actual: actual}}
+}
+
+// FormatDuration formats a duration in the units accepted by ParseDuration,
+// e.g. "90d" or "1d12h". Fractions of a second are dropped.
+func FormatDuration(d time.Duration) string {
+	if d > -time.Second && d < time.Second {
+		return "0s"
+	}
+
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	for _, unit := range []struct {
+		suffix string
+		length time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	} {
+		if n := d / unit.length; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, unit.suffix)
+			d -= n * unit.length
+		}
+	}
+
+	return b.String()
+}
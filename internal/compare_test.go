@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCompareSpec_Identical(t *testing.T) {
+	spec := &CertificateSpec{
+		Subject:               map[string]string{DNCommonName: "example.com"},
+		NotBefore:             "2024-01-01T00:00:00Z",
+		NotAfter:              "2025-01-01T00:00:00Z",
+		KeyUsage:              []string{KeyUsageDigitalSignature},
+		DNSNames:              []string{"example.com"},
+		BasicConstraintsValid: true,
+		SignatureAlgorithm:    SigAlgECDSAWithSHA256,
+	}
+
+	diffs, err := CompareSpec(spec, spec)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("Expected no differences, got %v", diffs)
+	}
+}
+
+func TestCompareSpec_Differences(t *testing.T) {
+	expected := &CertificateSpec{
+		Subject:            map[string]string{DNCommonName: "example.com", DNOrganization: "Example Corp"},
+		KeyUsage:           []string{KeyUsageDigitalSignature, KeyUsageKeyEncipherment},
+		ExtKeyUsage:        []string{ExtKeyUsageServerAuth},
+		DNSNames:           []string{"example.com", "www.example.com"},
+		IPAddresses:        []string{"2001:0db8::0001"},
+		SignatureAlgorithm: SigAlgECDSAWithSHA256,
+	}
+	actual := &CertificateSpec{
+		Subject:            map[string]string{DNCommonName: "other.com", DNCountry: "NL"},
+		KeyUsage:           []string{KeyUsageDigitalSignature},
+		ExtKeyUsage:        []string{ExtKeyUsageServerAuth, ExtKeyUsageClientAuth},
+		DNSNames:           []string{"www.example.com", "example.com"},
+		IPAddresses:        []string{"2001:db8::1"},
+		IsCA:               true,
+		SignatureAlgorithm: SigAlgSHA256WithRSA,
+	}
+
+	diffs, err := CompareSpec(expected, actual)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedDiffs := []Difference{
+		{Field: "subject.common_name", Kind: DifferenceMismatch, Expected: "example.com", Actual: "other.com"},
+		{Field: "subject.organization", Kind: DifferenceMissing, Expected: "Example Corp"},
+		{Field: "subject.country", Kind: DifferenceExtra, Actual: "NL"},
+		{Field: "key_usage", Kind: DifferenceMissing, Expected: KeyUsageKeyEncipherment},
+		{Field: "ext_key_usage", Kind: DifferenceExtra, Actual: ExtKeyUsageClientAuth},
+		{Field: "is_ca", Kind: DifferenceMismatch, Expected: "false", Actual: "true"},
+		{Field: "signature_algorithm", Kind: DifferenceMismatch, Expected: SigAlgECDSAWithSHA256, Actual: SigAlgSHA256WithRSA},
+	}
+	if !reflect.DeepEqual(diffs, expectedDiffs) {
+		t.Errorf("Expected %v, got %v", expectedDiffs, diffs)
+	}
+}
+
+func TestCompareSpec_IssuerAndAlgorithmsOnlyWhenSet(t *testing.T) {
+	expected := &CertificateSpec{}
+	actual := &CertificateSpec{
+		Issuer:             map[string]string{DNCommonName: "Example CA"},
+		SignatureAlgorithm: SigAlgECDSAWithSHA256,
+		PublicKeyAlgorithm: PubKeyAlgECDSA,
+	}
+
+	diffs, err := CompareSpec(expected, actual)
+
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("Expected no differences, got %v, %v", diffs, err)
+	}
+}
+
+func TestCompareSpec_RelativeValidity(t *testing.T) {
+	expected := &CertificateSpec{Validity: &ValiditySpec{Duration: "90d", Backdate: "1h"}}
+	actual := &CertificateSpec{NotBefore: "2024-01-01T00:00:00Z", NotAfter: "2024-03-31T01:00:00Z"}
+
+	diffs, err := CompareSpec(expected, actual)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(diffs) != 0 {
+		t.Errorf("Expected no differences for a matching period, got %v", diffs)
+	}
+
+	actual.NotAfter = "2024-12-31T01:00:00Z"
+	diffs, err = CompareSpec(expected, actual)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectedDiffs := []Difference{{Field: "validity", Kind: DifferenceMismatch, Expected: "90d1h", Actual: "365d1h"}}
+	if !reflect.DeepEqual(diffs, expectedDiffs) {
+		t.Errorf("Expected %v, got %v", expectedDiffs, diffs)
+	}
+}
+
+func TestCompareSpec_AbsoluteValidity(t *testing.T) {
+	expected := &CertificateSpec{Validity: &ValiditySpec{Duration: "1y", Anchor: "2024-01-01T00:00:00Z"}}
+	actual := &CertificateSpec{NotBefore: "2024-01-01T00:00:00Z", NotAfter: "2024-12-30T00:00:00Z"}
+
+	diffs, err := CompareSpec(expected, actual)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedDiffs := []Difference{{Field: "not_after", Kind: DifferenceMismatch, Expected: "2024-12-31T00:00:00Z", Actual: "2024-12-30T00:00:00Z"}}
+	if !reflect.DeepEqual(diffs, expectedDiffs) {
+		t.Errorf("Expected %v, got %v", expectedDiffs, diffs)
+	}
+}
+
+func TestCompareSpec_DatesInOtherTimeZone(t *testing.T) {
+	expected := &CertificateSpec{NotBefore: "2024-01-01T01:00:00+01:00"}
+	actual := &CertificateSpec{NotBefore: "2024-01-01T00:00:00Z"}
+
+	diffs, err := CompareSpec(expected, actual)
+
+	if err != nil || len(diffs) != 0 {
+		t.Errorf("Expected equal instants to match, got %v, %v", diffs, err)
+	}
+}
+
+func TestDifference_String(t *testing.T) {
+	tests := []struct {
+		diff     Difference
+		expected string
+	}{
+		{Difference{Field: "dns_names", Kind: DifferenceMissing, Expected: "a.com"}, "dns_names: missing 'a.com'"},
+		{Difference{Field: "dns_names", Kind: DifferenceExtra, Actual: "b.com"}, "dns_names: unexpected 'b.com'"},
+		{Difference{Field: "is_ca", Kind: DifferenceMismatch, Expected: "true", Actual: "false"}, "is_ca: expected 'true', got 'false'"},
+	}
+
+	for _, tt := range tests {
+		if result := tt.diff.String(); result != tt.expected {
+			t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                            "0s",
+		90 * 24 * time.Hour:          "90d",
+		36 * time.Hour:               "1d12h",
+		90*time.Minute + time.Second: "1h30m1s",
+		-2 * time.Hour:               "-2h",
+	}
+
+	for d, expected := range tests {
+		if result := FormatDuration(d); result != expected {
+			t.Errorf("FormatDuration(%v): expected '%s', got '%s'", d, expected, result)
+		}
+		if parsed, err := ParseDuration(expected); d > 0 && (err != nil || parsed != d) {
+			t.Errorf("ParseDuration('%s'): expected %v, got %v, %v", expected, d, parsed, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MergeConflict describes a field that two merged segments set to different values
+type MergeConflict struct {
+	Field           string
+	Segment         string
+	Value           string
+	PreviousSegment string
+	PreviousValue   string
+}
+
+// String returns a human-readable description of the conflict
+func (c MergeConflict) String() string {
+	return fmt.Sprintf("segment '%s' sets %s to '%s', conflicting with '%s' from segment '%s'",
+		c.Segment, c.Field, c.Value, c.PreviousValue, c.PreviousSegment)
+}
+
+// ConflictError is returned by ResolveConfig when conflicts are found in "error" mode
+type ConflictError struct {
+	Conflicts []MergeConflict
+}
+
+func (e *ConflictError) Error() string {
+	msg := e.Conflicts[0].String()
+	if len(e.Conflicts) > 1 {
+		msg += fmt.Sprintf(" (and %d more conflicts)", len(e.Conflicts)-1)
+	}
+	return msg
+}
+
+// scalarField is a single scalar value set by a spec, addressed by its field path
+type scalarField struct {
+	path  string
+	value string
+}
+
+// FindConflicts reports scalar fields and subject/issuer keys that are set to
+// different values by more than one spec, unless the later spec lists the field
+// in its overrides. Boolean and int fields are not checked because an unset
+// value cannot be told apart from false or 0.
+func FindConflicts(names []string, specs []*CertificateSpec) []MergeConflict {
+	var conflicts []MergeConflict
+	seen := make(map[string]MergeConflict)
+
+	for i, spec := range specs {
+		if spec == nil {
+			continue
+		}
+
+		for _, field := range scalarFields(spec) {
+			if prev, ok := seen[field.path]; ok && prev.Value != field.value && !spec.overrides(field.path) {
+				conflicts = append(conflicts, MergeConflict{
+					Field:           field.path,
+					Segment:         names[i],
+					Value:           field.value,
+					PreviousSegment: prev.Segment,
+					PreviousValue:   prev.Value,
+				})
+			}
+			seen[field.path] = MergeConflict{Segment: names[i], Value: field.value}
+		}
+	}
+
+	return conflicts
+}
+
+// scalarFields returns the non-empty scalar fields of a spec in a stable order
+func scalarFields(spec *CertificateSpec) []scalarField {
+	var fields []scalarField
+
+	add := func(path, value string) {
+		if value != "" {
+			fields = append(fields, scalarField{path: path, value: value})
+		}
+	}
+
+	add("serial_number", spec.SerialNumber)
+	add("not_before", spec.NotBefore)
+	add("not_after", spec.NotAfter)
+	if spec.Validity != nil {
+		add("validity.duration", spec.Validity.Duration)
+		add("validity.backdate", spec.Validity.Backdate)
+		add("validity.anchor", spec.Validity.Anchor)
+	}
+	add("signature_algorithm", spec.SignatureAlgorithm)
+	add("public_key_algorithm", spec.PublicKeyAlgorithm)
+	add("public_key", spec.PublicKey)
+	add("insecure_test_seed", spec.InsecureTestSeed)
+	if spec.Signer != nil {
+		add("signer.type", spec.Signer.Type)
+		add("signer.certificate", spec.Signer.Certificate)
+		add("signer.path", spec.Signer.Path)
+		add("signer.label", spec.Signer.Label)
+	}
+	if spec.KeyEncryption != nil {
+		add("key_encryption.kdf", spec.KeyEncryption.KDF)
+	}
+
+	for _, key := range sortedKeys(spec.Subject) {
+		add("subject."+key, spec.Subject[key])
+	}
+	for _, key := range sortedKeys(spec.Issuer) {
+		add("issuer."+key, spec.Issuer[key])
+	}
+
+	return fields
+}
+
+// overrides reports whether the spec explicitly overrides the given field path
+func (s *CertificateSpec) overrides(path string) bool {
+	for _, override := range s.Overrides {
+		if path == override || strings.HasPrefix(path, override+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFindConflicts_NoConflicts(t *testing.T) {
+	specs := []*CertificateSpec{
+		{SignatureAlgorithm: "SHA256WithRSA", Subject: map[string]string{"country": "US"}},
+		{SignatureAlgorithm: "SHA256WithRSA", Subject: map[string]string{"organization": "Example Corp"}},
+	}
+
+	conflicts := FindConflicts([]string{"a", "b"}, specs)
+
+	if len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %v", conflicts)
+	}
+}
+
+func TestFindConflicts_ScalarAndMapKeys(t *testing.T) {
+	specs := []*CertificateSpec{
+		{
+			SignatureAlgorithm: "SHA256WithRSA",
+			NotAfter:           "2026-01-01T00:00:00Z",
+			Subject:            map[string]string{"country": "US"},
+		},
+		{
+			SignatureAlgorithm: "SHA512WithRSA",
+			Subject:            map[string]string{"country": "UK"},
+		},
+	}
+
+	conflicts := FindConflicts([]string{"defaults", "web-server"}, specs)
+
+	if len(conflicts) != 2 {
+		t.Fatalf("Expected 2 conflicts, got %d: %v", len(conflicts), conflicts)
+	}
+	if conflicts[0].Field != "signature_algorithm" {
+		t.Errorf("Expected first conflict on signature_algorithm, got '%s'", conflicts[0].Field)
+	}
+	if conflicts[0].Segment != "web-server" || conflicts[0].PreviousSegment != "defaults" {
+		t.Errorf("Unexpected segments in conflict: %+v", conflicts[0])
+	}
+	if conflicts[1].Field != "subject.country" {
+		t.Errorf("Expected second conflict on subject.country, got '%s'", conflicts[1].Field)
+	}
+}
+
+func TestFindConflicts_ExplicitOverride(t *testing.T) {
+	specs := []*CertificateSpec{
+		{SignatureAlgorithm: "SHA256WithRSA", Subject: map[string]string{"country": "US", "locality": "Boston"}},
+		{
+			SignatureAlgorithm: "SHA512WithRSA",
+			Subject:            map[string]string{"country": "UK", "locality": "London"},
+			Overrides:          []string{"signature_algorithm", "subject"},
+		},
+	}
+
+	conflicts := FindConflicts([]string{"a", "b"}, specs)
+
+	if len(conflicts) != 0 {
+		t.Errorf("Expected overrides to suppress conflicts, got %v", conflicts)
+	}
+}
+
+func TestFindConflicts_OverrideIsPerField(t *testing.T) {
+	specs := []*CertificateSpec{
+		{Subject: map[string]string{"country": "US", "locality": "Boston"}},
+		{
+			Subject:   map[string]string{"country": "UK", "locality": "London"},
+			Overrides: []string{"subject.country"},
+		},
+	}
+
+	conflicts := FindConflicts([]string{"a", "b"}, specs)
+
+	if len(conflicts) != 1 || conflicts[0].Field != "subject.locality" {
+		t.Errorf("Expected a single conflict on subject.locality, got %v", conflicts)
+	}
+}
+
+func TestMergeConflict_String(t *testing.T) {
+	conflict := MergeConflict{
+		Field:           "not_after",
+		Segment:         "b",
+		Value:           "2027-01-01T00:00:00Z",
+		PreviousSegment: "a",
+		PreviousValue:   "2026-01-01T00:00:00Z",
+	}
+
+	expected := "segment 'b' sets not_after to '2027-01-01T00:00:00Z', conflicting with '2026-01-01T00:00:00Z' from segment 'a'"
+	if conflict.String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, conflict.String())
+	}
+}
+
+func conflictingDocument(mode string) *ConfigDocument {
+	return &ConfigDocument{
+		Segments: map[string]*CertificateSpec{
+			"a": {SignatureAlgorithm: "SHA256WithRSA"},
+			"b": {SignatureAlgorithm: "SHA512WithRSA"},
+		},
+		Merge:     []string{"a", "b"},
+		Config:    &CertificateSpec{SignatureAlgorithm: "ECDSAWithSHA256"},
+		Conflicts: mode,
+	}
+}
+
+func TestResolveConfig_ConflictsIgnoredByDefault(t *testing.T) {
+	result, err := ResolveConfig(conflictingDocument(""))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.SignatureAlgorithm != "ECDSAWithSHA256" {
+		t.Errorf("Expected config to win, got '%s'", result.SignatureAlgorithm)
+	}
+}
+
+func TestResolveConfig_ConflictModeError(t *testing.T) {
+	result, err := ResolveConfig(conflictingDocument(ConflictModeError))
+
+	var conflictErr *ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected ConflictError, got %v", err)
+	}
+	if result != nil {
+		t.Error("Expected nil result on error")
+	}
+	if len(conflictErr.Conflicts) != 1 || conflictErr.Conflicts[0].Field != "signature_algorithm" {
+		t.Errorf("Unexpected conflicts: %v", conflictErr.Conflicts)
+	}
+}
+
+func TestResolveConfig_ConfigIsNotAConflict(t *testing.T) {
+	doc := conflictingDocument(ConflictModeError)
+	doc.Segments["b"].SignatureAlgorithm = "SHA256WithRSA"
+
+	_, err := ResolveConfig(doc)
+
+	if err != nil {
+		t.Errorf("Expected config override to be allowed, got %v", err)
+	}
+}
+
+func TestResolveConfigWithOptions_ConflictModeWarn(t *testing.T) {
+	var warnings []MergeConflict
+	opts := ResolveOptions{
+		ConflictMode: ConflictModeWarn,
+		OnConflict: func(c MergeConflict) {
+			warnings = append(warnings, c)
+		},
+	}
+
+	result, err := ResolveConfigWithOptions(conflictingDocument(ConflictModeError), opts)
+
+	if err != nil {
+		t.Fatalf("Expected option to override document mode, got %v", err)
+	}
+	if result == nil {
+		t.Fatal("Expected non-nil result")
+	}
+	if len(warnings) != 1 {
+		t.Errorf("Expected 1 warning, got %d", len(warnings))
+	}
+}
+
+func TestResolveConfigWithOptions_ConflictModeWarnLogs(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	_, err := ResolveConfigWithOptions(conflictingDocument(ConflictModeWarn), ResolveOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected no error in warn mode, got %v", err)
+	}
+	if !strings.Contains(logged.String(), "warning: segment 'b' sets") {
+		t.Errorf("Expected the conflict to be logged without a callback, got %q", logged.String())
+	}
+}
+
+func TestResolveConfig_UnknownConflictMode(t *testing.T) {
+	_, err := ResolveConfig(conflictingDocument("strict"))
+
+	if err == nil || err.Error() != "unknown conflict mode 'strict'" {
+		t.Errorf("Expected unknown conflict mode error, got %v", err)
+	}
+}
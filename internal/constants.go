@@ -1,5 +1,7 @@
 package internal
 
+import "github.com/rschoonheim/go-yaml-to-x509/keyfile"
+
 // Distinguished Name field constants
 const (
 	DNCommonName         = "common_name"
@@ -71,3 +73,141 @@ const (
 	PubKeyAlgECDSA   = "ECDSA"
 	PubKeyAlgEd25519 = "Ed25519"
 )
+
+// Conflict mode constants
+const (
+	ConflictModeOff   = "off"
+	ConflictModeWarn  = "warn"
+	ConflictModeError = "error"
+)
+
+// TLS role constants
+const (
+	TLSRoleServer = "server"
+	TLSRoleClient = "client"
+)
+
+// TLS client authentication constants
+const (
+	ClientAuthNone             = "none"
+	ClientAuthRequest          = "request"
+	ClientAuthRequire          = "require"
+	ClientAuthVerifyIfGiven    = "verify_if_given"
+	ClientAuthRequireAndVerify = "require_and_verify"
+)
+
+// TLS version constants
+const (
+	TLSVersion10 = "1.0"
+	TLSVersion11 = "1.1"
+	TLSVersion12 = "1.2"
+	TLSVersion13 = "1.3"
+)
+
+// DNFields lists every supported distinguished name field
+var DNFields = []string{
+	DNCommonName,
+	DNCountry,
+	DNOrganization,
+	DNOrganizationalUnit,
+	DNLocality,
+	DNProvince,
+	DNStreetAddress,
+	DNPostalCode,
+	DNSerialNumber,
+}
+
+// KeyUsages lists every supported key usage
+var KeyUsages = []string{
+	KeyUsageDigitalSignature,
+	KeyUsageContentCommitment,
+	KeyUsageKeyEncipherment,
+	KeyUsageDataEncipherment,
+	KeyUsageKeyAgreement,
+	KeyUsageCertSign,
+	KeyUsageCRLSign,
+	KeyUsageEncipherOnly,
+	KeyUsageDecipherOnly,
+}
+
+// ExtKeyUsages lists every supported extended key usage
+var ExtKeyUsages = []string{
+	ExtKeyUsageAny,
+	ExtKeyUsageServerAuth,
+	ExtKeyUsageClientAuth,
+	ExtKeyUsageCodeSigning,
+	ExtKeyUsageEmailProtection,
+	ExtKeyUsageIPSECEndSystem,
+	ExtKeyUsageIPSECTunnel,
+	ExtKeyUsageIPSECUser,
+	ExtKeyUsageTimeStamping,
+	ExtKeyUsageOCSPSigning,
+	ExtKeyUsageMicrosoftServerGatedCrypto,
+	ExtKeyUsageNetscapeServerGatedCrypto,
+	ExtKeyUsageMicrosoftCommercialCodeSigning,
+	ExtKeyUsageMicrosoftKernelCodeSigning,
+}
+
+// SignatureAlgorithms lists every supported signature algorithm
+var SignatureAlgorithms = []string{
+	SigAlgMD2WithRSA,
+	SigAlgMD5WithRSA,
+	SigAlgSHA1WithRSA,
+	SigAlgSHA256WithRSA,
+	SigAlgSHA384WithRSA,
+	SigAlgSHA512WithRSA,
+	SigAlgDSAWithSHA1,
+	SigAlgDSAWithSHA256,
+	SigAlgECDSAWithSHA1,
+	SigAlgECDSAWithSHA256,
+	SigAlgECDSAWithSHA384,
+	SigAlgECDSAWithSHA512,
+	SigAlgSHA256WithRSAPSS,
+	SigAlgSHA384WithRSAPSS,
+	SigAlgSHA512WithRSAPSS,
+	SigAlgPureEd25519,
+}
+
+// PublicKeyAlgorithms lists every supported public key algorithm
+var PublicKeyAlgorithms = []string{
+	PubKeyAlgRSA,
+	PubKeyAlgDSA,
+	PubKeyAlgECDSA,
+	PubKeyAlgEd25519,
+}
+
+// KeyDerivationFunctions lists the key derivation functions of key_encryption
+var KeyDerivationFunctions = []string{
+	keyfile.KDFScrypt,
+	keyfile.KDFPBKDF2,
+}
+
+// ConflictModes lists every conflict mode
+var ConflictModes = []string{
+	ConflictModeOff,
+	ConflictModeWarn,
+	ConflictModeError,
+}
+
+// TLSRoles lists the roles of a 'tls' entry
+var TLSRoles = []string{
+	TLSRoleServer,
+	TLSRoleClient,
+}
+
+// ClientAuthModes lists every client authentication mode
+var ClientAuthModes = []string{
+	ClientAuthNone,
+	ClientAuthRequest,
+	ClientAuthRequire,
+	ClientAuthVerifyIfGiven,
+	ClientAuthRequireAndVerify,
+}
+
+// TLSVersions lists every TLS version
+var TLSVersions = []string{
+	TLSVersion10,
+	TLSVersion11,
+	TLSVersion12,
+	TLSVersion13,
+}
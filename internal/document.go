@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DecodeDocument decodes a YAML document into a ConfigDocument. Documents in the
+// simple format are treated as a ConfigDocument whose config is the whole document.
+func DecodeDocument(node *yaml.Node) (*ConfigDocument, error) {
+	doc := &ConfigDocument{}
+	if err := node.Decode(doc); err != nil {
+		return nil, err
+	}
+
+	if doc.isSimple() {
+		doc.Config = &CertificateSpec{}
+		if err := node.Decode(doc.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+// DecodeDocumentStrict decodes like DecodeDocument, but rejects keys that are not
+// part of the format, such as misspelled fields, instead of ignoring them
+func DecodeDocumentStrict(node *yaml.Node) (*ConfigDocument, error) {
+	structured := &ConfigDocument{}
+	if err := node.Decode(structured); err != nil {
+		return nil, err
+	}
+
+	// yaml.Node.Decode cannot reject unknown keys, so decode the node again
+	// with a decoder in known fields mode
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+
+	// Documents in the simple format mix document keys such as 'name' and 'vars'
+	// with certificate fields
+	var target interface{} = &ConfigDocument{}
+	if structured.isSimple() {
+		target = &struct {
+			ConfigDocument  `yaml:",inline"`
+			CertificateSpec `yaml:",inline"`
+		}{}
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(target); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return nil, unknownFieldsError(typeErr)
+		}
+		return nil, err
+	}
+
+	return DecodeDocument(node)
+}
+
+// unknownFieldPattern matches the errors yaml.v3 reports for unknown keys
+var unknownFieldPattern = regexp.MustCompile(`^(line \d+): field (.+) not found in type .+$`)
+
+// unknownFieldsError rewrites the errors of a strict decode without Go type names
+func unknownFieldsError(err *yaml.TypeError) error {
+	messages := make([]string, len(err.Errors))
+	for i, msg := range err.Errors {
+		messages[i] = unknownFieldPattern.ReplaceAllString(msg, "$1: unknown field '$2'")
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// isSimple reports whether a decoded document is in the simple format, i.e. has
+// none of the keys of the segments format
+func (d *ConfigDocument) isSimple() bool {
+	return d.Certificates == nil && d.Segments == nil && d.Merge == nil && d.Config == nil && d.Include == nil && d.TLS == nil && d.Verify == nil
+}
+
+// definesNoCertificate reports whether a document only has 'tls' or 'verify'
+// sections referring to certificates of other documents
+func (d *ConfigDocument) definesNoCertificate() bool {
+	return (d.TLS != nil || d.Verify != nil) && d.Certificates == nil && d.Merge == nil && d.Config == nil
+}
+
+// CertificateDocument returns a document for a single entry of the 'certificates'
+// map, sharing the top-level segments, vars and conflict mode
+func (d *ConfigDocument) CertificateDocument(name string) (*ConfigDocument, bool) {
+	entry, exists := d.Certificates[name]
+	if !exists {
+		return nil, false
+	}
+	if entry == nil {
+		entry = &CertificateEntry{}
+	}
+
+	return &ConfigDocument{
+		Name:      name,
+		Config:    entry.Config,
+		Merge:     entry.Merge,
+		Segments:  d.Segments,
+		Vars:      d.Vars,
+		Conflicts: d.Conflicts,
+		Include:   d.Include,
+	}, true
+}
+
+// ResolveDocument resolves a decoded document into certificate specs keyed by name.
+// Documents without a 'certificates' map resolve to a single spec named after the
+// document's 'name' field, or defaultName when it is not set. Documents with only a
+// 'tls' or 'verify' section resolve to no specs.
+func ResolveDocument(doc *ConfigDocument, defaultName string, opts ResolveOptions) (map[string]*CertificateSpec, error) {
+	if doc.Certificates != nil {
+		// Handle multi-certificate config sharing the top-level segments
+		return ResolveCertificates(doc, opts)
+	}
+	if doc.definesNoCertificate() {
+		return map[string]*CertificateSpec{}, nil
+	}
+
+	spec, err := ResolveConfigWithOptions(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil {
+		spec = &CertificateSpec{}
+	}
+
+	return map[string]*CertificateSpec{doc.DocumentName(defaultName): spec}, nil
+}
+
+// DocumentName returns the document's 'name', or defaultName when it is not set
+func (d *ConfigDocument) DocumentName(defaultName string) string {
+	if d.Name == "" {
+		return defaultName
+	}
+	return d.Name
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func decodeTestDocument(t *testing.T, data string) *ConfigDocument {
+	t.Helper()
+
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &node); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := DecodeDocument(&node)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return doc
+}
+
+func TestDecodeDocument_SimpleFormat(t *testing.T) {
+	doc := decodeTestDocument(t, `
+name: www
+subject:
+  common_name: "www.example.com"
+`)
+
+	if doc.Config == nil || doc.Config.Subject["common_name"] != "www.example.com" {
+		t.Error("Expected simple format document to become the config")
+	}
+	if doc.Name != "www" {
+		t.Errorf("Expected name 'www', got '%s'", doc.Name)
+	}
+}
+
+func TestDecodeDocument_SegmentsFormat(t *testing.T) {
+	doc := decodeTestDocument(t, `
+segments:
+  defaults:
+    serial_number: "1"
+merge:
+  - defaults
+`)
+
+	if doc.Config != nil {
+		t.Error("Expected no config for segments document without one")
+	}
+	if len(doc.Merge) != 1 {
+		t.Errorf("Expected merge list, got %v", doc.Merge)
+	}
+}
+
+func TestConfigDocument_CertificateDocument(t *testing.T) {
+	doc := decodeTestDocument(t, `
+vars:
+  env: prod
+conflicts: error
+segments:
+  defaults:
+    serial_number: "1"
+certificates:
+  www:
+    merge: [defaults]
+`)
+
+	entry, ok := doc.CertificateDocument("www")
+	if !ok {
+		t.Fatal("Expected certificate 'www'")
+	}
+	if entry.Name != "www" || len(entry.Merge) != 1 || entry.Segments == nil {
+		t.Errorf("Unexpected entry document: %+v", entry)
+	}
+	if entry.Conflicts != "error" || entry.Vars["env"][0] != "prod" {
+		t.Error("Expected vars and conflict mode to be shared")
+	}
+	if _, ok := doc.CertificateDocument("missing"); ok {
+		t.Error("Expected missing certificate not to be found")
+	}
+}
+
+func TestResolveDocument_DefaultName(t *testing.T) {
+	doc := decodeTestDocument(t, `serial_number: "1"`)
+
+	specs, err := ResolveDocument(doc, "0", ResolveOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if specs["0"] == nil || specs["0"].SerialNumber != "1" {
+		t.Errorf("Expected spec keyed by default name, got %v", specs)
+	}
+}
+
+func TestDecodeDocumentStrict(t *testing.T) {
+	tests := map[string]string{
+		"name: www\nvars: {host: www}\nsubject: {common_name: www}\n":           "",
+		"segments: {tls: {key_usage: []}}\nmerge: [tls]\nconfig: {is_ca: true}": "",
+		"subjct: {common_name: www}\n":                                          "line 1: unknown field 'subjct'",
+		"merge: [tls]\nsegments: {tls: {}}\nsubject: {}\n":                      "line 3: unknown field 'subject'",
+		"segments:\n  tls:\n    key_usag: []\nmerge: [tls]\n":                   "line 3: unknown field 'key_usag'",
+		"certificates: {www: {config: {dns: []}, merge: [], extra: 1}}\n":       "line 1: unknown field 'dns'; line 1: unknown field 'extra'",
+	}
+
+	for data, expected := range tests {
+		var node yaml.Node
+		if err := yaml.Unmarshal([]byte(data), &node); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := DecodeDocumentStrict(&node)
+		if expected == "" && err != nil {
+			t.Errorf("Expected %q to decode, got %v", data, err)
+		}
+		if expected != "" && (err == nil || err.Error() != expected) {
+			t.Errorf("Expected error %q for %q, got %v", expected, data, err)
+		}
+	}
+}
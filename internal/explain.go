@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"strconv"
+)
+
+// FieldOrigin records which source determines a value in the merged spec
+type FieldOrigin struct {
+	Field  string
+	Value  string
+	Source string
+}
+
+// ExplainSources reports where every value of the merged spec comes from, given
+// the sources returned by ResolveSources. Scalar fields and subject/issuer keys
+// report the last source that set them, list items report the source that
+// contributed them, and boolean and int fields report the last source, since
+// MergeSpecs applies them unconditionally.
+func ExplainSources(names []string, specs []*CertificateSpec) []FieldOrigin {
+	var origins []FieldOrigin
+
+	// Scalar fields, in order of first appearance
+	scalarIndex := make(map[string]int)
+	for i, spec := range specs {
+		if spec == nil {
+			continue
+		}
+		for _, field := range scalarFields(spec) {
+			origin := FieldOrigin{Field: field.path, Value: field.value, Source: names[i]}
+			if j, seen := scalarIndex[field.path]; seen {
+				origins[j] = origin
+				continue
+			}
+			scalarIndex[field.path] = len(origins)
+			origins = append(origins, origin)
+		}
+	}
+
+	// List items, which are appended by every source
+	for _, list := range []struct {
+		field string
+		items func(*CertificateSpec) []string
+	}{
+		{"key_usage", func(s *CertificateSpec) []string { return s.KeyUsage }},
+		{"ext_key_usage", func(s *CertificateSpec) []string { return s.ExtKeyUsage }},
+		{"dns_names", func(s *CertificateSpec) []string { return s.DNSNames }},
+		{"email_addresses", func(s *CertificateSpec) []string { return s.EmailAddresses }},
+		{"ip_addresses", func(s *CertificateSpec) []string { return s.IPAddresses }},
+		{"uris", func(s *CertificateSpec) []string { return s.URIs }},
+	} {
+		for i, spec := range specs {
+			if spec == nil {
+				continue
+			}
+			for _, item := range list.items(spec) {
+				origins = append(origins, FieldOrigin{Field: list.field, Value: item, Source: names[i]})
+			}
+		}
+	}
+
+	// Boolean and int fields - the last source wins
+	for i := len(specs) - 1; i >= 0; i-- {
+		last := specs[i]
+		if last == nil {
+			continue
+		}
+		if last.IsCA {
+			origins = append(origins, FieldOrigin{Field: "is_ca", Value: "true", Source: names[i]})
+		}
+		if last.MaxPathLen != 0 {
+			origins = append(origins, FieldOrigin{Field: "max_path_len", Value: strconv.Itoa(last.MaxPathLen), Source: names[i]})
+		}
+		if last.MaxPathLenZero {
+			origins = append(origins, FieldOrigin{Field: "max_path_len_zero", Value: "true", Source: names[i]})
+		}
+		if last.BasicConstraintsValid {
+			origins = append(origins, FieldOrigin{Field: "basic_constraints_valid", Value: "true", Source: names[i]})
+		}
+		break
+	}
+
+	return origins
+}
@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestExplainSources(t *testing.T) {
+	names := []string{"defaults", "web-server", "config"}
+	specs := []*CertificateSpec{
+		{
+			SignatureAlgorithm: "SHA256WithRSA",
+			Subject:            map[string]string{"country": "US"},
+			KeyUsage:           []string{"digital_signature"},
+			IsCA:               true,
+		},
+		{
+			SignatureAlgorithm: "SHA512WithRSA",
+			KeyUsage:           []string{"key_encipherment"},
+		},
+		{
+			Subject:               map[string]string{"common_name": "www.example.com"},
+			BasicConstraintsValid: true,
+		},
+	}
+
+	origins := ExplainSources(names, specs)
+
+	expected := []FieldOrigin{
+		{"signature_algorithm", "SHA512WithRSA", "web-server"},
+		{"subject.country", "US", "defaults"},
+		{"subject.common_name", "www.example.com", "config"},
+		{"key_usage", "digital_signature", "defaults"},
+		{"key_usage", "key_encipherment", "web-server"},
+		{"basic_constraints_valid", "true", "config"},
+	}
+	if len(origins) != len(expected) {
+		t.Fatalf("Expected %d origins, got %d: %v", len(expected), len(origins), origins)
+	}
+	for i := range expected {
+		if origins[i] != expected[i] {
+			t.Errorf("Origin %d: expected %+v, got %+v", i, expected[i], origins[i])
+		}
+	}
+}
+
+func TestExplainSources_Empty(t *testing.T) {
+	if origins := ExplainSources(nil, nil); len(origins) != 0 {
+		t.Errorf("Expected no origins, got %v", origins)
+	}
+}
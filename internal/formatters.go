@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"time"
+)
+
+// FormatPkixName converts a pkix.Name to a map of distinguished name components.
+// Only the first value of multi-valued attributes is kept.
+func FormatPkixName(name pkix.Name) map[string]string {
+	nameMap := make(map[string]string)
+
+	add := func(key string, values ...string) {
+		if len(values) > 0 && values[0] != "" {
+			nameMap[key] = values[0]
+		}
+	}
+
+	add(DNCommonName, name.CommonName)
+	add(DNCountry, name.Country...)
+	add(DNOrganization, name.Organization...)
+	add(DNOrganizationalUnit, name.OrganizationalUnit...)
+	add(DNLocality, name.Locality...)
+	add(DNProvince, name.Province...)
+	add(DNStreetAddress, name.StreetAddress...)
+	add(DNPostalCode, name.PostalCode...)
+	add(DNSerialNumber, name.SerialNumber)
+
+	if len(nameMap) == 0 {
+		return nil
+	}
+	return nameMap
+}
+
+// keyUsageNames lists the key usage bits in the order of constants.go
+var keyUsageNames = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, KeyUsageDigitalSignature},
+	{x509.KeyUsageContentCommitment, KeyUsageContentCommitment},
+	{x509.KeyUsageKeyEncipherment, KeyUsageKeyEncipherment},
+	{x509.KeyUsageDataEncipherment, KeyUsageDataEncipherment},
+	{x509.KeyUsageKeyAgreement, KeyUsageKeyAgreement},
+	{x509.KeyUsageCertSign, KeyUsageCertSign},
+	{x509.KeyUsageCRLSign, KeyUsageCRLSign},
+	{x509.KeyUsageEncipherOnly, KeyUsageEncipherOnly},
+	{x509.KeyUsageDecipherOnly, KeyUsageDecipherOnly},
+}
+
+// FormatKeyUsage converts x509.KeyUsage to its string representations
+func FormatKeyUsage(keyUsage x509.KeyUsage) []string {
+	var usages []string
+
+	for _, ku := range keyUsageNames {
+		if keyUsage&ku.usage != 0 {
+			usages = append(usages, ku.name)
+		}
+	}
+
+	return usages
+}
+
+// FormatExtKeyUsage converts []x509.ExtKeyUsage to string representations,
+// skipping usages without a name
+func FormatExtKeyUsage(extKeyUsage []x509.ExtKeyUsage) []string {
+	var usages []string
+
+	for _, usage := range extKeyUsage {
+		switch usage {
+		case x509.ExtKeyUsageAny:
+			usages = append(usages, ExtKeyUsageAny)
+		case x509.ExtKeyUsageServerAuth:
+			usages = append(usages, ExtKeyUsageServerAuth)
+		case x509.ExtKeyUsageClientAuth:
+			usages = append(usages, ExtKeyUsageClientAuth)
+		case x509.ExtKeyUsageCodeSigning:
+			usages = append(usages, ExtKeyUsageCodeSigning)
+		case x509.ExtKeyUsageEmailProtection:
+			usages = append(usages, ExtKeyUsageEmailProtection)
+		case x509.ExtKeyUsageIPSECEndSystem:
+			usages = append(usages, ExtKeyUsageIPSECEndSystem)
+		case x509.ExtKeyUsageIPSECTunnel:
+			usages = append(usages, ExtKeyUsageIPSECTunnel)
+		case x509.ExtKeyUsageIPSECUser:
+			usages = append(usages, ExtKeyUsageIPSECUser)
+		case x509.ExtKeyUsageTimeStamping:
+			usages = append(usages, ExtKeyUsageTimeStamping)
+		case x509.ExtKeyUsageOCSPSigning:
+			usages = append(usages, ExtKeyUsageOCSPSigning)
+		case x509.ExtKeyUsageMicrosoftServerGatedCrypto:
+			usages = append(usages, ExtKeyUsageMicrosoftServerGatedCrypto)
+		case x509.ExtKeyUsageNetscapeServerGatedCrypto:
+			usages = append(usages, ExtKeyUsageNetscapeServerGatedCrypto)
+		case x509.ExtKeyUsageMicrosoftCommercialCodeSigning:
+			usages = append(usages, ExtKeyUsageMicrosoftCommercialCodeSigning)
+		case x509.ExtKeyUsageMicrosoftKernelCodeSigning:
+			usages = append(usages, ExtKeyUsageMicrosoftKernelCodeSigning)
+		}
+	}
+
+	return usages
+}
+
+// FormatSignatureAlgorithm converts x509.SignatureAlgorithm to its string representation,
+// or "" for unknown algorithms
+func FormatSignatureAlgorithm(alg x509.SignatureAlgorithm) string {
+	switch alg {
+	case x509.MD2WithRSA:
+		return SigAlgMD2WithRSA
+	case x509.MD5WithRSA:
+		return SigAlgMD5WithRSA
+	case x509.SHA1WithRSA:
+		return SigAlgSHA1WithRSA
+	case x509.SHA256WithRSA:
+		return SigAlgSHA256WithRSA
+	case x509.SHA384WithRSA:
+		return SigAlgSHA384WithRSA
+	case x509.SHA512WithRSA:
+		return SigAlgSHA512WithRSA
+	case x509.DSAWithSHA1:
+		return SigAlgDSAWithSHA1
+	case x509.DSAWithSHA256:
+		return SigAlgDSAWithSHA256
+	case x509.ECDSAWithSHA1:
+		return SigAlgECDSAWithSHA1
+	case x509.ECDSAWithSHA256:
+		return SigAlgECDSAWithSHA256
+	case x509.ECDSAWithSHA384:
+		return SigAlgECDSAWithSHA384
+	case x509.ECDSAWithSHA512:
+		return SigAlgECDSAWithSHA512
+	case x509.SHA256WithRSAPSS:
+		return SigAlgSHA256WithRSAPSS
+	case x509.SHA384WithRSAPSS:
+		return SigAlgSHA384WithRSAPSS
+	case x509.SHA512WithRSAPSS:
+		return SigAlgSHA512WithRSAPSS
+	case x509.PureEd25519:
+		return SigAlgPureEd25519
+	default:
+		return ""
+	}
+}
+
+// FormatPublicKeyAlgorithm converts x509.PublicKeyAlgorithm to its string representation,
+// or "" for unknown algorithms
+func FormatPublicKeyAlgorithm(alg x509.PublicKeyAlgorithm) string {
+	switch alg {
+	case x509.RSA:
+		return PubKeyAlgRSA
+	case x509.DSA:
+		return PubKeyAlgDSA
+	case x509.ECDSA:
+		return PubKeyAlgECDSA
+	case x509.Ed25519:
+		return PubKeyAlgEd25519
+	default:
+		return ""
+	}
+}
+
+// SpecFromCertificate converts a certificate back to a CertificateSpec
+func SpecFromCertificate(cert *x509.Certificate) *CertificateSpec {
+	spec := &CertificateSpec{
+		Subject:               FormatPkixName(cert.Subject),
+		Issuer:                FormatPkixName(cert.Issuer),
+		KeyUsage:              FormatKeyUsage(cert.KeyUsage),
+		ExtKeyUsage:           FormatExtKeyUsage(cert.ExtKeyUsage),
+		DNSNames:              cert.DNSNames,
+		EmailAddresses:        cert.EmailAddresses,
+		IsCA:                  cert.IsCA,
+		MaxPathLen:            cert.MaxPathLen,
+		MaxPathLenZero:        cert.MaxPathLenZero,
+		BasicConstraintsValid: cert.BasicConstraintsValid,
+		SignatureAlgorithm:    FormatSignatureAlgorithm(cert.SignatureAlgorithm),
+		PublicKeyAlgorithm:    FormatPublicKeyAlgorithm(cert.PublicKeyAlgorithm),
+	}
+
+	if cert.SerialNumber != nil {
+		spec.SerialNumber = "0x" + cert.SerialNumber.Text(16)
+	}
+	if !cert.NotBefore.IsZero() {
+		spec.NotBefore = cert.NotBefore.UTC().Format(time.RFC3339)
+	}
+	if !cert.NotAfter.IsZero() {
+		spec.NotAfter = cert.NotAfter.UTC().Format(time.RFC3339)
+	}
+	// x509 reports -1 for an unset path length, which the YAML schema writes as 0
+	if spec.MaxPathLen < 0 {
+		spec.MaxPathLen = 0
+	}
+	for _, ip := range cert.IPAddresses {
+		spec.IPAddresses = append(spec.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		spec.URIs = append(spec.URIs, uri.String())
+	}
+
+	return spec
+}
+
+// SpecFromRequest converts a certificate signing request to a CertificateSpec
+func SpecFromRequest(csr *x509.CertificateRequest) *CertificateSpec {
+	spec := &CertificateSpec{
+		Subject:            FormatPkixName(csr.Subject),
+		DNSNames:           csr.DNSNames,
+		EmailAddresses:     csr.EmailAddresses,
+		SignatureAlgorithm: FormatSignatureAlgorithm(csr.SignatureAlgorithm),
+		PublicKeyAlgorithm: FormatPublicKeyAlgorithm(csr.PublicKeyAlgorithm),
+	}
+
+	for _, ip := range csr.IPAddresses {
+		spec.IPAddresses = append(spec.IPAddresses, ip.String())
+	}
+	for _, uri := range csr.URIs {
+		spec.URIs = append(spec.URIs, uri.String())
+	}
+
+	return spec
+}
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFormatPkixName_RoundTrip(t *testing.T) {
+	nameMap := map[string]string{
+		DNCommonName:         "example.com",
+		DNCountry:            "US",
+		DNOrganization:       "Example Corp",
+		DNOrganizationalUnit: "IT Department",
+		DNLocality:           "San Francisco",
+		DNProvince:           "California",
+		DNStreetAddress:      "123 Main St",
+		DNPostalCode:         "94102",
+		DNSerialNumber:       "12345",
+	}
+
+	result := FormatPkixName(ParsePkixName(nameMap))
+
+	if !reflect.DeepEqual(result, nameMap) {
+		t.Errorf("Expected %v, got %v", nameMap, result)
+	}
+}
+
+func TestFormatPkixName_Empty(t *testing.T) {
+	if result := FormatPkixName(pkix.Name{}); result != nil {
+		t.Errorf("Expected nil map, got %v", result)
+	}
+}
+
+func TestFormatKeyUsage_RoundTrip(t *testing.T) {
+	usages := []string{
+		KeyUsageDigitalSignature,
+		KeyUsageContentCommitment,
+		KeyUsageKeyEncipherment,
+		KeyUsageDataEncipherment,
+		KeyUsageKeyAgreement,
+		KeyUsageCertSign,
+		KeyUsageCRLSign,
+		KeyUsageEncipherOnly,
+		KeyUsageDecipherOnly,
+	}
+
+	result := FormatKeyUsage(ParseKeyUsage(usages))
+
+	if !reflect.DeepEqual(result, usages) {
+		t.Errorf("Expected %v, got %v", usages, result)
+	}
+}
+
+func TestFormatExtKeyUsage_RoundTrip(t *testing.T) {
+	usages := []string{
+		ExtKeyUsageAny,
+		ExtKeyUsageServerAuth,
+		ExtKeyUsageClientAuth,
+		ExtKeyUsageCodeSigning,
+		ExtKeyUsageEmailProtection,
+		ExtKeyUsageIPSECEndSystem,
+		ExtKeyUsageIPSECTunnel,
+		ExtKeyUsageIPSECUser,
+		ExtKeyUsageTimeStamping,
+		ExtKeyUsageOCSPSigning,
+		ExtKeyUsageMicrosoftServerGatedCrypto,
+		ExtKeyUsageNetscapeServerGatedCrypto,
+		ExtKeyUsageMicrosoftCommercialCodeSigning,
+		ExtKeyUsageMicrosoftKernelCodeSigning,
+	}
+
+	result := FormatExtKeyUsage(ParseExtKeyUsage(usages))
+
+	if !reflect.DeepEqual(result, usages) {
+		t.Errorf("Expected %v, got %v", usages, result)
+	}
+}
+
+func TestFormatSignatureAlgorithm_RoundTrip(t *testing.T) {
+	algorithms := []string{
+		SigAlgMD2WithRSA, SigAlgMD5WithRSA, SigAlgSHA1WithRSA, SigAlgSHA256WithRSA,
+		SigAlgSHA384WithRSA, SigAlgSHA512WithRSA, SigAlgDSAWithSHA1, SigAlgDSAWithSHA256,
+		SigAlgECDSAWithSHA1, SigAlgECDSAWithSHA256, SigAlgECDSAWithSHA384, SigAlgECDSAWithSHA512,
+		SigAlgSHA256WithRSAPSS, SigAlgSHA384WithRSAPSS, SigAlgSHA512WithRSAPSS, SigAlgPureEd25519,
+	}
+
+	for _, alg := range algorithms {
+		if result := FormatSignatureAlgorithm(ParseSignatureAlgorithm(alg)); result != alg {
+			t.Errorf("Expected '%s', got '%s'", alg, result)
+		}
+	}
+
+	if result := FormatSignatureAlgorithm(x509.UnknownSignatureAlgorithm); result != "" {
+		t.Errorf("Expected empty string for unknown algorithm, got '%s'", result)
+	}
+}
+
+func TestFormatPublicKeyAlgorithm_RoundTrip(t *testing.T) {
+	for _, alg := range []string{PubKeyAlgRSA, PubKeyAlgDSA, PubKeyAlgECDSA, PubKeyAlgEd25519} {
+		if result := FormatPublicKeyAlgorithm(ParsePublicKeyAlgorithm(alg)); result != alg {
+			t.Errorf("Expected '%s', got '%s'", alg, result)
+		}
+	}
+
+	if result := FormatPublicKeyAlgorithm(x509.UnknownPublicKeyAlgorithm); result != "" {
+		t.Errorf("Expected empty string for unknown algorithm, got '%s'", result)
+	}
+}
+
+func TestSpecFromCertificate(t *testing.T) {
+	uri, _ := url.Parse("spiffe://example.com/api")
+	cert := &x509.Certificate{
+		SerialNumber:          big.NewInt(0x1234),
+		Subject:               pkix.Name{CommonName: "example.com", Organization: []string{"Example Corp"}},
+		Issuer:                pkix.Name{CommonName: "Example CA"},
+		NotBefore:             time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:              []string{"example.com"},
+		IPAddresses:           []net.IP{net.ParseIP("192.0.2.1")},
+		URIs:                  []*url.URL{uri},
+		MaxPathLen:            -1,
+		BasicConstraintsValid: true,
+		SignatureAlgorithm:    x509.ECDSAWithSHA256,
+		PublicKeyAlgorithm:    x509.ECDSA,
+	}
+
+	spec := SpecFromCertificate(cert)
+
+	expected := &CertificateSpec{
+		SerialNumber:          "0x1234",
+		Subject:               map[string]string{DNCommonName: "example.com", DNOrganization: "Example Corp"},
+		Issuer:                map[string]string{DNCommonName: "Example CA"},
+		NotBefore:             "2024-01-01T00:00:00Z",
+		NotAfter:              "2025-01-01T00:00:00Z",
+		KeyUsage:              []string{KeyUsageDigitalSignature, KeyUsageKeyEncipherment},
+		ExtKeyUsage:           []string{ExtKeyUsageServerAuth},
+		DNSNames:              []string{"example.com"},
+		IPAddresses:           []string{"192.0.2.1"},
+		URIs:                  []string{"spiffe://example.com/api"},
+		BasicConstraintsValid: true,
+		SignatureAlgorithm:    SigAlgECDSAWithSHA256,
+		PublicKeyAlgorithm:    PubKeyAlgECDSA,
+	}
+	if !reflect.DeepEqual(spec, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, spec)
+	}
+}
+
+func TestSpecFromRequest(t *testing.T) {
+	csr := &x509.CertificateRequest{
+		Subject:            pkix.Name{CommonName: "example.com"},
+		DNSNames:           []string{"example.com", "www.example.com"},
+		IPAddresses:        []net.IP{net.ParseIP("2001:db8::1")},
+		SignatureAlgorithm: x509.PureEd25519,
+		PublicKeyAlgorithm: x509.Ed25519,
+	}
+
+	spec := SpecFromRequest(csr)
+
+	if spec.Subject[DNCommonName] != "example.com" {
+		t.Errorf("Expected common name 'example.com', got '%s'", spec.Subject[DNCommonName])
+	}
+	if len(spec.DNSNames) != 2 || spec.IPAddresses[0] != "2001:db8::1" {
+		t.Errorf("Unexpected SANs: %v %v", spec.DNSNames, spec.IPAddresses)
+	}
+	if spec.SignatureAlgorithm != SigAlgPureEd25519 || spec.PublicKeyAlgorithm != PubKeyAlgEd25519 {
+		t.Errorf("Unexpected algorithms: %s %s", spec.SignatureAlgorithm, spec.PublicKeyAlgorithm)
+	}
+}
@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExpandIncludes returns a copy of the document with the segments and vars of every
+// file listed in 'include' added. Files are read from fsys, and may include further
+// files relative to their own directory. The document's own segments and vars take
+// precedence over included ones, and later includes over earlier ones. With strict
+// set, unknown keys in included files are rejected like in the document itself.
+func ExpandIncludes(doc *ConfigDocument, fsys fs.FS, strict bool) (*ConfigDocument, error) {
+	if len(doc.Include) == 0 {
+		return doc, nil
+	}
+	if fsys == nil {
+		return nil, fmt.Errorf("include '%s' requires an include filesystem", doc.Include[0])
+	}
+
+	segments := make(map[string]*CertificateSpec)
+	vars := make(map[string]VarValue)
+	if err := loadIncludes(fsys, ".", doc.Include, strict, segments, vars, nil); err != nil {
+		return nil, err
+	}
+
+	for name, segment := range doc.Segments {
+		segments[name] = segment
+	}
+	for name, value := range doc.Vars {
+		vars[name] = value
+	}
+
+	expanded := *doc
+	expanded.Include = nil
+	expanded.Segments = segments
+	expanded.Vars = vars
+	return &expanded, nil
+}
+
+// ReadFile reads a file a document refers to, such as a 'public_key' or a signer's
+// certificate. Relative paths are read from fsys, the filesystem includes are read
+// from, so that they resolve against the document. Absolute paths, and every path
+// when fsys is nil, are read from the operating system.
+func ReadFile(fsys fs.FS, name string) ([]byte, error) {
+	if fsys == nil || filepath.IsAbs(name) {
+		return os.ReadFile(name)
+	}
+
+	file := path.Clean(filepath.ToSlash(name))
+	if !fs.ValidPath(file) {
+		return nil, fmt.Errorf("invalid path '%s', expected a path below the document's directory or an absolute path", name)
+	}
+	return fs.ReadFile(fsys, file)
+}
+
+// loadIncludes reads the included files in order into segments and vars. Stack
+// holds the files being included, to detect include cycles.
+func loadIncludes(fsys fs.FS, dir string, includes []string, strict bool, segments map[string]*CertificateSpec, vars map[string]VarValue, stack []string) error {
+	for _, include := range includes {
+		file := path.Clean(path.Join(dir, include))
+		if !fs.ValidPath(file) {
+			return fmt.Errorf("invalid include '%s'", include)
+		}
+		for _, parent := range stack {
+			if parent == file {
+				return fmt.Errorf("include '%s' forms a cycle", file)
+			}
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("include '%s': %w", include, err)
+		}
+
+		included, err := decodeInclude(data, strict)
+		if err != nil {
+			return fmt.Errorf("include '%s': %w", include, err)
+		}
+		if included.Config != nil || included.Merge != nil || included.Certificates != nil {
+			return fmt.Errorf("include '%s': only 'segments', 'vars' and 'include' are allowed", include)
+		}
+
+		if err := loadIncludes(fsys, path.Dir(file), included.Include, strict, segments, vars, append(stack, file)); err != nil {
+			return err
+		}
+		for name, segment := range included.Segments {
+			segments[name] = segment
+		}
+		for name, value := range included.Vars {
+			vars[name] = value
+		}
+	}
+	return nil
+}
+
+// decodeInclude decodes an included file, rejecting unknown keys when strict is set
+func decodeInclude(data []byte, strict bool) (*ConfigDocument, error) {
+	included := &ConfigDocument{}
+	if !strict {
+		return included, yaml.Unmarshal(data, included)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(included); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return nil, unknownFieldsError(typeErr)
+		}
+		return nil, err
+	}
+	return included, nil
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExpandIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"common/base.yaml": {Data: []byte(`
+include: [tls.yaml]
+vars:
+  org: Example
+segments:
+  defaults:
+    subject:
+      organization: "${org}"
+  tls:
+    key_usage: [key_encipherment]
+`)},
+		"common/tls.yaml": {Data: []byte(`
+segments:
+  tls:
+    ext_key_usage: [server_auth]
+`)},
+	}
+
+	doc := &ConfigDocument{
+		Include:  []string{"common/base.yaml"},
+		Merge:    []string{"defaults", "tls"},
+		Segments: map[string]*CertificateSpec{"local": {IsCA: true}},
+		Vars:     map[string]VarValue{"org": {"Local"}},
+	}
+
+	expanded, err := ExpandIncludes(doc, fsys, false)
+	if err != nil {
+		t.Fatalf("Failed to expand includes: %v", err)
+	}
+
+	if expanded.Include != nil || doc.Include == nil {
+		t.Error("Expected the includes to be expanded on a copy")
+	}
+	if len(expanded.Segments) != 3 || expanded.Segments["local"] == nil {
+		t.Errorf("Expected included and local segments, got %v", expanded.Segments)
+	}
+	if ku := expanded.Segments["tls"].KeyUsage; len(ku) != 1 || ku[0] != "key_encipherment" {
+		t.Errorf("Expected the including file to override the nested include, got %+v", expanded.Segments["tls"])
+	}
+	if org := expanded.Vars["org"]; len(org) != 1 || org[0] != "Local" {
+		t.Errorf("Expected the document's vars to take precedence, got %v", org)
+	}
+
+	spec, err := ResolveConfigWithOptions(&ConfigDocument{Include: []string{"common/base.yaml"}, Merge: []string{"defaults"}}, ResolveOptions{IncludeFS: fsys})
+	if err != nil || spec.Subject["organization"] != "Example" {
+		t.Errorf("Expected included segment and vars to resolve, got %+v (%v)", spec, err)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	fsys := fstest.MapFS{"certs/ca.pem": {Data: []byte("from fs")}}
+	absolute := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(absolute, []byte("from os"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"certs/ca.pem", "from fs"},
+		{"./certs/../certs/ca.pem", "from fs"},
+		{absolute, "from os"},
+	}
+	for _, tt := range tests {
+		if data, err := ReadFile(fsys, tt.name); err != nil || string(data) != tt.expected {
+			t.Errorf("ReadFile(%q): expected %q, got %q (%v)", tt.name, tt.expected, data, err)
+		}
+	}
+
+	if _, err := ReadFile(fsys, "../ca.pem"); err == nil || !strings.HasPrefix(err.Error(), "invalid path '../ca.pem'") {
+		t.Errorf("Expected a path outside the filesystem to fail, got %v", err)
+	}
+	if _, err := ReadFile(nil, "certs/ca.pem"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a relative path without a filesystem to be read from the working directory, got %v", err)
+	}
+}
+
+func TestExpandIncludes_Errors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.yaml":      {Data: []byte("include: [b.yaml]\n")},
+		"b.yaml":      {Data: []byte("include: [a.yaml]\n")},
+		"config.yaml": {Data: []byte("config: {is_ca: true}\n")},
+	}
+
+	tests := []struct {
+		fsys     fstest.MapFS
+		include  string
+		expected string
+	}{
+		{nil, "a.yaml", "include 'a.yaml' requires an include filesystem"},
+		{fsys, "missing.yaml", "include 'missing.yaml': open missing.yaml: file does not exist"},
+		{fsys, "../a.yaml", "invalid include '../a.yaml'"},
+		{fsys, "a.yaml", "include 'a.yaml' forms a cycle"},
+		{fsys, "config.yaml", "include 'config.yaml': only 'segments', 'vars' and 'include' are allowed"},
+	}
+
+	for _, tt := range tests {
+		doc := &ConfigDocument{Include: []string{tt.include}}
+		var err error
+		if tt.fsys == nil {
+			_, err = ExpandIncludes(doc, nil, false)
+		} else {
+			_, err = ExpandIncludes(doc, tt.fsys, false)
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+		}
+	}
+}
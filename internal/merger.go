@@ -1,8 +1,14 @@
 package internal
 
-import "fmt"
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"slices"
+)
 
-// MergeSpecs merges multiple CertificateSpec objects, with later specs overriding earlier ones
+// MergeSpecs merges multiple CertificateSpec objects, with later specs overriding earlier ones.
+// Lists are combined in order without duplicates.
 func MergeSpecs(specs ...*CertificateSpec) *CertificateSpec {
 	result := &CertificateSpec{}
 
@@ -27,6 +33,34 @@ func MergeSpecs(specs ...*CertificateSpec) *CertificateSpec {
 		if spec.PublicKeyAlgorithm != "" {
 			result.PublicKeyAlgorithm = spec.PublicKeyAlgorithm
 		}
+		if spec.PublicKey != "" {
+			result.PublicKey = spec.PublicKey
+		}
+		if spec.InsecureTestSeed != "" {
+			result.InsecureTestSeed = spec.InsecureTestSeed
+		}
+		if spec.Signer != nil {
+			result.Signer = spec.Signer
+		}
+		if spec.KeyEncryption != nil {
+			result.KeyEncryption = spec.KeyEncryption
+		}
+
+		// Merge validity fields (later values override)
+		if spec.Validity != nil {
+			if result.Validity == nil {
+				result.Validity = &ValiditySpec{}
+			}
+			if spec.Validity.Duration != "" {
+				result.Validity.Duration = spec.Validity.Duration
+			}
+			if spec.Validity.Backdate != "" {
+				result.Validity.Backdate = spec.Validity.Backdate
+			}
+			if spec.Validity.Anchor != "" {
+				result.Validity.Anchor = spec.Validity.Anchor
+			}
+		}
 
 		// Merge maps (later values extend/override)
 		if spec.Subject != nil {
@@ -46,25 +80,13 @@ func MergeSpecs(specs ...*CertificateSpec) *CertificateSpec {
 			}
 		}
 
-		// Merge slices (later values extend)
-		if len(spec.KeyUsage) > 0 {
-			result.KeyUsage = append(result.KeyUsage, spec.KeyUsage...)
-		}
-		if len(spec.ExtKeyUsage) > 0 {
-			result.ExtKeyUsage = append(result.ExtKeyUsage, spec.ExtKeyUsage...)
-		}
-		if len(spec.DNSNames) > 0 {
-			result.DNSNames = append(result.DNSNames, spec.DNSNames...)
-		}
-		if len(spec.EmailAddresses) > 0 {
-			result.EmailAddresses = append(result.EmailAddresses, spec.EmailAddresses...)
-		}
-		if len(spec.IPAddresses) > 0 {
-			result.IPAddresses = append(result.IPAddresses, spec.IPAddresses...)
-		}
-		if len(spec.URIs) > 0 {
-			result.URIs = append(result.URIs, spec.URIs...)
-		}
+		// Merge slices (later values extend, skipping values already present)
+		result.KeyUsage = appendUnique(result.KeyUsage, spec.KeyUsage)
+		result.ExtKeyUsage = appendUnique(result.ExtKeyUsage, spec.ExtKeyUsage)
+		result.DNSNames = appendUnique(result.DNSNames, spec.DNSNames)
+		result.EmailAddresses = appendUnique(result.EmailAddresses, spec.EmailAddresses)
+		result.IPAddresses = appendUnique(result.IPAddresses, spec.IPAddresses)
+		result.URIs = appendUnique(result.URIs, spec.URIs)
 
 		// Boolean and int fields - last one wins
 		// Note: We can't distinguish between "not set" and "false/0" for non-pointer fields
@@ -78,32 +100,176 @@ func MergeSpecs(specs ...*CertificateSpec) *CertificateSpec {
 	return result
 }
 
+// appendUnique appends the values not yet in list, keeping their order
+func appendUnique(list, values []string) []string {
+	for _, value := range values {
+		if !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// ResolveOptions controls how ResolveConfigWithOptions resolves a ConfigDocument
+type ResolveOptions struct {
+	// ConflictMode overrides the document's 'conflicts' setting when non-empty
+	ConflictMode string
+
+	// OnConflict is called for every conflict found in "warn" mode. Conflicts are
+	// written to the standard logger when it is nil.
+	OnConflict func(MergeConflict)
+
+	// Vars supplies variable values that take precedence over the document's 'vars'
+	Vars map[string][]string
+
+	// UseEnv enables looking up variables in the environment, prefixed with EnvPrefix
+	UseEnv    bool
+	EnvPrefix string
+
+	// IncludeFS is the filesystem files listed in 'include' are read from
+	IncludeFS fs.FS
+
+	// Strict rejects unknown keys in included files
+	Strict bool
+}
+
 // ResolveConfig processes a ConfigDocument and returns the final merged CertificateSpec
 func ResolveConfig(doc *ConfigDocument) (*CertificateSpec, error) {
+	return ResolveConfigWithOptions(doc, ResolveOptions{})
+}
+
+// ResolveConfigWithOptions processes a ConfigDocument like ResolveConfig, applying
+// variable interpolation and conflict detection as configured by opts and the document
+func ResolveConfigWithOptions(doc *ConfigDocument, opts ResolveOptions) (*CertificateSpec, error) {
 	if doc.Config == nil && len(doc.Merge) == 0 {
 		// No segments, treat the entire document as a spec
 		return doc.Config, nil
 	}
 
-	var specsToMerge []*CertificateSpec
+	_, specsToMerge, err := ResolveSources(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(specsToMerge) == 0 {
+		return &CertificateSpec{}, nil
+	}
+
+	return MergeSpecs(specsToMerge...), nil
+}
+
+// ResolveSources returns the interpolated specs that make up a document in merge
+// order: every segment referenced in 'merge', followed by the main config. The
+// returned names are the merge references and "config".
+func ResolveSources(doc *ConfigDocument, opts ResolveOptions) ([]string, []*CertificateSpec, error) {
+	doc, err := ExpandIncludes(doc, opts.IncludeFS, opts.Strict)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scope := &VarScope{
+		Values:    opts.Vars,
+		UseEnv:    opts.UseEnv,
+		EnvPrefix: opts.EnvPrefix,
+		Defaults:  doc.Vars,
+	}
+
+	var names []string
+	var specs []*CertificateSpec
 
 	// First, merge all segments referenced in 'merge'
-	for _, segmentName := range doc.Merge {
-		segment, exists := doc.Segments[segmentName]
-		if !exists {
-			return nil, fmt.Errorf("segment '%s' referenced in merge but not defined", segmentName)
+	for _, ref := range doc.Merge {
+		segment, err := resolveSegment(doc, ref, scope)
+		if err != nil {
+			return nil, nil, err
 		}
-		specsToMerge = append(specsToMerge, segment)
+		names = append(names, ref)
+		specs = append(specs, segment)
+	}
+
+	// Check the merged segments for conflicting values. The main config is an
+	// explicit override and is never reported as a conflict.
+	if err := checkConflicts(doc, opts, specs); err != nil {
+		return nil, nil, err
 	}
 
 	// Finally, apply the main config (which overrides segments)
 	if doc.Config != nil {
-		specsToMerge = append(specsToMerge, doc.Config)
+		config, err := scope.InterpolateSpec(doc.Config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("config: %w", err)
+		}
+		names = append(names, "config")
+		specs = append(specs, config)
 	}
 
-	if len(specsToMerge) == 0 {
-		return &CertificateSpec{}, nil
+	return names, specs, nil
+}
+
+// ResolveCertificates resolves every entry in the document's 'certificates' map
+// against the shared top-level segments and returns the specs keyed by name
+func ResolveCertificates(doc *ConfigDocument, opts ResolveOptions) (map[string]*CertificateSpec, error) {
+	if doc.Config != nil || len(doc.Merge) > 0 {
+		return nil, fmt.Errorf("'certificates' cannot be combined with a top-level 'config' or 'merge'")
 	}
 
-	return MergeSpecs(specsToMerge...), nil
+	// Read the included files once for all entries
+	doc, err := ExpandIncludes(doc, opts.IncludeFS, opts.Strict)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make(map[string]*CertificateSpec, len(doc.Certificates))
+	for name := range doc.Certificates {
+		entryDoc, ok := doc.CertificateDocument(name)
+		if !ok {
+			return nil, fmt.Errorf("certificate '%s' not defined", name)
+		}
+
+		spec, err := ResolveConfigWithOptions(entryDoc, opts)
+		if err != nil {
+			return nil, fmt.Errorf("certificate '%s': %w", name, err)
+		}
+		if spec == nil {
+			spec = &CertificateSpec{}
+		}
+		specs[name] = spec
+	}
+
+	return specs, nil
+}
+
+// checkConflicts runs conflict detection over the merged segments according to the conflict mode
+func checkConflicts(doc *ConfigDocument, opts ResolveOptions, segments []*CertificateSpec) error {
+	mode := doc.Conflicts
+	if opts.ConflictMode != "" {
+		mode = opts.ConflictMode
+	}
+
+	switch mode {
+	case "", ConflictModeOff:
+		return nil
+	case ConflictModeWarn, ConflictModeError:
+	default:
+		return fmt.Errorf("unknown conflict mode '%s'", mode)
+	}
+
+	conflicts := FindConflicts(doc.Merge, segments)
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	if mode == ConflictModeError {
+		return &ConflictError{Conflicts: conflicts}
+	}
+
+	onConflict := opts.OnConflict
+	if onConflict == nil {
+		onConflict = func(c MergeConflict) { log.Printf("warning: %s", c) }
+	}
+	for _, conflict := range conflicts {
+		onConflict(conflict)
+	}
+
+	return nil
 }
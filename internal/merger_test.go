@@ -206,6 +206,20 @@ func TestMergeSpecs_SlicesAppend(t *testing.T) {
 	}
 }
 
+func TestMergeSpecs_SlicesWithoutDuplicates(t *testing.T) {
+	result := MergeSpecs(
+		&CertificateSpec{KeyUsage: []string{"digital_signature"}, DNSNames: []string{"a.example.com", "a.example.com"}},
+		&CertificateSpec{KeyUsage: []string{"digital_signature", "key_encipherment"}, DNSNames: []string{"b.example.com", "a.example.com"}},
+	)
+
+	if len(result.KeyUsage) != 2 || result.KeyUsage[0] != "digital_signature" || result.KeyUsage[1] != "key_encipherment" {
+		t.Errorf("Expected [digital_signature key_encipherment], got %v", result.KeyUsage)
+	}
+	if len(result.DNSNames) != 2 || result.DNSNames[0] != "a.example.com" || result.DNSNames[1] != "b.example.com" {
+		t.Errorf("Expected [a.example.com b.example.com], got %v", result.DNSNames)
+	}
+}
+
 func TestMergeSpecs_BooleanFieldsLastWins(t *testing.T) {
 	spec1 := &CertificateSpec{
 		IsCA:                  true,
@@ -501,3 +515,111 @@ func TestResolveConfig_MergeWithoutConfig(t *testing.T) {
 		t.Error("Expected Subject from segment")
 	}
 }
+
+func TestResolveCertificates_SharedSegments(t *testing.T) {
+	doc := &ConfigDocument{
+		Segments: map[string]*CertificateSpec{
+			"defaults": {
+				Issuer:   map[string]string{"common_name": "Example CA"},
+				KeyUsage: []string{"digital_signature"},
+			},
+			"web-server": {ExtKeyUsage: []string{"server_auth"}},
+			"client":     {ExtKeyUsage: []string{"client_auth"}},
+		},
+		Certificates: map[string]*CertificateEntry{
+			"www": {
+				Merge:  []string{"defaults", "web-server"},
+				Config: &CertificateSpec{Subject: map[string]string{"common_name": "www.example.com"}},
+			},
+			"agent": {
+				Merge: []string{"defaults", "client"},
+			},
+		},
+	}
+
+	result, err := ResolveCertificates(doc, ResolveOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("Expected 2 certificates, got %d", len(result))
+	}
+	if result["www"].Subject["common_name"] != "www.example.com" {
+		t.Error("Expected www subject from its config")
+	}
+	if result["www"].Issuer["common_name"] != "Example CA" || result["agent"].Issuer["common_name"] != "Example CA" {
+		t.Error("Expected issuer from shared defaults segment")
+	}
+	if len(result["agent"].ExtKeyUsage) != 1 || result["agent"].ExtKeyUsage[0] != "client_auth" {
+		t.Errorf("Expected agent ExtKeyUsage [client_auth], got %v", result["agent"].ExtKeyUsage)
+	}
+	if len(doc.Segments["defaults"].KeyUsage) != 1 {
+		t.Error("Expected shared segment not to be modified by merging")
+	}
+}
+
+func TestResolveCertificates_EmptyEntry(t *testing.T) {
+	doc := &ConfigDocument{
+		Certificates: map[string]*CertificateEntry{"empty": nil},
+	}
+
+	result, err := ResolveCertificates(doc, ResolveOptions{})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result["empty"] == nil {
+		t.Error("Expected empty spec for empty entry")
+	}
+}
+
+func TestResolveCertificates_MissingSegment(t *testing.T) {
+	doc := &ConfigDocument{
+		Certificates: map[string]*CertificateEntry{
+			"www": {Merge: []string{"nonexistent"}},
+		},
+	}
+
+	_, err := ResolveCertificates(doc, ResolveOptions{})
+
+	expectedError := "certificate 'www': segment 'nonexistent' referenced in merge but not defined"
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("Expected error '%s', got %v", expectedError, err)
+	}
+}
+
+func TestResolveCertificates_TopLevelConfig(t *testing.T) {
+	doc := &ConfigDocument{
+		Config:       &CertificateSpec{SerialNumber: "1"},
+		Certificates: map[string]*CertificateEntry{"www": {}},
+	}
+
+	_, err := ResolveCertificates(doc, ResolveOptions{})
+
+	if err == nil {
+		t.Error("Expected error when combining certificates with top-level config")
+	}
+}
+
+func TestMergeSpecs_ValidityFieldsOverride(t *testing.T) {
+	spec1 := &CertificateSpec{
+		Validity: &ValiditySpec{Duration: "1y", Backdate: "5m"},
+	}
+
+	spec2 := &CertificateSpec{
+		Validity: &ValiditySpec{Duration: "90d"},
+	}
+
+	result := MergeSpecs(spec1, spec2)
+
+	if result.Validity.Duration != "90d" {
+		t.Errorf("Expected Duration '90d' (last value), got '%s'", result.Validity.Duration)
+	}
+	if result.Validity.Backdate != "5m" {
+		t.Errorf("Expected Backdate from spec1, got '%s'", result.Validity.Backdate)
+	}
+	if spec1.Validity.Duration != "1y" {
+		t.Error("Expected input spec not to be modified")
+	}
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SegmentRef is a reference to a segment in a merge list, optionally with
+// parameter arguments: "service(name=api, namespace=prod)"
+type SegmentRef struct {
+	Name string
+	Args map[string]string
+}
+
+// ParseSegmentRef parses a merge list entry into a SegmentRef
+func ParseSegmentRef(ref string) (SegmentRef, error) {
+	ref = strings.TrimSpace(ref)
+
+	open := strings.IndexByte(ref, '(')
+	if open < 0 {
+		return SegmentRef{Name: ref}, nil
+	}
+	if !strings.HasSuffix(ref, ")") || open == 0 {
+		return SegmentRef{}, fmt.Errorf("invalid segment reference '%s'", ref)
+	}
+
+	parsed := SegmentRef{
+		Name: strings.TrimSpace(ref[:open]),
+		Args: make(map[string]string),
+	}
+
+	argList := strings.TrimSpace(ref[open+1 : len(ref)-1])
+	if argList == "" {
+		return parsed, nil
+	}
+
+	for _, arg := range strings.Split(argList, ",") {
+		key, value, ok := strings.Cut(arg, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return SegmentRef{}, fmt.Errorf("invalid argument '%s' in segment reference '%s'", strings.TrimSpace(arg), ref)
+		}
+		if _, exists := parsed.Args[key]; exists {
+			return SegmentRef{}, fmt.Errorf("parameter '%s' given more than once in segment reference '%s'", key, ref)
+		}
+		parsed.Args[key] = unquote(strings.TrimSpace(value))
+	}
+
+	return parsed, nil
+}
+
+// unquote strips matching single or double quotes around a value
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// bindParams validates the arguments of a reference against the segment's declared
+// parameters and returns the value of every parameter, applying defaults. A segment
+// without a body declares no parameters.
+func (s *CertificateSpec) bindParams(ref SegmentRef) (map[string]string, error) {
+	if s == nil || s.Params == nil {
+		if len(ref.Args) > 0 {
+			return nil, fmt.Errorf("segment '%s' does not declare parameters", ref.Name)
+		}
+		return nil, nil
+	}
+
+	for _, name := range sortedKeys(ref.Args) {
+		if _, declared := s.Params[name]; !declared {
+			return nil, fmt.Errorf("segment '%s' has no parameter '%s'", ref.Name, name)
+		}
+	}
+
+	values := make(map[string]string, len(s.Params))
+	for _, name := range sortedParamNames(s.Params) {
+		if value, ok := ref.Args[name]; ok {
+			values[name] = value
+			continue
+		}
+		if s.Params[name] == nil {
+			return nil, fmt.Errorf("segment '%s' requires parameter '%s'", ref.Name, name)
+		}
+		values[name] = *s.Params[name]
+	}
+
+	return values, nil
+}
+
+// resolveSegment looks up a merge list entry, binds its parameters and returns the
+// segment with parameters and variables interpolated
+func resolveSegment(doc *ConfigDocument, ref string, scope *VarScope) (*CertificateSpec, error) {
+	parsed, err := ParseSegmentRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	segment, exists := doc.Segments[parsed.Name]
+	if !exists {
+		return nil, fmt.Errorf("segment '%s' referenced in merge but not defined", parsed.Name)
+	}
+
+	// Arguments may themselves reference document variables
+	for name, value := range parsed.Args {
+		if parsed.Args[name], err = scope.Interpolate(value); err != nil {
+			return nil, fmt.Errorf("segment '%s': parameter '%s': %w", ref, name, err)
+		}
+	}
+
+	params, err := segment.bindParams(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := scope.With(params).InterpolateSpec(segment)
+	if err != nil {
+		return nil, fmt.Errorf("segment '%s': %w", ref, err)
+	}
+	return spec, nil
+}
+
+func sortedParamNames(params map[string]*string) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
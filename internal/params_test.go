@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"testing"
+)
+
+func TestParseSegmentRef_Plain(t *testing.T) {
+	ref, err := ParseSegmentRef("defaults")
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ref.Name != "defaults" || ref.Args != nil {
+		t.Errorf("Expected plain reference, got %+v", ref)
+	}
+}
+
+func TestParseSegmentRef_WithArgs(t *testing.T) {
+	ref, err := ParseSegmentRef(`service(name=api, namespace="prod")`)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ref.Name != "service" {
+		t.Errorf("Expected name 'service', got '%s'", ref.Name)
+	}
+	if ref.Args["name"] != "api" || ref.Args["namespace"] != "prod" {
+		t.Errorf("Unexpected arguments: %v", ref.Args)
+	}
+}
+
+func TestParseSegmentRef_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"service(name=api":         "invalid segment reference 'service(name=api'",
+		"(name=api)":               "invalid segment reference '(name=api)'",
+		"service(api)":             "invalid argument 'api' in segment reference 'service(api)'",
+		"service(name=a, name=b)":  "parameter 'name' given more than once in segment reference 'service(name=a, name=b)'",
+		"service(name=api, =prod)": "invalid argument '=prod' in segment reference 'service(name=api, =prod)'",
+	}
+
+	for input, expected := range tests {
+		_, err := ParseSegmentRef(input)
+		if err == nil || err.Error() != expected {
+			t.Errorf("ParseSegmentRef(%q): expected error '%s', got %v", input, expected, err)
+		}
+	}
+}
+
+func serviceDocument(merge ...string) *ConfigDocument {
+	namespace := "default"
+	return &ConfigDocument{
+		Segments: map[string]*CertificateSpec{
+			"service": {
+				Params: map[string]*string{
+					"name":      nil,
+					"namespace": &namespace,
+				},
+				DNSNames: []string{
+					"${name}.${namespace}.svc",
+					"${name}.${namespace}.svc.cluster.local",
+				},
+			},
+			"defaults": {KeyUsage: []string{"digital_signature"}},
+		},
+		Merge: merge,
+	}
+}
+
+func TestResolveConfig_ParameterisedSegment(t *testing.T) {
+	result, err := ResolveConfig(serviceDocument("defaults", "service(name=api, namespace=prod)"))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{"api.prod.svc", "api.prod.svc.cluster.local"}
+	if len(result.DNSNames) != 2 || result.DNSNames[0] != expected[0] || result.DNSNames[1] != expected[1] {
+		t.Errorf("Expected DNSNames %v, got %v", expected, result.DNSNames)
+	}
+}
+
+func TestResolveConfig_ParameterDefaults(t *testing.T) {
+	result, err := ResolveConfig(serviceDocument("service(name=api)"))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.DNSNames[0] != "api.default.svc" {
+		t.Errorf("Expected default namespace, got '%s'", result.DNSNames[0])
+	}
+}
+
+func TestResolveConfig_SegmentUsedTwice(t *testing.T) {
+	result, err := ResolveConfig(serviceDocument("service(name=api)", "service(name=web)"))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.DNSNames) != 4 || result.DNSNames[2] != "web.default.svc" {
+		t.Errorf("Expected DNS names for both services, got %v", result.DNSNames)
+	}
+}
+
+func TestResolveConfig_ParametersShadowVars(t *testing.T) {
+	doc := serviceDocument("service(name=${app})")
+	doc.Vars = map[string]VarValue{"app": {"billing"}, "name": {"ignored"}}
+
+	result, err := ResolveConfig(doc)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.DNSNames[0] != "billing.default.svc" {
+		t.Errorf("Expected argument interpolated from vars, got '%s'", result.DNSNames[0])
+	}
+}
+
+func TestResolveConfig_ParameterValidation(t *testing.T) {
+	tests := map[string]string{
+		"service":                    "segment 'service' requires parameter 'name'",
+		"service(name=api, port=80)": "segment 'service' has no parameter 'port'",
+		"defaults(name=api)":         "segment 'defaults' does not declare parameters",
+		"missing(name=api)":          "segment 'missing' referenced in merge but not defined",
+	}
+
+	for ref, expected := range tests {
+		_, err := ResolveConfig(serviceDocument(ref))
+		if err == nil || err.Error() != expected {
+			t.Errorf("Merge %q: expected error '%s', got %v", ref, expected, err)
+		}
+	}
+}
+
+func TestResolveConfig_EmptySegment(t *testing.T) {
+	doc := serviceDocument("defaults", "empty")
+	doc.Segments["empty"] = nil
+
+	result, err := ResolveConfig(doc)
+
+	if err != nil {
+		t.Fatalf("Expected an empty segment to be skipped, got %v", err)
+	}
+	if len(result.KeyUsage) != 1 {
+		t.Errorf("Expected the other segments to merge, got %v", result.KeyUsage)
+	}
+
+	doc = serviceDocument("empty(name=api)")
+	doc.Segments["empty"] = nil
+	if _, err := ResolveConfig(doc); err == nil || err.Error() != "segment 'empty' does not declare parameters" {
+		t.Errorf("Expected arguments to an empty segment to fail, got %v", err)
+	}
+}
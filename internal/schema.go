@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"reflect"
+)
+
+// SchemaID is the $id of the generated JSON Schema
+const SchemaID = "https://raw.githubusercontent.com/rschoonheim/go-yaml-to-x509/main/yaml-to-x509.schema.json"
+
+// fieldDescriptions documents every YAML key in the schema, keyed by "<Type>.<key>"
+var fieldDescriptions = map[string]string{
+	"ConfigDocument.name":         "Name of the certificate defined by this document",
+	"ConfigDocument.config":       "Final overrides applied after all merged segments",
+	"ConfigDocument.merge":        "Segments to merge in order, optionally with arguments: name(param=value)",
+	"ConfigDocument.segments":     "Reusable configuration blocks, referenced by name from 'merge'",
+	"ConfigDocument.certificates": "Named certificates resolved against the shared segments",
+	"ConfigDocument.vars":         "Default values for ${name} references, a string or a list of strings",
+	"ConfigDocument.conflicts":    "How merge conflicts between segments are reported",
+	"ConfigDocument.include":      "Files whose segments and vars are added to this document, read from the include filesystem",
+	"ConfigDocument.tls":          "Named TLS server and client configurations built from certificates of the stream or PEM files",
+	"ConfigDocument.verify":       "Named chain verifications of certificates of the stream or PEM files",
+
+	"CertificateEntry.merge":  "Segments to merge in order, optionally with arguments: name(param=value)",
+	"CertificateEntry.config": "Final overrides applied after all merged segments",
+
+	"CertificateSpec.serial_number":           "Serial number in decimal, 0x hex or colon-separated hex, or a strategy: random, timestamp or sequential",
+	"CertificateSpec.subject":                 "Subject distinguished name",
+	"CertificateSpec.issuer":                  "Issuer distinguished name",
+	"CertificateSpec.not_before":              "Start of the validity period as an RFC3339 timestamp",
+	"CertificateSpec.not_after":               "End of the validity period as an RFC3339 timestamp",
+	"CertificateSpec.validity":                "Validity period relative to an anchor, instead of not_before and not_after",
+	"CertificateSpec.key_usage":               "Key usages",
+	"CertificateSpec.ext_key_usage":           "Extended key usages",
+	"CertificateSpec.dns_names":               "DNS subject alternative names",
+	"CertificateSpec.email_addresses":         "Email subject alternative names",
+	"CertificateSpec.ip_addresses":            "IP address subject alternative names",
+	"CertificateSpec.uris":                    "URI subject alternative names",
+	"CertificateSpec.is_ca":                   "Whether the certificate is a CA",
+	"CertificateSpec.max_path_len":            "Maximum number of intermediate CAs below this CA",
+	"CertificateSpec.max_path_len_zero":       "Whether a max_path_len of 0 is explicit",
+	"CertificateSpec.basic_constraints_valid": "Whether the basic constraints extension is included",
+	"CertificateSpec.signature_algorithm":     "Signature algorithm",
+	"CertificateSpec.public_key_algorithm":    "Public key algorithm",
+	"CertificateSpec.public_key":              "Existing key to certify: a PEM public key, certificate request or certificate, inline or as a file path",
+	"CertificateSpec.overrides":               "Fields this segment may override without a merge conflict, e.g. subject.country",
+	"CertificateSpec.params":                  "Parameters of a parameterised segment mapped to their default, null when required",
+	"CertificateSpec.signer":                  "Key that signs certificates issued from this profile, kept in a file or token",
+	"CertificateSpec.key_encryption":          "Passphrase protection of private keys generated for this profile",
+	"CertificateSpec.insecure_test_seed":      "TEST ONLY: derives keys, serial numbers and signatures from this seed for reproducible fixtures; the keys are not secret",
+
+	"SignerSpec.type":        "Signer provider: file, encrypted-pem, pkcs11 or a registered custom provider",
+	"SignerSpec.certificate": "PEM file of the issuing certificate, omitted when the signer's key is the subject key",
+	"SignerSpec.path":        "Private key file of the file and encrypted-pem providers",
+	"SignerSpec.passphrase":  "Passphrase of an encrypted-pem key",
+	"SignerSpec.module":      "Path of the PKCS#11 module library",
+	"SignerSpec.token":       "Label of the PKCS#11 token",
+	"SignerSpec.label":       "Label of the PKCS#11 private key",
+	"SignerSpec.pin":         "User PIN of the PKCS#11 token",
+	"SignerSpec.params":      "Settings of custom signer providers",
+
+	"KeyEncryptionSpec.kdf":        "Key derivation function of the PKCS#8 encryption, scrypt when unset",
+	"KeyEncryptionSpec.passphrase": "Passphrase that encrypts generated keys; without it the caller supplies one",
+
+	"SecretSpec.env":  "Environment variable holding the secret",
+	"SecretSpec.file": "File holding the secret, with a trailing newline removed",
+
+	"TLSSpec.role":          "Whether the configuration is for a server or a client",
+	"TLSSpec.certificate":   "Certificate of the stream to issue and present, with a generated key",
+	"TLSSpec.issuer":        "Certificate of the stream that signs 'certificate', which is self-signed when omitted",
+	"TLSSpec.cert_file":     "PEM file of the certificate to present, instead of 'certificate'",
+	"TLSSpec.key_file":      "PEM file of the private key of cert_file",
+	"TLSSpec.roots":         "Certificates of the stream or PEM files trusted to verify the peer",
+	"TLSSpec.client_auth":   "Client certificate authentication of a server, none when unset",
+	"TLSSpec.min_version":   "Minimum TLS version",
+	"TLSSpec.max_version":   "Maximum TLS version",
+	"TLSSpec.cipher_suites": "TLS 1.0-1.2 cipher suites by their Go name, e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+	"TLSSpec.alpn":          "Application protocols in order of preference, e.g. h2 and http/1.1",
+	"TLSSpec.server_name":   "Name a client verifies the server certificate for, instead of the dialed host",
+
+	"VerifySpec.certificate":   "Certificate of the stream to issue and verify",
+	"VerifySpec.issuer":        "Certificate of the stream that signs 'certificate', which is self-signed when omitted",
+	"VerifySpec.cert_file":     "PEM file of the certificate to verify, followed by its intermediates, instead of 'certificate'",
+	"VerifySpec.roots":         "Certificates of the stream or PEM files trusted as roots, the top issuer of 'certificate' when omitted",
+	"VerifySpec.intermediates": "Certificates of the stream or PEM files available as intermediates, in addition to the issuers of 'certificate'",
+	"VerifySpec.dns_name":      "DNS name or IP address the certificate must be valid for",
+	"VerifySpec.key_usage":     "Key usages the certificate must have",
+	"VerifySpec.ext_key_usage": "Extended key usages a chain must permit, any usage when omitted",
+	"VerifySpec.time":          "Time to verify at as an RFC3339 timestamp, instead of now",
+
+	"ValiditySpec.duration": "Length of the validity period, e.g. 90d, 1y or 1y30d",
+	"ValiditySpec.backdate": "Allowance subtracted from the start of the period for clock skew, e.g. 1h",
+	"ValiditySpec.anchor":   "Start of the period: now (the default) or an RFC3339 timestamp",
+}
+
+// fieldEnums restricts string fields, and the items of string lists, to known values
+var fieldEnums = map[string][]string{
+	"ConfigDocument.conflicts":             ConflictModes,
+	"KeyEncryptionSpec.kdf":                KeyDerivationFunctions,
+	"TLSSpec.role":                         TLSRoles,
+	"TLSSpec.client_auth":                  ClientAuthModes,
+	"TLSSpec.min_version":                  TLSVersions,
+	"TLSSpec.max_version":                  TLSVersions,
+	"VerifySpec.key_usage":                 KeyUsages,
+	"VerifySpec.ext_key_usage":             ExtKeyUsages,
+	"CertificateSpec.key_usage":            KeyUsages,
+	"CertificateSpec.ext_key_usage":        ExtKeyUsages,
+	"CertificateSpec.signature_algorithm":  SignatureAlgorithms,
+	"CertificateSpec.public_key_algorithm": PublicKeyAlgorithms,
+}
+
+// fieldDefs replaces the schema of a field with a reference to a shared definition
+var fieldDefs = map[string]string{
+	"CertificateSpec.subject": "DistinguishedName",
+	"CertificateSpec.issuer":  "DistinguishedName",
+}
+
+// Schema returns a JSON Schema (draft 2020-12) describing the YAML document format.
+// A document is either a ConfigDocument or, in the simple format, a CertificateSpec,
+// so the top level accepts the keys of both.
+func Schema() map[string]interface{} {
+	g := &schemaGenerator{defs: map[string]interface{}{
+		"DistinguishedName": distinguishedNameSchema(),
+		"Variable": map[string]interface{}{
+			"description": "A ${name} variable reference",
+			"type":        "string",
+			"pattern":     `^\$\{[^}]+\}$`,
+		},
+	}}
+
+	root := g.structSchema(reflect.TypeOf(ConfigDocument{}))
+	properties := root["properties"].(map[string]interface{})
+	specProperties := g.structSchema(reflect.TypeOf(CertificateSpec{}))["properties"].(map[string]interface{})
+	for key, value := range specProperties {
+		if _, exists := properties[key]; !exists {
+			properties[key] = value
+		}
+	}
+
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = SchemaID
+	root["title"] = "go-yaml-to-x509 certificate profile"
+	root["description"] = "An X.509 certificate profile in the simple format, or a document with segments, merge and config"
+	root["$defs"] = g.defs
+	return root
+}
+
+// schemaGenerator builds schemas for Go types, collecting struct definitions
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+// structSchema returns an object schema with a property for every YAML field of t
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := yamlName(field)
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		properties[name] = g.fieldSchema(t.Name()+"."+name, field.Type)
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// fieldSchema returns the schema of a struct field, applying descriptions, enums and shared definitions
+func (g *schemaGenerator) fieldSchema(key string, t reflect.Type) map[string]interface{} {
+	var schema map[string]interface{}
+	if def, ok := fieldDefs[key]; ok {
+		schema = map[string]interface{}{"$ref": "#/$defs/" + def}
+	} else {
+		schema = g.typeSchema(t)
+	}
+
+	if values, ok := fieldEnums[key]; ok {
+		if schema["type"] == "array" {
+			schema["items"] = enumSchema(values)
+		} else {
+			schema = enumSchema(values)
+		}
+	}
+
+	if description, ok := fieldDescriptions[key]; ok {
+		schema["description"] = description
+	}
+	return schema
+}
+
+// typeSchema returns the schema of a Go type
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(VarValue{}) {
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if t.Elem().Kind() == reflect.Struct {
+			return g.ref(t.Elem())
+		}
+		schema := g.typeSchema(t.Elem())
+		schema["type"] = []interface{}{schema["type"], "null"}
+		return schema
+	case reflect.Struct:
+		return g.ref(t)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// ref returns a reference to the definition of a struct type, generating it on first use
+func (g *schemaGenerator) ref(t reflect.Type) map[string]interface{} {
+	if _, exists := g.defs[t.Name()]; !exists {
+		g.defs[t.Name()] = nil // reserve the name so recursive types terminate
+		g.defs[t.Name()] = g.structSchema(t)
+	}
+	return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+}
+
+// enumSchema accepts one of the given values or a variable reference
+func enumSchema(values []string) map[string]interface{} {
+	enum := make([]interface{}, len(values))
+	for i, v := range values {
+		enum[i] = v
+	}
+
+	return map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string", "enum": enum},
+			map[string]interface{}{"$ref": "#/$defs/Variable"},
+		},
+	}
+}
+
+// distinguishedNameSchema describes a subject or issuer map
+func distinguishedNameSchema() map[string]interface{} {
+	properties := make(map[string]interface{}, len(DNFields))
+	for _, field := range DNFields {
+		properties[field] = map[string]interface{}{"type": "string"}
+	}
+
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"crypto/x509"
+	"testing"
+)
+
+func TestSchema_EnumsMatchParsers(t *testing.T) {
+	for _, usage := range KeyUsages {
+		if ParseKeyUsage([]string{usage}) == 0 {
+			t.Errorf("Key usage '%s' is not recognised by ParseKeyUsage", usage)
+		}
+	}
+	for _, usage := range ExtKeyUsages {
+		if len(ParseExtKeyUsage([]string{usage})) != 1 {
+			t.Errorf("Extended key usage '%s' is not recognised by ParseExtKeyUsage", usage)
+		}
+	}
+	for _, alg := range SignatureAlgorithms {
+		if ParseSignatureAlgorithm(alg) == x509.UnknownSignatureAlgorithm {
+			t.Errorf("Signature algorithm '%s' is not recognised by ParseSignatureAlgorithm", alg)
+		}
+	}
+	for _, alg := range PublicKeyAlgorithms {
+		if ParsePublicKeyAlgorithm(alg) == x509.UnknownPublicKeyAlgorithm {
+			t.Errorf("Public key algorithm '%s' is not recognised by ParsePublicKeyAlgorithm", alg)
+		}
+	}
+	for _, field := range DNFields {
+		if FormatPkixName(ParsePkixName(map[string]string{field: "x"}))[field] != "x" {
+			t.Errorf("DN field '%s' is not recognised by ParsePkixName", field)
+		}
+	}
+}
+
+func TestSchema_EnumsCoverAllX509Values(t *testing.T) {
+	var allKeyUsages x509.KeyUsage
+	for bit := x509.KeyUsageDigitalSignature; bit <= x509.KeyUsageDecipherOnly; bit <<= 1 {
+		allKeyUsages |= bit
+	}
+	if ParseKeyUsage(KeyUsages) != allKeyUsages {
+		t.Errorf("Expected KeyUsages to cover every key usage bit")
+	}
+	if len(ParseExtKeyUsage(ExtKeyUsages)) != int(x509.ExtKeyUsageMicrosoftKernelCodeSigning)+1 {
+		t.Errorf("Expected ExtKeyUsages to cover every extended key usage")
+	}
+}
+
+func TestSchema_EveryFieldDocumented(t *testing.T) {
+	schema := Schema()
+	defs := schema["$defs"].(map[string]interface{})
+
+	objects := map[string]interface{}{"top level": schema}
+	for _, name := range []string{"CertificateEntry", "CertificateSpec", "ValiditySpec"} {
+		objects[name] = defs[name]
+	}
+
+	for name, object := range objects {
+		properties := object.(map[string]interface{})["properties"].(map[string]interface{})
+		for key, property := range properties {
+			if _, ok := property.(map[string]interface{})["description"]; !ok {
+				t.Errorf("Expected a description for %s.%s", name, key)
+			}
+		}
+	}
+}
+
+func TestSchema_SimpleAndSegmentFormats(t *testing.T) {
+	properties := Schema()["properties"].(map[string]interface{})
+
+	for _, key := range []string{"segments", "merge", "config", "certificates", "vars", "subject", "key_usage", "validity"} {
+		if _, ok := properties[key]; !ok {
+			t.Errorf("Expected top-level property '%s'", key)
+		}
+	}
+	if _, ok := properties["overrides"]; !ok {
+		t.Error("Expected segment-only keys to be accepted in the simple format")
+	}
+}
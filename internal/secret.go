@@ -0,0 +1,17 @@
+package internal
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalYAML rejects secrets written inline, so that they do not end up in profiles
+func (s *SecretSpec) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return fmt.Errorf("line %d: secrets cannot be written inline, reference an 'env' variable or a 'file'", node.Line)
+	}
+
+	type plain SecretSpec
+	return node.Decode((*plain)(s))
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSecretSpec_UnmarshalYAML(t *testing.T) {
+	var spec KeyEncryptionSpec
+	err := yaml.Unmarshal([]byte(`
+kdf: pbkdf2
+passphrase:
+  env: KEY_PASSPHRASE
+`), &spec)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if spec.Passphrase == nil || spec.Passphrase.Env != "KEY_PASSPHRASE" {
+		t.Errorf("Expected env reference, got %+v", spec.Passphrase)
+	}
+
+	err = yaml.Unmarshal([]byte("passphrase: hunter2\n"), &spec)
+	if err == nil || !strings.Contains(err.Error(), "line 1: secrets cannot be written inline") {
+		t.Errorf("Expected inline secret to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Serial number strategy constants
+const (
+	SerialRandom     = "random"
+	SerialTimestamp  = "timestamp"
+	SerialSequential = "sequential"
+)
+
+// MaxSerialBits is the largest serial number size allowed by RFC 5280 (20 octets, positive)
+const MaxSerialBits = 159
+
+// ParseSerialNumber parses a literal serial number written in decimal, as hex with a
+// "0x" prefix, or as colon-separated hex bytes ("01:a2:ff")
+func ParseSerialNumber(s string) (*big.Int, error) {
+	serial := new(big.Int)
+
+	switch {
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+		if _, ok := serial.SetString(s[2:], 16); !ok {
+			return nil, fmt.Errorf("invalid serial_number '%s'", s)
+		}
+	case strings.Contains(s, ":"):
+		b, err := hex.DecodeString(strings.ReplaceAll(s, ":", ""))
+		if err != nil || len(b) == 0 {
+			return nil, fmt.Errorf("invalid serial_number '%s'", s)
+		}
+		serial.SetBytes(b)
+	default:
+		if _, ok := serial.SetString(s, 10); !ok {
+			return nil, fmt.Errorf("invalid serial_number '%s'", s)
+		}
+	}
+
+	if err := ValidateSerialNumber(serial); err != nil {
+		return nil, err
+	}
+	return serial, nil
+}
+
+// ValidateSerialNumber checks that a serial number is positive and fits in 20 octets
+func ValidateSerialNumber(serial *big.Int) error {
+	if serial.Sign() <= 0 {
+		return fmt.Errorf("serial_number must be positive")
+	}
+	if serial.BitLen() > MaxSerialBits {
+		return fmt.Errorf("serial_number exceeds 20 octets")
+	}
+	return nil
+}
+
+// RandomSerialNumber returns a positive, non-zero serial number of up to 159 bits read from r
+func RandomSerialNumber(r io.Reader) (*big.Int, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), MaxSerialBits)
+	for {
+		serial, err := rand.Int(r, limit)
+		if err != nil {
+			return nil, fmt.Errorf("generating serial_number: %w", err)
+		}
+		if serial.Sign() > 0 {
+			return serial, nil
+		}
+	}
+}
+
+// TimestampSerialNumber returns a serial number made of the nanosecond timestamp
+// followed by 32 random bits, so serials issued at the same instant still differ
+func TimestampSerialNumber(now time.Time, r io.Reader) (*big.Int, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := io.ReadFull(r, suffix); err != nil {
+		return nil, fmt.Errorf("generating serial_number: %w", err)
+	}
+
+	serial := new(big.Int).Lsh(big.NewInt(now.UnixNano()), 32)
+	serial.Or(serial, new(big.Int).SetBytes(suffix))
+
+	if err := ValidateSerialNumber(serial); err != nil {
+		return nil, err
+	}
+	return serial, nil
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestParseSerialNumber_Formats(t *testing.T) {
+	tests := map[string]int64{
+		"12345":    12345,
+		"0x3039":   12345,
+		"0X3039":   12345,
+		"30:39":    12345,
+		"00:30:39": 12345,
+	}
+
+	for input, expected := range tests {
+		result, err := ParseSerialNumber(input)
+		if err != nil {
+			t.Errorf("ParseSerialNumber(%q): unexpected error %v", input, err)
+			continue
+		}
+		if result.Int64() != expected {
+			t.Errorf("ParseSerialNumber(%q): expected %d, got %s", input, expected, result)
+		}
+	}
+}
+
+func TestParseSerialNumber_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"abc":   "invalid serial_number 'abc'",
+		"0xzz":  "invalid serial_number '0xzz'",
+		"30:3g": "invalid serial_number '30:3g'",
+		"0":     "serial_number must be positive",
+		"-5":    "serial_number must be positive",
+		"0x" + "ff" + "0000000000000000000000000000000000000000": "serial_number exceeds 20 octets",
+	}
+
+	for input, expected := range tests {
+		_, err := ParseSerialNumber(input)
+		if err == nil || err.Error() != expected {
+			t.Errorf("ParseSerialNumber(%q): expected error '%s', got %v", input, expected, err)
+		}
+	}
+}
+
+func TestRandomSerialNumber(t *testing.T) {
+	seen := make(map[string]bool)
+
+	for i := 0; i < 100; i++ {
+		serial, err := RandomSerialNumber(nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := ValidateSerialNumber(serial); err != nil {
+			t.Fatalf("Generated invalid serial %s: %v", serial, err)
+		}
+		if seen[serial.String()] {
+			t.Fatalf("Generated duplicate serial %s", serial)
+		}
+		seen[serial.String()] = true
+	}
+}
+
+func TestRandomSerialNumber_SkipsZero(t *testing.T) {
+	// 20 zero bytes produce 0, which must be skipped in favour of the next draw
+	source := append(make([]byte, 20), bytes.Repeat([]byte{0x01}, 20)...)
+
+	serial, err := RandomSerialNumber(bytes.NewReader(source))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if serial.Sign() <= 0 {
+		t.Errorf("Expected positive serial, got %s", serial)
+	}
+}
+
+func TestTimestampSerialNumber(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	serial, err := TimestampSerialNumber(now, bytes.NewReader([]byte{0, 0, 0, 7}))
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := new(big.Int).Lsh(big.NewInt(now.UnixNano()), 32)
+	expected.Add(expected, big.NewInt(7))
+	if serial.Cmp(expected) != 0 {
+		t.Errorf("Expected %s, got %s", expected, serial)
+	}
+}
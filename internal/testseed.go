@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"time"
+)
+
+// TestSeedEpoch is the clock of seeded builds that set no clock of their own, so
+// that relative validity periods are reproducible too
+var TestSeedEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SeededReader returns an endless stream of bytes derived from seed and label with
+// HMAC-SHA256 in counter mode. The stream is predictable by design and only suits
+// test fixtures.
+func SeededReader(seed []byte, label string) io.Reader {
+	return &seededReader{mac: hmac.New(sha256.New, seed), label: label}
+}
+
+type seededReader struct {
+	mac     hash.Hash
+	label   string
+	counter uint64
+	buf     []byte
+}
+
+func (r *seededReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.buf) == 0 {
+			r.mac.Reset()
+			r.mac.Write([]byte(r.label))
+			r.mac.Write(binary.BigEndian.AppendUint64(nil, r.counter))
+			r.buf = r.mac.Sum(nil)
+			r.counter++
+		}
+		copied := copy(p[n:], r.buf)
+		r.buf = r.buf[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
+// SeededKey derives a key pair for the public key algorithm from seed and label,
+// the same key for the same inputs: RSA 2048, ECDSA P-256 or Ed25519, with ECDSA
+// P-256 used when the algorithm is unknown. The keys are not secret and only suit
+// test fixtures.
+func SeededKey(alg x509.PublicKeyAlgorithm, seed []byte, label string) (crypto.Signer, error) {
+	r := SeededReader(seed, label)
+
+	switch alg {
+	case x509.RSA:
+		return seededRSAKey(r, 2048)
+	case x509.ECDSA, x509.UnknownPublicKeyAlgorithm:
+		return seededECDSAKey(r, elliptic.P256())
+	case x509.Ed25519:
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := io.ReadFull(r, seed); err != nil {
+			return nil, err
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	default:
+		return nil, fmt.Errorf("cannot generate keys for public key algorithm %s", alg)
+	}
+}
+
+// seededECDSAKey draws scalars until one is a valid private key for the curve
+func seededECDSAKey(r io.Reader, curve elliptic.Curve) (*ecdsa.PrivateKey, error) {
+	d := make([]byte, (curve.Params().BitSize+7)/8)
+	for {
+		if _, err := io.ReadFull(r, d); err != nil {
+			return nil, err
+		}
+		if key, err := ecdsa.ParseRawPrivateKey(curve, d); err == nil {
+			return key, nil
+		}
+	}
+}
+
+// seededRSAKey builds an RSA key with public exponent 65537 from two seeded primes
+func seededRSAKey(r io.Reader, bits int) (*rsa.PrivateKey, error) {
+	e := big.NewInt(65537)
+	one := big.NewInt(1)
+
+	for {
+		p, err := seededPrime(r, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := seededPrime(r, bits-bits/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue
+		}
+
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: new(big.Int).Mul(p, q), E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		key.Precompute()
+		if err := key.Validate(); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+}
+
+// seededPrime returns the first prime at or above a seeded odd number with its top
+// two bits set, so that the product of two such primes has exactly twice the bits
+func seededPrime(r io.Reader, bits int) (*big.Int, error) {
+	buf := make([]byte, (bits+7)/8)
+	two := big.NewInt(2)
+
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		p := new(big.Int).SetBytes(buf)
+		p.SetBit(p, bits-1, 1)
+		p.SetBit(p, bits-2, 1)
+		p.SetBit(p, 0, 1)
+		for i := len(buf)*8 - 1; i >= bits; i-- {
+			p.SetBit(p, i, 0)
+		}
+
+		for ; p.BitLen() == bits; p.Add(p, two) {
+			if p.ProbablyPrime(20) {
+				return p, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"io"
+	"testing"
+)
+
+func TestSeededReader(t *testing.T) {
+	read := func(seed, label string) []byte {
+		buf := make([]byte, 100)
+		if _, err := io.ReadFull(SeededReader([]byte(seed), label), buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf
+	}
+
+	if !bytes.Equal(read("seed", "a"), read("seed", "a")) {
+		t.Error("Expected the same stream for the same seed and label")
+	}
+	if bytes.Equal(read("seed", "a"), read("seed", "b")) || bytes.Equal(read("seed", "a"), read("other", "a")) {
+		t.Error("Expected different streams for different seeds or labels")
+	}
+}
+
+func TestSeededKey(t *testing.T) {
+	for _, alg := range []x509.PublicKeyAlgorithm{x509.RSA, x509.ECDSA, x509.Ed25519} {
+		key, err := SeededKey(alg, []byte("seed"), "ca")
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		again, err := SeededKey(alg, []byte("seed"), "ca")
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := SeededKey(alg, []byte("seed"), "leaf")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		equal := key.(interface{ Equal(crypto.PrivateKey) bool })
+		if !equal.Equal(again) {
+			t.Errorf("%s: expected the same key for the same seed and label", alg)
+		}
+		if equal.Equal(other) {
+			t.Errorf("%s: expected a different key for a different label", alg)
+		}
+	}
+
+	key, err := SeededKey(x509.RSA, []byte("seed"), "ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsaKey := key.(*rsa.PrivateKey); rsaKey.N.BitLen() != 2048 || rsaKey.Validate() != nil {
+		t.Errorf("Expected a valid RSA 2048 key, got %d bits", rsaKey.N.BitLen())
+	}
+	if _, ok := mustSeededKey(t, x509.UnknownPublicKeyAlgorithm).(*ecdsa.PrivateKey); !ok {
+		t.Error("Expected ECDSA for an unknown algorithm")
+	}
+	if _, ok := mustSeededKey(t, x509.Ed25519).(ed25519.PrivateKey); !ok {
+		t.Error("Expected an Ed25519 key")
+	}
+}
+
+func mustSeededKey(t *testing.T, alg x509.PublicKeyAlgorithm) any {
+	t.Helper()
+
+	key, err := SeededKey(alg, []byte("seed"), "label")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
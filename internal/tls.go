@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+// ParseTLSVersion converts a TLS version such as "1.2" to its crypto/tls constant
+func ParseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case TLSVersion10:
+		return tls.VersionTLS10, nil
+	case TLSVersion11:
+		return tls.VersionTLS11, nil
+	case TLSVersion12:
+		return tls.VersionTLS12, nil
+	case TLSVersion13:
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unknown TLS version '%s'", version)
+	}
+}
+
+// ParseClientAuth converts a client authentication mode to tls.ClientAuthType, with
+// an empty mode meaning "none"
+func ParseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "", ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthRequire:
+		return tls.RequireAnyClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequireAndVerify:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("unknown client_auth '%s'", mode)
+	}
+}
+
+// ParseCipherSuites converts cipher suite names, as listed by tls.CipherSuites, to
+// their IDs. Insecure suites are rejected.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := cipherSuiteID(name, tls.CipherSuites())
+		if !ok {
+			if _, insecure := cipherSuiteID(name, tls.InsecureCipherSuites()); insecure {
+				return nil, fmt.Errorf("cipher suite '%s' is insecure", name)
+			}
+			return nil, fmt.Errorf("unknown cipher suite '%s'", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func cipherSuiteID(name string, suites []*tls.CipherSuite) (uint16, bool) {
+	for _, suite := range suites {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	for _, version := range TLSVersions {
+		if _, err := ParseTLSVersion(version); err != nil {
+			t.Errorf("ParseTLSVersion(%q): %v", version, err)
+		}
+	}
+
+	v, err := ParseTLSVersion("1.3")
+	if err != nil || v != tls.VersionTLS13 {
+		t.Errorf("expected TLS 1.3, got %x, %v", v, err)
+	}
+	if _, err := ParseTLSVersion("1.4"); err == nil {
+		t.Error("expected an error for an unknown version")
+	}
+}
+
+func TestParseClientAuth(t *testing.T) {
+	tests := map[string]tls.ClientAuthType{
+		"":                   tls.NoClientCert,
+		"none":               tls.NoClientCert,
+		"request":            tls.RequestClientCert,
+		"require":            tls.RequireAnyClientCert,
+		"verify_if_given":    tls.VerifyClientCertIfGiven,
+		"require_and_verify": tls.RequireAndVerifyClientCert,
+	}
+	for mode, expected := range tests {
+		got, err := ParseClientAuth(mode)
+		if err != nil || got != expected {
+			t.Errorf("ParseClientAuth(%q) = %v, %v; expected %v", mode, got, err, expected)
+		}
+	}
+
+	if _, err := ParseClientAuth("always"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	ids, err := ParseCipherSuites([]string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 || ids[1] != tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256 {
+		t.Errorf("unexpected IDs %v", ids)
+	}
+
+	if ids, err := ParseCipherSuites(nil); err != nil || ids != nil {
+		t.Errorf("expected no suites, got %v, %v", ids, err)
+	}
+
+	_, err = ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"})
+	if err == nil || err.Error() != "cipher suite 'TLS_RSA_WITH_RC4_128_SHA' is insecure" {
+		t.Errorf("expected an insecure suite error, got %v", err)
+	}
+	if _, err := ParseCipherSuites([]string{"TLS_MADE_UP"}); err == nil {
+		t.Error("expected an error for an unknown suite")
+	}
+}
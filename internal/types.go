@@ -2,18 +2,33 @@ package internal
 
 // ConfigDocument represents a YAML document with optional config segments
 type ConfigDocument struct {
-	Config   *CertificateSpec            `yaml:"config,omitempty"`
-	Merge    []string                    `yaml:"merge,omitempty"`
-	Segments map[string]*CertificateSpec `yaml:"segments,omitempty"`
+	Name         string                       `yaml:"name,omitempty"`
+	Config       *CertificateSpec             `yaml:"config,omitempty"`
+	Merge        []string                     `yaml:"merge,omitempty"`
+	Segments     map[string]*CertificateSpec  `yaml:"segments,omitempty"`
+	Certificates map[string]*CertificateEntry `yaml:"certificates,omitempty"`
+	Vars         map[string]VarValue          `yaml:"vars,omitempty"`
+	Conflicts    string                       `yaml:"conflicts,omitempty"`
+	Include      []string                     `yaml:"include,omitempty"`
+	TLS          map[string]*TLSSpec          `yaml:"tls,omitempty"`
+	Verify       map[string]*VerifySpec       `yaml:"verify,omitempty"`
 }
 
-// CertificateSpec is an intermediate struct for YAML unmarshalling
+// CertificateEntry is a named certificate in a document's 'certificates' map,
+// resolved against the document's top-level segments
+type CertificateEntry struct {
+	Merge  []string         `yaml:"merge,omitempty"`
+	Config *CertificateSpec `yaml:"config,omitempty"`
+}
+
+// CertificateSpec describes a certificate with the fields of the YAML format
 type CertificateSpec struct {
 	SerialNumber          string            `yaml:"serial_number,omitempty"`
 	Subject               map[string]string `yaml:"subject,omitempty"`
 	Issuer                map[string]string `yaml:"issuer,omitempty"`
 	NotBefore             string            `yaml:"not_before,omitempty"`
 	NotAfter              string            `yaml:"not_after,omitempty"`
+	Validity              *ValiditySpec     `yaml:"validity,omitempty"`
 	KeyUsage              []string          `yaml:"key_usage,omitempty"`
 	ExtKeyUsage           []string          `yaml:"ext_key_usage,omitempty"`
 	DNSNames              []string          `yaml:"dns_names,omitempty"`
@@ -26,4 +41,111 @@ type CertificateSpec struct {
 	BasicConstraintsValid bool              `yaml:"basic_constraints_valid,omitempty"`
 	SignatureAlgorithm    string            `yaml:"signature_algorithm,omitempty"`
 	PublicKeyAlgorithm    string            `yaml:"public_key_algorithm,omitempty"`
+
+	// PublicKey is an existing key to certify instead of generating one: a PEM public
+	// key, certificate request or certificate, inline or as a file path
+	PublicKey string `yaml:"public_key,omitempty"`
+
+	// Overrides lists the fields this segment is allowed to override when
+	// conflict detection is enabled, e.g. "signature_algorithm", "subject.country"
+	// or "subject" for every subject key
+	Overrides []string `yaml:"overrides,omitempty"`
+
+	// Params declares the parameters of a parameterised segment, mapped to their
+	// default value; a parameter without a default is required
+	Params map[string]*string `yaml:"params,omitempty"`
+
+	// Signer selects the key that signs certificates issued from this spec
+	Signer *SignerSpec `yaml:"signer,omitempty"`
+
+	// KeyEncryption protects private keys generated for this spec with a passphrase
+	KeyEncryption *KeyEncryptionSpec `yaml:"key_encryption,omitempty"`
+
+	// InsecureTestSeed derives keys, serial numbers and signatures from a seed so that
+	// test fixtures are reproducible. Keys issued this way are not secret.
+	InsecureTestSeed string `yaml:"insecure_test_seed,omitempty"`
+}
+
+// SignerSpec refers to a signing key held by a signer provider, such as a key
+// file or a PKCS#11 token, and optionally to the issuing certificate
+type SignerSpec struct {
+	Type        string `yaml:"type"`
+	Certificate string `yaml:"certificate,omitempty"`
+
+	// Path is the key file of the "file" and "encrypted-pem" providers
+	Path       string      `yaml:"path,omitempty"`
+	Passphrase *SecretSpec `yaml:"passphrase,omitempty"`
+
+	// Module, Token and Label select a key of the "pkcs11" provider
+	Module string      `yaml:"module,omitempty"`
+	Token  string      `yaml:"token,omitempty"`
+	Label  string      `yaml:"label,omitempty"`
+	PIN    *SecretSpec `yaml:"pin,omitempty"`
+
+	// Params holds the settings of custom providers
+	Params map[string]string `yaml:"params,omitempty"`
+}
+
+// KeyEncryptionSpec describes how generated private keys are encrypted as PKCS#8
+type KeyEncryptionSpec struct {
+	KDF        string      `yaml:"kdf,omitempty"`
+	Passphrase *SecretSpec `yaml:"passphrase,omitempty"`
+}
+
+// SecretSpec refers to a secret, such as a passphrase or PIN, without containing it
+type SecretSpec struct {
+	Env  string `yaml:"env,omitempty"`
+	File string `yaml:"file,omitempty"`
+}
+
+// TLSSpec describes a TLS server or client configuration, with its certificate and
+// trusted roots issued from certificates of the same stream or read from PEM files
+type TLSSpec struct {
+	Role string `yaml:"role"`
+
+	// Certificate names a certificate of the stream to issue, signed by Issuer when
+	// set; CertFile and KeyFile load an existing certificate instead
+	Certificate string `yaml:"certificate,omitempty"`
+	Issuer      string `yaml:"issuer,omitempty"`
+	CertFile    string `yaml:"cert_file,omitempty"`
+	KeyFile     string `yaml:"key_file,omitempty"`
+
+	// Roots are certificates of the stream or PEM files that clients verify servers
+	// with, and that servers verify client certificates with
+	Roots []string `yaml:"roots,omitempty"`
+
+	ClientAuth   string   `yaml:"client_auth,omitempty"`
+	MinVersion   string   `yaml:"min_version,omitempty"`
+	MaxVersion   string   `yaml:"max_version,omitempty"`
+	CipherSuites []string `yaml:"cipher_suites,omitempty"`
+	ALPN         []string `yaml:"alpn,omitempty"`
+	ServerName   string   `yaml:"server_name,omitempty"`
+}
+
+// VerifySpec describes a chain verification of a certificate of the stream or a PEM
+// file against roots and intermediates
+type VerifySpec struct {
+	// Certificate names a certificate of the stream, signed by Issuer when set;
+	// CertFile reads a certificate and the intermediates following it instead
+	Certificate string `yaml:"certificate,omitempty"`
+	Issuer      string `yaml:"issuer,omitempty"`
+	CertFile    string `yaml:"cert_file,omitempty"`
+
+	// Roots and Intermediates are certificates of the stream or PEM files. For a
+	// certificate of the stream they default to its issuers.
+	Roots         []string `yaml:"roots,omitempty"`
+	Intermediates []string `yaml:"intermediates,omitempty"`
+
+	DNSName     string   `yaml:"dns_name,omitempty"`
+	KeyUsage    []string `yaml:"key_usage,omitempty"`
+	ExtKeyUsage []string `yaml:"ext_key_usage,omitempty"`
+	Time        string   `yaml:"time,omitempty"`
+}
+
+// ValiditySpec describes a validity period relative to an anchor time instead of
+// absolute not_before/not_after dates
+type ValiditySpec struct {
+	Duration string `yaml:"duration,omitempty"`
+	Backdate string `yaml:"backdate,omitempty"`
+	Anchor   string `yaml:"anchor,omitempty"`
 }
@@ -0,0 +1,15 @@
+package internal
+
+import "fmt"
+
+// Unsupported is a field or setting that a conversion to or from another format
+// cannot express, together with the reason it was left out
+type Unsupported struct {
+	Field  string
+	Reason string
+}
+
+// String returns the field and reason as "field: reason"
+func (u Unsupported) String() string {
+	return fmt.Sprintf("%s: %s", u.Field, u.Reason)
+}
@@ -0,0 +1,10 @@
+package internal
+
+import "testing"
+
+func TestUnsupported_String(t *testing.T) {
+	u := Unsupported{Field: "signer", Reason: "keys are generated by the issuer"}
+	if u.String() != "signer: keys are generated by the issuer" {
+		t.Errorf("Unexpected string: %s", u.String())
+	}
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validity anchor constants
+const (
+	ValidityAnchorNow = "now"
+)
+
+// durationUnits maps the units accepted by ParseDuration to their length
+var durationUnits = map[string]time.Duration{
+	"y": 365 * 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"d": 24 * time.Hour,
+	"h": time.Hour,
+	"m": time.Minute,
+	"s": time.Second,
+}
+
+// ParseDuration parses durations such as "90d", "1y", "8760h" or "1y30d".
+// A year is 365 days and a week is 7 days. Durations must be positive and fit a
+// time.Duration, about 292 years.
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration ''")
+	}
+
+	var total time.Duration
+	rest := s
+	for rest != "" {
+		i := 0
+		for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+			i++
+		}
+		j := i
+		for j < len(rest) && (rest[j] < '0' || rest[j] > '9') {
+			j++
+		}
+
+		unit, ok := durationUnits[rest[i:j]]
+		if i == 0 || !ok {
+			return 0, fmt.Errorf("invalid duration '%s'", s)
+		}
+		n, err := strconv.ParseInt(rest[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s'", s)
+		}
+
+		if n > int64((math.MaxInt64-total)/unit) {
+			return 0, fmt.Errorf("duration '%s' is too long", s)
+		}
+		total += time.Duration(n) * unit
+		rest = rest[j:]
+	}
+	if total == 0 {
+		return 0, fmt.Errorf("invalid duration '%s', expected a positive duration", s)
+	}
+
+	return total, nil
+}
+
+// Period computes the validity period relative to the anchor, which is either
+// "now" (the default, taken from the given clock value) or an RFC3339 timestamp.
+// NotBefore is moved back by the backdate allowance to tolerate clock skew.
+func (v *ValiditySpec) Period(now time.Time) (notBefore, notAfter time.Time, err error) {
+	if v.Duration == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("validity.duration is required")
+	}
+
+	duration, err := ParseDuration(v.Duration)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("validity.duration: %w", err)
+	}
+
+	var backdate time.Duration
+	if v.Backdate != "" {
+		if backdate, err = ParseDuration(v.Backdate); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("validity.backdate: %w", err)
+		}
+	}
+
+	anchor := now
+	if v.Anchor != "" && !strings.EqualFold(v.Anchor, ValidityAnchorNow) {
+		if anchor, err = time.Parse(time.RFC3339, v.Anchor); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid validity.anchor '%s', expected 'now' or an RFC3339 timestamp", v.Anchor)
+		}
+	}
+
+	return anchor.Add(-backdate), anchor.Add(duration), nil
+}
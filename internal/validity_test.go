@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90d":   90 * 24 * time.Hour,
+		"1y":    365 * 24 * time.Hour,
+		"8760h": 8760 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
+		"1y30d": 395 * 24 * time.Hour,
+		"1h30m": 90 * time.Minute,
+		"45s":   45 * time.Second,
+	}
+
+	for input, expected := range tests {
+		result, err := ParseDuration(input)
+		if err != nil {
+			t.Errorf("ParseDuration(%q): unexpected error %v", input, err)
+			continue
+		}
+		if result != expected {
+			t.Errorf("ParseDuration(%q): expected %v, got %v", input, expected, result)
+		}
+	}
+}
+
+func TestParseDuration_Invalid(t *testing.T) {
+	for _, input := range []string{"", "90", "d", "90days", "1.5d", "-1d", "1y x"} {
+		if _, err := ParseDuration(input); err == nil {
+			t.Errorf("ParseDuration(%q): expected error", input)
+		}
+	}
+}
+
+func TestParseDuration_ZeroAndOverflow(t *testing.T) {
+	tests := map[string]string{
+		"0d":                   "invalid duration '0d', expected a positive duration",
+		"0y0s":                 "invalid duration '0y0s', expected a positive duration",
+		"300y":                 "duration '300y' is too long",
+		"200y200y":             "duration '200y200y' is too long",
+		"9223372036854775807s": "duration '9223372036854775807s' is too long",
+	}
+
+	for input, expected := range tests {
+		if _, err := ParseDuration(input); err == nil || err.Error() != expected {
+			t.Errorf("ParseDuration(%q): expected error '%s', got %v", input, expected, err)
+		}
+	}
+	if d, err := ParseDuration("292y"); err != nil || d != 292*365*24*time.Hour {
+		t.Errorf("ParseDuration(\"292y\"): expected 292 years, got %v, %v", d, err)
+	}
+}
+
+func TestValiditySpec_PeriodFromNow(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	validity := &ValiditySpec{Duration: "90d", Backdate: "5m"}
+
+	notBefore, notAfter, err := validity.Period(now)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !notBefore.Equal(now.Add(-5 * time.Minute)) {
+		t.Errorf("Expected NotBefore backdated by 5m, got %v", notBefore)
+	}
+	if !notAfter.Equal(now.Add(90 * 24 * time.Hour)) {
+		t.Errorf("Expected NotAfter 90 days after now, got %v", notAfter)
+	}
+}
+
+func TestValiditySpec_PeriodFromAnchor(t *testing.T) {
+	validity := &ValiditySpec{Duration: "1y", Anchor: "2025-01-01T00:00:00Z"}
+
+	notBefore, notAfter, err := validity.Period(time.Now())
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if notBefore.Format(time.RFC3339) != "2025-01-01T00:00:00Z" {
+		t.Errorf("Expected NotBefore at anchor, got %v", notBefore)
+	}
+	if notAfter.Format(time.RFC3339) != "2026-01-01T00:00:00Z" {
+		t.Errorf("Expected NotAfter one year after anchor, got %v", notAfter)
+	}
+}
+
+func TestValiditySpec_PeriodErrors(t *testing.T) {
+	tests := map[string]*ValiditySpec{
+		"validity.duration is required":                                              {Backdate: "1h"},
+		"validity.duration: invalid duration '90 days'":                              {Duration: "90 days"},
+		"validity.backdate: invalid duration 'soon'":                                 {Duration: "90d", Backdate: "soon"},
+		"invalid validity.anchor 'tomorrow', expected 'now' or an RFC3339 timestamp": {Duration: "90d", Anchor: "tomorrow"},
+	}
+
+	for expected, validity := range tests {
+		_, _, err := validity.Period(time.Now())
+		if err == nil || err.Error() != expected {
+			t.Errorf("Expected error '%s', got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VarValue is the value of a variable, written in YAML as a single string or a list of strings
+type VarValue []string
+
+// UnmarshalYAML accepts both a scalar and a sequence of scalars
+func (v *VarValue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*v = VarValue{node.Value}
+		return nil
+	}
+
+	var values []string
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+	*v = values
+	return nil
+}
+
+// VarScope resolves variable names for interpolation. Caller-supplied values take
+// precedence over environment variables (when enabled), which take precedence over
+// the document's 'vars' defaults.
+type VarScope struct {
+	Values    map[string][]string
+	UseEnv    bool
+	EnvPrefix string
+	Defaults  map[string]VarValue
+}
+
+// Lookup returns the values of the named variable
+func (s *VarScope) Lookup(name string) ([]string, bool) {
+	if values, ok := s.Values[name]; ok {
+		return values, true
+	}
+	if s.UseEnv {
+		if value, ok := os.LookupEnv(s.EnvPrefix + name); ok {
+			return []string{value}, true
+		}
+	}
+	if values, ok := s.Defaults[name]; ok {
+		return values, true
+	}
+	return nil, false
+}
+
+// With returns a scope in which the given values shadow every other source
+func (s *VarScope) With(values map[string]string) *VarScope {
+	if len(values) == 0 {
+		return s
+	}
+
+	scoped := *s
+	scoped.Values = make(map[string][]string, len(s.Values)+len(values))
+	for name, value := range s.Values {
+		scoped.Values[name] = value
+	}
+	for name, value := range values {
+		scoped.Values[name] = []string{value}
+	}
+	return &scoped
+}
+
+// Interpolate replaces every ${name} reference in s with the variable's value.
+// Variables with several values cannot be used inside a single value. A literal
+// "${" is written as "$${".
+func (s *VarScope) Interpolate(str string) (string, error) {
+	if !strings.Contains(str, "${") {
+		return str, nil
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.Index(str, "${")
+		if start < 0 {
+			b.WriteString(str)
+			return b.String(), nil
+		}
+
+		// "$${" escapes a literal "${"
+		if start > 0 && str[start-1] == '$' {
+			b.WriteString(str[:start-1])
+			b.WriteString("${")
+			str = str[start+2:]
+			continue
+		}
+
+		end := strings.IndexByte(str[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated variable reference in '%s'", str)
+		}
+		name := str[start+2 : start+end]
+
+		values, ok := s.Lookup(name)
+		if !ok {
+			return "", fmt.Errorf("undefined variable '%s'", name)
+		}
+		if len(values) != 1 {
+			return "", fmt.Errorf("variable '%s' has %d values and cannot be used in a single value", name, len(values))
+		}
+
+		b.WriteString(str[:start])
+		b.WriteString(values[0])
+		str = str[start+end+1:]
+	}
+}
+
+// InterpolateList interpolates every item of a list. An item consisting only of a
+// single ${name} reference expands to all of the variable's values.
+func (s *VarScope) InterpolateList(items []string) ([]string, error) {
+	if items == nil {
+		return nil, nil
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if name, ok := wholeReference(item); ok {
+			values, found := s.Lookup(name)
+			if !found {
+				return nil, fmt.Errorf("undefined variable '%s'", name)
+			}
+			result = append(result, values...)
+			continue
+		}
+
+		value, err := s.Interpolate(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+
+	return result, nil
+}
+
+// wholeReference reports whether s consists of exactly one ${name} reference
+func wholeReference(s string) (string, bool) {
+	if !strings.HasPrefix(s, "${") || !strings.HasSuffix(s, "}") {
+		return "", false
+	}
+	name := s[2 : len(s)-1]
+	if name == "" || strings.ContainsAny(name, "${}") {
+		return "", false
+	}
+	return name, true
+}
+
+// InterpolateSpec returns a copy of spec with variables interpolated in every string,
+// string list and string map field, including nested structs
+func (s *VarScope) InterpolateSpec(spec *CertificateSpec) (*CertificateSpec, error) {
+	if spec == nil {
+		return nil, nil
+	}
+
+	result := &CertificateSpec{}
+	if err := s.interpolateValue(reflect.ValueOf(result).Elem(), reflect.ValueOf(spec).Elem(), ""); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// interpolateValue copies src into dst, interpolating strings on the way.
+// path is the YAML field path used in error messages.
+func (s *VarScope) interpolateValue(dst, src reflect.Value, path string) error {
+	switch src.Kind() {
+	case reflect.String:
+		value, err := s.Interpolate(src.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		dst.SetString(value)
+
+	case reflect.Slice:
+		if src.Type().Elem().Kind() != reflect.String {
+			dst.Set(src)
+			return nil
+		}
+		values, err := s.InterpolateList(src.Interface().([]string))
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		dst.Set(reflect.ValueOf(values).Convert(dst.Type()))
+
+	case reflect.Map:
+		if src.IsNil() || src.Type().Elem().Kind() != reflect.String {
+			dst.Set(src)
+			return nil
+		}
+		result := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			value, err := s.Interpolate(iter.Value().String())
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", path, iter.Key().String(), err)
+			}
+			result.SetMapIndex(iter.Key(), reflect.ValueOf(value).Convert(src.Type().Elem()))
+		}
+		dst.Set(result)
+
+	case reflect.Ptr:
+		if src.IsNil() || src.Elem().Kind() != reflect.Struct {
+			dst.Set(src)
+			return nil
+		}
+		dst.Set(reflect.New(src.Elem().Type()))
+		return s.interpolateValue(dst.Elem(), src.Elem(), path)
+
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			field := src.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if err := s.interpolateValue(dst.Field(i), src.Field(i), joinPath(path, yamlName(field))); err != nil {
+				return err
+			}
+		}
+
+	default:
+		dst.Set(src)
+	}
+
+	return nil
+}
+
+// yamlName returns the YAML key of a struct field
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,219 @@
+package internal
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestVarValue_UnmarshalYAML(t *testing.T) {
+	var vars map[string]VarValue
+	err := yaml.Unmarshal([]byte(`
+host: api
+names:
+  - a.example.com
+  - b.example.com
+`), &vars)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(vars["host"]) != 1 || vars["host"][0] != "api" {
+		t.Errorf("Expected scalar to become single value, got %v", vars["host"])
+	}
+	if len(vars["names"]) != 2 {
+		t.Errorf("Expected 2 values for list, got %v", vars["names"])
+	}
+}
+
+func TestVarScope_LookupPrecedence(t *testing.T) {
+	t.Setenv("TEST_VARS_env", "from-env")
+	t.Setenv("TEST_VARS_caller", "from-env")
+
+	scope := &VarScope{
+		Values:    map[string][]string{"caller": {"from-caller"}},
+		UseEnv:    true,
+		EnvPrefix: "TEST_VARS_",
+		Defaults: map[string]VarValue{
+			"caller":  {"from-defaults"},
+			"env":     {"from-defaults"},
+			"default": {"from-defaults"},
+		},
+	}
+
+	for name, expected := range map[string]string{
+		"caller":  "from-caller",
+		"env":     "from-env",
+		"default": "from-defaults",
+	} {
+		values, ok := scope.Lookup(name)
+		if !ok || values[0] != expected {
+			t.Errorf("Expected %s to resolve to '%s', got %v", name, expected, values)
+		}
+	}
+}
+
+func TestVarScope_EnvIsOptIn(t *testing.T) {
+	t.Setenv("TEST_VARS_host", "from-env")
+
+	scope := &VarScope{EnvPrefix: "TEST_VARS_"}
+
+	if _, ok := scope.Lookup("host"); ok {
+		t.Error("Expected environment to be ignored unless UseEnv is set")
+	}
+}
+
+func TestVarScope_Interpolate(t *testing.T) {
+	scope := &VarScope{Defaults: map[string]VarValue{
+		"host": {"api"},
+		"env":  {"prod"},
+	}}
+
+	tests := map[string]string{
+		"plain":                    "plain",
+		"${host}":                  "api",
+		"${host}.${env}.corp":      "api.prod.corp",
+		"literal $${host} kept":    "literal ${host} kept",
+		"https://${host}/callback": "https://api/callback",
+	}
+
+	for input, expected := range tests {
+		result, err := scope.Interpolate(input)
+		if err != nil {
+			t.Errorf("Interpolate(%q): unexpected error %v", input, err)
+			continue
+		}
+		if result != expected {
+			t.Errorf("Interpolate(%q): expected '%s', got '%s'", input, expected, result)
+		}
+	}
+}
+
+func TestVarScope_InterpolateErrors(t *testing.T) {
+	scope := &VarScope{Defaults: map[string]VarValue{
+		"names": {"a", "b"},
+	}}
+
+	tests := map[string]string{
+		"${missing}":       "undefined variable 'missing'",
+		"${unterminated":   "unterminated variable reference in '${unterminated'",
+		"www.${names}.com": "variable 'names' has 2 values and cannot be used in a single value",
+	}
+
+	for input, expected := range tests {
+		_, err := scope.Interpolate(input)
+		if err == nil || err.Error() != expected {
+			t.Errorf("Interpolate(%q): expected error '%s', got %v", input, expected, err)
+		}
+	}
+}
+
+func TestVarScope_InterpolateListExpansion(t *testing.T) {
+	scope := &VarScope{Defaults: map[string]VarValue{
+		"host":    {"api"},
+		"aliases": {"api.example.com", "api.internal"},
+	}}
+
+	result, err := scope.InterpolateList([]string{"${host}.example.com", "${aliases}"})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{"api.example.com", "api.example.com", "api.internal"}
+	if len(result) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, result)
+			break
+		}
+	}
+}
+
+func TestVarScope_InterpolateSpec(t *testing.T) {
+	scope := &VarScope{Defaults: map[string]VarValue{
+		"host": {"api"},
+		"env":  {"prod"},
+	}}
+	spec := &CertificateSpec{
+		Subject:   map[string]string{"common_name": "${host}.${env}.example.com"},
+		DNSNames:  []string{"${host}.example.com"},
+		NotBefore: "2025-01-01T00:00:00Z",
+		IsCA:      true,
+	}
+
+	result, err := scope.InterpolateSpec(spec)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Subject["common_name"] != "api.prod.example.com" {
+		t.Errorf("Expected interpolated common_name, got '%s'", result.Subject["common_name"])
+	}
+	if result.DNSNames[0] != "api.example.com" {
+		t.Errorf("Expected interpolated DNS name, got '%s'", result.DNSNames[0])
+	}
+	if result.NotBefore != spec.NotBefore || !result.IsCA {
+		t.Error("Expected other fields to be copied unchanged")
+	}
+	if spec.Subject["common_name"] != "${host}.${env}.example.com" {
+		t.Error("Expected original spec to be left unchanged")
+	}
+}
+
+func TestVarScope_InterpolateSpecErrorPath(t *testing.T) {
+	scope := &VarScope{}
+	spec := &CertificateSpec{Subject: map[string]string{"common_name": "${host}"}}
+
+	_, err := scope.InterpolateSpec(spec)
+
+	expected := "subject.common_name: undefined variable 'host'"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error '%s', got %v", expected, err)
+	}
+}
+
+func TestResolveConfig_Variables(t *testing.T) {
+	doc := &ConfigDocument{
+		Vars: map[string]VarValue{"host": {"www"}},
+		Segments: map[string]*CertificateSpec{
+			"web-server": {DNSNames: []string{"${host}.example.com"}},
+		},
+		Merge:  []string{"web-server"},
+		Config: &CertificateSpec{Subject: map[string]string{"common_name": "${host}.example.com"}},
+	}
+
+	result, err := ResolveConfigWithOptions(doc, ResolveOptions{
+		Vars: map[string][]string{"host": {"api"}},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Subject["common_name"] != "api.example.com" {
+		t.Errorf("Expected caller value to win, got '%s'", result.Subject["common_name"])
+	}
+	if result.DNSNames[0] != "api.example.com" {
+		t.Errorf("Expected segment to be interpolated, got '%s'", result.DNSNames[0])
+	}
+	if doc.Segments["web-server"].DNSNames[0] != "${host}.example.com" {
+		t.Error("Expected segment definition to be left unchanged")
+	}
+}
+
+func TestResolveConfig_UndefinedVariable(t *testing.T) {
+	doc := &ConfigDocument{
+		Segments: map[string]*CertificateSpec{
+			"web-server": {DNSNames: []string{"${host}.example.com"}},
+		},
+		Merge: []string{"web-server"},
+	}
+
+	_, err := ResolveConfig(doc)
+
+	expected := "segment 'web-server': dns_names: undefined variable 'host'"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error '%s', got %v", expected, err)
+	}
+}
@@ -0,0 +1,210 @@
+package go_yaml_to_x509
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+	"github.com/rschoonheim/go-yaml-to-x509/store"
+)
+
+// IssueRequest describes a certificate to issue from a template
+type IssueRequest struct {
+	// Template is the certificate to issue, typically built by X509FromYaml
+	Template *x509.Certificate
+
+	// PublicKey is the key to certify. When nil, the template's public key is used,
+	// as loaded from 'public_key', or else a key pair is generated for the template's
+	// public key algorithm (ECDSA P-256 when unset).
+	PublicKey crypto.PublicKey
+
+	// Parent and Signer are the issuing CA certificate and its key. Without a Parent
+	// the certificate is self-signed, by Signer when set or by the generated key.
+	Parent *x509.Certificate
+	Signer crypto.Signer
+
+	// Profile is the profile name recorded in the issuance store
+	Profile string
+
+	// InsecureTestSeed is the spec's 'insecure_test_seed', used when Options sets none
+	InsecureTestSeed []byte
+}
+
+// IssuedCertificate is the result of Issue
+type IssuedCertificate struct {
+	Certificate *x509.Certificate
+
+	// PrivateKey is the generated key, or nil when the request supplied a PublicKey
+	PrivateKey crypto.Signer
+}
+
+// Issue signs a certificate from req.Template. Templates without a serial number get
+// a random one. When opts.Store is set the serial number must not have been issued
+// before, and the certificate is recorded in the store. When opts.Policy is set the
+// certificate and its public key are checked against it before signing.
+func Issue(req IssueRequest, opts Options) (*IssuedCertificate, error) {
+	return IssueContext(context.Background(), req, opts)
+}
+
+// IssueContext issues like Issue, returning ctx.Err() instead of generating a key,
+// signing or recording the certificate once ctx is done
+func IssueContext(ctx context.Context, req IssueRequest, opts Options) (*IssuedCertificate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if req.Template == nil {
+		return nil, errors.New("issue request has no template")
+	}
+	if req.Parent != nil && req.Signer == nil {
+		return nil, errors.New("issuing with a parent certificate requires a signer")
+	}
+
+	// Seeded issuance derives the serial number and key from the seed and signs
+	// deterministically, so that it can be repeated byte for byte
+	seed := opts.InsecureTestSeed
+	if seed == nil {
+		seed = req.InsecureTestSeed
+	}
+	signRand := randReader(opts.Rand)
+
+	template := *req.Template
+	if seed != nil {
+		if isPSS(template.SignatureAlgorithm) {
+			return nil, errors.New("seeded issuance cannot reproduce RSA-PSS signatures, choose another signature_algorithm")
+		}
+		signRand = nil
+	}
+	if template.SerialNumber == nil {
+		r := opts.Rand
+		if seed != nil {
+			r = internal.SeededReader(seed, "serial:"+template.Subject.String())
+		}
+		serial, err := internal.RandomSerialNumber(r)
+		if err != nil {
+			return nil, err
+		}
+		template.SerialNumber = serial
+	}
+
+	issued := &IssuedCertificate{}
+	publicKey := req.PublicKey
+	if publicKey == nil {
+		publicKey = template.PublicKey
+	}
+	signer := req.Signer
+
+	switch {
+	case publicKey == nil:
+		var key crypto.Signer
+		var err error
+		if seed != nil {
+			key, err = InsecureTestKey(template.PublicKeyAlgorithm, seed, template.SerialNumber.String())
+		} else {
+			key, err = GenerateKey(template.PublicKeyAlgorithm, opts.Rand)
+		}
+		if err != nil {
+			return nil, err
+		}
+		issued.PrivateKey = key
+		publicKey = key.Public()
+		if req.Parent == nil && signer == nil {
+			signer = key
+		}
+	case req.Parent == nil && signer == nil:
+		return nil, errors.New("self-signing a supplied public key requires a signer")
+	}
+
+	// Check the policy with the public key, so that key_size rules apply
+	checked := template
+	checked.PublicKey = publicKey
+	if err := opts.checkPolicy(req.Profile, &checked, seed); err != nil {
+		return nil, err
+	}
+
+	parent := req.Parent
+	if parent == nil {
+		parent = &template
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	der, err := x509.CreateCertificate(signRand, &template, parent, publicKey, signer)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	issued.Certificate = cert
+
+	if opts.Store != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Add checks and records the serial number atomically, so that concurrent
+		// issuances of the same serial number cannot both succeed
+		err := opts.Store.Add(store.NewRecord(cert, req.Profile, opts.now()))
+		if errors.Is(err, store.ErrDuplicateSerial) {
+			return nil, fmt.Errorf("serial number %s: %w", store.SerialString(cert.SerialNumber), err)
+		} else if err != nil {
+			return nil, fmt.Errorf("recording certificate: %w", err)
+		}
+	}
+
+	return issued, nil
+}
+
+// GenerateKey creates a key pair for the public key algorithm: RSA 2048, ECDSA P-256
+// or Ed25519, with ECDSA P-256 used when the algorithm is unknown
+func GenerateKey(alg x509.PublicKeyAlgorithm, r io.Reader) (crypto.Signer, error) {
+	r = randReader(r)
+
+	switch alg {
+	case x509.RSA:
+		return rsa.GenerateKey(r, 2048)
+	case x509.ECDSA, x509.UnknownPublicKeyAlgorithm:
+		return ecdsa.GenerateKey(elliptic.P256(), r)
+	case x509.Ed25519:
+		_, key, err := ed25519.GenerateKey(r)
+		return key, err
+	default:
+		return nil, fmt.Errorf("cannot generate keys for public key algorithm %s", alg)
+	}
+}
+
+// InsecureTestKey derives a key pair for the public key algorithm from seed and label,
+// returning the same key for the same inputs; the algorithms are those of GenerateKey.
+//
+// TEST ONLY: anyone who knows the seed and label can recreate the private key.
+func InsecureTestKey(alg x509.PublicKeyAlgorithm, seed []byte, label string) (crypto.Signer, error) {
+	return internal.SeededKey(alg, seed, label)
+}
+
+// isPSS reports whether a signature algorithm uses RSA-PSS, whose salt is always random
+func isPSS(alg x509.SignatureAlgorithm) bool {
+	switch alg {
+	case x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS:
+		return true
+	default:
+		return false
+	}
+}
+
+func randReader(r io.Reader) io.Reader {
+	if r == nil {
+		return rand.Reader
+	}
+	return r
+}
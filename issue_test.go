@@ -0,0 +1,203 @@
+package go_yaml_to_x509
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/rschoonheim/go-yaml-to-x509/store"
+)
+
+func mustTemplate(t *testing.T, yamlData string) *x509.Certificate {
+	t.Helper()
+
+	template, err := X509FromYaml([]byte(yamlData))
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	return template
+}
+
+func TestIssue_SelfSigned(t *testing.T) {
+	template := mustTemplate(t, `
+subject:
+  common_name: "Example CA"
+validity:
+  duration: "1y"
+is_ca: true
+basic_constraints_valid: true
+key_usage:
+  - cert_sign
+`)
+
+	issued, err := Issue(IssueRequest{Template: template}, Options{})
+	if err != nil {
+		t.Fatalf("Failed to issue certificate: %v", err)
+	}
+
+	if issued.PrivateKey == nil {
+		t.Fatal("Expected a generated private key")
+	}
+	if _, ok := issued.PrivateKey.(*ecdsa.PrivateKey); !ok {
+		t.Errorf("Expected ECDSA key by default, got %T", issued.PrivateKey)
+	}
+	if issued.Certificate.SerialNumber == nil || issued.Certificate.SerialNumber.Sign() <= 0 {
+		t.Error("Expected a random serial number to be assigned")
+	}
+	if err := issued.Certificate.CheckSignatureFrom(issued.Certificate); err != nil {
+		t.Errorf("Expected self-signed certificate, got %v", err)
+	}
+}
+
+func TestIssue_KeyAlgorithms(t *testing.T) {
+	for alg, check := range map[string]func(any) bool{
+		"RSA":     func(k any) bool { _, ok := k.(*rsa.PrivateKey); return ok },
+		"ECDSA":   func(k any) bool { _, ok := k.(*ecdsa.PrivateKey); return ok },
+		"Ed25519": func(k any) bool { _, ok := k.(ed25519.PrivateKey); return ok },
+	} {
+		template := mustTemplate(t, "public_key_algorithm: "+alg+"\nvalidity: {duration: 1d}")
+
+		issued, err := Issue(IssueRequest{Template: template}, Options{})
+		if err != nil {
+			t.Errorf("%s: failed to issue certificate: %v", alg, err)
+			continue
+		}
+		if !check(issued.PrivateKey) {
+			t.Errorf("%s: unexpected key type %T", alg, issued.PrivateKey)
+		}
+	}
+}
+
+func TestIssue_SignedByCA(t *testing.T) {
+	ca, err := Issue(IssueRequest{Template: mustTemplate(t, `
+subject: {common_name: "Example CA"}
+validity: {duration: 1y}
+is_ca: true
+basic_constraints_valid: true
+key_usage: [cert_sign]
+`)}, Options{})
+	if err != nil {
+		t.Fatalf("Failed to issue CA: %v", err)
+	}
+
+	leaf, err := Issue(IssueRequest{
+		Template: mustTemplate(t, `
+subject: {common_name: "www.example.com"}
+validity: {duration: 90d}
+dns_names: ["www.example.com"]
+`),
+		Parent: ca.Certificate,
+		Signer: ca.PrivateKey,
+	}, Options{})
+	if err != nil {
+		t.Fatalf("Failed to issue leaf: %v", err)
+	}
+
+	if err := leaf.Certificate.CheckSignatureFrom(ca.Certificate); err != nil {
+		t.Errorf("Expected leaf signed by CA, got %v", err)
+	}
+	if leaf.Certificate.Issuer.CommonName != "Example CA" {
+		t.Errorf("Expected issuer 'Example CA', got '%s'", leaf.Certificate.Issuer.CommonName)
+	}
+}
+
+func TestIssue_InvalidRequests(t *testing.T) {
+	template := mustTemplate(t, `validity: {duration: 1d}`)
+
+	if _, err := Issue(IssueRequest{}, Options{}); err == nil {
+		t.Error("Expected error without template")
+	}
+	if _, err := Issue(IssueRequest{Template: template, Parent: template}, Options{}); err == nil {
+		t.Error("Expected error for parent without signer")
+	}
+	if _, err := Issue(IssueRequest{Template: template, PublicKey: ed25519.PublicKey(make([]byte, 32))}, Options{}); err == nil {
+		t.Error("Expected error for self-signing a supplied public key without signer")
+	}
+}
+
+func TestIssue_RecordsInStore(t *testing.T) {
+	s := store.NewMemoryStore()
+	opts := Options{Store: s}
+	template := mustTemplate(t, `
+serial_number: "0x2a"
+subject: {common_name: "www.example.com"}
+dns_names: ["www.example.com"]
+validity: {duration: 90d}
+`)
+
+	if _, err := Issue(IssueRequest{Template: template, Profile: "web-server"}, opts); err != nil {
+		t.Fatalf("Failed to issue certificate: %v", err)
+	}
+
+	record, err := s.Get(big.NewInt(42))
+	if err != nil {
+		t.Fatalf("Expected certificate to be recorded, got %v", err)
+	}
+	if record.Profile != "web-server" || record.DNSNames[0] != "www.example.com" {
+		t.Errorf("Unexpected record: %+v", record)
+	}
+
+	_, err = Issue(IssueRequest{Template: template}, opts)
+	if !errors.Is(err, store.ErrDuplicateSerial) {
+		t.Errorf("Expected ErrDuplicateSerial for reused serial, got %v", err)
+	}
+}
+
+func TestIssue_ConcurrentDuplicateSerial(t *testing.T) {
+	s, err := store.OpenFileStore(filepath.Join(t.TempDir(), "issued.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := mustTemplate(t, "serial_number: \"0x2a\"\nsubject: {common_name: \"www.example.com\"}\n")
+
+	const issuers = 8
+	errs := make(chan error, issuers)
+	var wg sync.WaitGroup
+	for range issuers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Issue(IssueRequest{Template: template}, Options{Store: s})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	issued := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			issued++
+		case !errors.Is(err, store.ErrDuplicateSerial):
+			t.Errorf("Expected ErrDuplicateSerial, got %v", err)
+		}
+	}
+	records, _ := s.List(store.Query{})
+	if issued != 1 || len(records) != 1 {
+		t.Errorf("Expected one issuance and record, got %d and %d", issued, len(records))
+	}
+}
+
+func TestIssueContext_Canceled(t *testing.T) {
+	template := mustTemplate(t, `
+subject:
+  common_name: "Example CA"
+is_ca: true
+basic_constraints_valid: true
+`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := IssueContext(ctx, IssueRequest{Template: template}, Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package keyfile reads and writes PEM encoded private keys, including PKCS#8
+// keys encrypted with a passphrase.
+package keyfile
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// PEM block types
+const (
+	TypePrivateKey          = "PRIVATE KEY"
+	TypeEncryptedPrivateKey = "ENCRYPTED PRIVATE KEY"
+	TypeECPrivateKey        = "EC PRIVATE KEY"
+	TypeRSAPrivateKey       = "RSA PRIVATE KEY"
+)
+
+// ErrPassphraseRequired is returned when reading an encrypted key without a passphrase
+var ErrPassphraseRequired = errors.New("private key is encrypted, a passphrase is required")
+
+// ErrIncorrectPassphrase is returned when an encrypted key cannot be decrypted
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase")
+
+// Parse parses the first private key in PEM data: a PKCS#8, encrypted PKCS#8,
+// SEC 1 EC or PKCS#1 RSA key. The passphrase is only used for encrypted keys.
+func Parse(data, passphrase []byte) (crypto.Signer, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no private key found")
+		}
+
+		switch block.Type {
+		case TypeEncryptedPrivateKey:
+			if len(passphrase) == 0 {
+				return nil, ErrPassphraseRequired
+			}
+			der, err := DecryptPKCS8(block.Bytes, passphrase)
+			if err != nil {
+				return nil, err
+			}
+			key, err := parsePKCS8(der)
+			if err != nil {
+				// Padding checks let a small share of wrong passphrases through
+				return nil, ErrIncorrectPassphrase
+			}
+			return key, nil
+		case TypePrivateKey:
+			return parsePKCS8(block.Bytes)
+		case TypeECPrivateKey:
+			return x509.ParseECPrivateKey(block.Bytes)
+		case TypeRSAPrivateKey:
+			return x509.ParsePKCS1PrivateKey(block.Bytes)
+		}
+	}
+}
+
+// Read reads a private key file, see Parse
+func Read(path string, passphrase []byte) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := Parse(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// Marshal encodes a private key as a PKCS#8 PEM block, encrypted with the passphrase
+// unless it is empty. opts may be nil.
+func Marshal(key crypto.PrivateKey, passphrase []byte, opts *EncryptOptions) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) == 0 {
+		return pem.EncodeToMemory(&pem.Block{Type: TypePrivateKey, Bytes: der}), nil
+	}
+
+	der, err = EncryptPKCS8(der, passphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: TypeEncryptedPrivateKey, Bytes: der}), nil
+}
+
+// Write writes a private key file readable only by the owner, see Marshal
+func Write(path string, key crypto.PrivateKey, passphrase []byte, opts *EncryptOptions) error {
+	data, err := Marshal(key, passphrase, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// IsEncrypted reports whether PEM data holds an encrypted PKCS#8 key
+func IsEncrypted(data []byte) bool {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return false
+		}
+		if block.Type == TypeEncryptedPrivateKey {
+			return true
+		}
+	}
+}
+
+func parsePKCS8(der []byte) (crypto.Signer, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
@@ -0,0 +1,124 @@
+package keyfile
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The files in testdata were generated with OpenSSL 3, with the passphrase "secret":
+//
+//	openssl genpkey -algorithm EC -pkeyopt ec_paramgen_curve:P-256 -out ec.pem
+//	openssl pkcs8 -topk8 -in ec.pem -v2 aes-256-cbc -v2prf hmacWithSHA256 -passout pass:secret -out ec-aes256-sha256.pem
+//	openssl pkcs8 -topk8 -in rsa.pem -v2 aes-128-cbc -v2prf hmacWithSHA1 -passout pass:secret -out rsa-aes128-sha1.pem
+//	openssl pkcs8 -topk8 -in ec.pem -scrypt -passout pass:secret -out ec-scrypt.pem
+
+func TestRead_Formats(t *testing.T) {
+	ec, err := Read("testdata/ec.pem", nil)
+	if err != nil {
+		t.Fatalf("Failed to read PKCS#8 key: %v", err)
+	}
+	rsaKey, err := Read("testdata/rsa.pem", nil)
+	if err != nil {
+		t.Fatalf("Failed to read PKCS#8 key: %v", err)
+	}
+
+	for path, expected := range map[string]interface{ Equal(crypto.PrivateKey) bool }{
+		"testdata/ec-sec1.pem":          ec.(*ecdsa.PrivateKey),
+		"testdata/ec-aes256-sha256.pem": ec.(*ecdsa.PrivateKey),
+		"testdata/ec-scrypt.pem":        ec.(*ecdsa.PrivateKey),
+		"testdata/rsa-pkcs1.pem":        rsaKey.(*rsa.PrivateKey),
+		"testdata/rsa-aes128-sha1.pem":  rsaKey.(*rsa.PrivateKey),
+	} {
+		key, err := Read(path, []byte("secret"))
+		if err != nil {
+			t.Errorf("Failed to read %s: %v", path, err)
+			continue
+		}
+		if !expected.Equal(key) {
+			t.Errorf("%s: expected the same key as the unencrypted file", path)
+		}
+	}
+}
+
+func TestRead_Passphrase(t *testing.T) {
+	if _, err := Read("testdata/ec-aes256-sha256.pem", nil); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("Expected ErrPassphraseRequired, got %v", err)
+	}
+	if _, err := Read("testdata/ec-aes256-sha256.pem", []byte("wrong")); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Errorf("Expected ErrIncorrectPassphrase, got %v", err)
+	}
+	if _, err := Parse([]byte("not a key"), nil); err == nil || err.Error() != "no private key found" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	key, err := Read("testdata/ec.pem", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kdf := range []string{"", KDFScrypt, KDFPBKDF2} {
+		data, err := Marshal(key, []byte("secret"), &EncryptOptions{KDF: kdf})
+		if err != nil {
+			t.Fatalf("Failed to encrypt key with KDF '%s': %v", kdf, err)
+		}
+		if !IsEncrypted(data) {
+			t.Fatalf("Expected an encrypted key with KDF '%s'", kdf)
+		}
+		parsed, err := Parse(data, []byte("secret"))
+		if err != nil {
+			t.Fatalf("Failed to decrypt key with KDF '%s': %v", kdf, err)
+		}
+		if !key.(*ecdsa.PrivateKey).Equal(parsed) {
+			t.Errorf("Expected the original key back with KDF '%s'", kdf)
+		}
+	}
+
+	data, err := Marshal(key, nil, nil)
+	if err != nil || IsEncrypted(data) {
+		t.Errorf("Expected an unencrypted key without a passphrase, got %v", err)
+	}
+	if _, err := Marshal(key, []byte("secret"), &EncryptOptions{KDF: "bcrypt"}); err == nil || err.Error() != "unknown key derivation function 'bcrypt', expected scrypt or pbkdf2" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	key, err := Read("testdata/rsa.pem", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := Write(path, key, []byte("secret"), nil); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+	if _, err := Read(path, []byte("wrong")); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Errorf("Expected ErrIncorrectPassphrase, got %v", err)
+	}
+}
+
+func TestIsEncrypted(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"testdata/ec.pem":               false,
+		"testdata/ec-aes256-sha256.pem": true,
+	} {
+		key := mustReadFile(t, path)
+		if IsEncrypted(key) != expected {
+			t.Errorf("Expected IsEncrypted(%s) to be %v", path, expected)
+		}
+	}
+}
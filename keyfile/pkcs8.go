@@ -0,0 +1,315 @@
+package keyfile
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Key derivation functions for EncryptPKCS8
+const (
+	KDFScrypt = "scrypt"
+	KDFPBKDF2 = "pbkdf2"
+)
+
+// Parameters of newly encrypted keys: scrypt with OpenSSL's cost, or PBKDF2 with
+// HMAC-SHA256 at the OWASP recommended iteration count
+const (
+	scryptCost       = 1 << 14
+	scryptBlockSize  = 8
+	scryptParallel   = 1
+	pbkdf2Iterations = 600000
+	saltSize         = 16
+)
+
+// Limits on the parameters of keys being decrypted, so that a crafted key cannot
+// demand unbounded memory or time before the passphrase is checked. They leave
+// room for 16 times the cost of newly encrypted keys.
+const (
+	maxScryptMemory     = 256 << 20 // 128 * N * r bytes
+	maxScryptParallel   = 16
+	maxPBKDF2Iterations = 10000000
+)
+
+// Object identifiers of PKCS#5 v2 (RFC 8018) and the AES-CBC ciphers
+var (
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+	oidHMACSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// algorithmIdentifier is an AlgorithmIdentifier with raw parameters
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+// encryptedPrivateKeyInfo is the PKCS#8 EncryptedPrivateKeyInfo structure
+type encryptedPrivateKeyInfo struct {
+	Algorithm     algorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc algorithmIdentifier
+	EncryptionScheme  algorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                 `asn1:"optional"`
+	PRF            algorithmIdentifier `asn1:"optional"`
+}
+
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+// EncryptOptions control how EncryptPKCS8 protects a key
+type EncryptOptions struct {
+	// KDF is KDFScrypt or KDFPBKDF2, scrypt when empty
+	KDF string
+
+	// Rand is the source of the salt and IV, crypto/rand when nil
+	Rand io.Reader
+}
+
+// EncryptPKCS8 encrypts a DER encoded PKCS#8 private key with PBES2 and AES-256-CBC,
+// and returns the DER encoded EncryptedPrivateKeyInfo. opts may be nil.
+func EncryptPKCS8(der, passphrase []byte, opts *EncryptOptions) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+	if opts == nil {
+		opts = &EncryptOptions{}
+	}
+	r := opts.Rand
+	if r == nil {
+		r = rand.Reader
+	}
+
+	salt := make([]byte, saltSize)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(r, salt); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, iv); err != nil {
+		return nil, err
+	}
+
+	var kdf algorithmIdentifier
+	var key []byte
+	var err error
+	switch opts.KDF {
+	case "", KDFScrypt:
+		kdf, err = marshalAlgorithm(oidScrypt, scryptParams{
+			Salt:                     salt,
+			CostParameter:            scryptCost,
+			BlockSize:                scryptBlockSize,
+			ParallelizationParameter: scryptParallel,
+		})
+		if err == nil {
+			key, err = scrypt.Key(passphrase, salt, scryptCost, scryptBlockSize, scryptParallel, 32)
+		}
+	case KDFPBKDF2:
+		kdf, err = marshalAlgorithm(oidPBKDF2, pbkdf2Params{
+			Salt:           salt,
+			IterationCount: pbkdf2Iterations,
+			PRF:            algorithmIdentifier{Algorithm: oidHMACSHA256, Parameters: asn1.NullRawValue},
+		})
+		if err == nil {
+			key, err = pbkdf2.Key(sha256.New, string(passphrase), salt, pbkdf2Iterations, 32)
+		}
+	default:
+		return nil, fmt.Errorf("unknown key derivation function '%s', expected %s or %s", opts.KDF, KDFScrypt, KDFPBKDF2)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scheme, err := marshalAlgorithm(oidAES256CBC, iv)
+	if err != nil {
+		return nil, err
+	}
+	algorithm, err := marshalAlgorithm(oidPBES2, pbes2Params{KeyDerivationFunc: kdf, EncryptionScheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data := pad(der, c.BlockSize())
+	cipher.NewCBCEncrypter(c, iv).CryptBlocks(data, data)
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{Algorithm: algorithm, EncryptedData: data})
+}
+
+// marshalAlgorithm returns an AlgorithmIdentifier with DER encoded parameters
+func marshalAlgorithm(oid asn1.ObjectIdentifier, params any) (algorithmIdentifier, error) {
+	der, err := asn1.Marshal(params)
+	if err != nil {
+		return algorithmIdentifier{}, err
+	}
+	return algorithmIdentifier{Algorithm: oid, Parameters: asn1.RawValue{FullBytes: der}}, nil
+}
+
+// DecryptPKCS8 decrypts a DER encoded EncryptedPrivateKeyInfo protected with
+// PBES2, and returns the DER encoded PKCS#8 private key
+func DecryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("invalid encrypted private key: %w", err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("invalid encrypted private key: trailing data")
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported key encryption %s, expected PBES2", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("invalid PBES2 parameters: %w", err)
+	}
+
+	block, iv, err := cipherFor(params.EncryptionScheme)
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(params.KeyDerivationFunc, passphrase, block)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data := info.EncryptedData
+	if len(data) == 0 || len(data)%c.BlockSize() != 0 {
+		return nil, errors.New("invalid encrypted private key: bad length")
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(c, iv).CryptBlocks(plain, data)
+
+	return unpad(plain, c.BlockSize())
+}
+
+// cipherFor returns the AES key size and IV of an encryption scheme
+func cipherFor(scheme algorithmIdentifier) (int, []byte, error) {
+	var size int
+	switch {
+	case scheme.Algorithm.Equal(oidAES128CBC):
+		size = 16
+	case scheme.Algorithm.Equal(oidAES192CBC):
+		size = 24
+	case scheme.Algorithm.Equal(oidAES256CBC):
+		size = 32
+	default:
+		return 0, nil, fmt.Errorf("unsupported cipher %s, expected AES-CBC", scheme.Algorithm)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(scheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return 0, nil, errors.New("invalid AES-CBC initialisation vector")
+	}
+	return size, iv, nil
+}
+
+// deriveKey derives a key of the given size from the passphrase
+func deriveKey(kdf algorithmIdentifier, passphrase []byte, size int) ([]byte, error) {
+	if kdf.Algorithm.Equal(oidScrypt) {
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, fmt.Errorf("invalid scrypt parameters: %w", err)
+		}
+		if params.KeyLength != 0 && params.KeyLength != size {
+			return nil, fmt.Errorf("scrypt key length %d does not match the cipher", params.KeyLength)
+		}
+		n, r, p := params.CostParameter, params.BlockSize, params.ParallelizationParameter
+		if n <= 1 || r <= 0 || p <= 0 {
+			return nil, fmt.Errorf("invalid scrypt parameters N=%d r=%d p=%d", n, r, p)
+		}
+		if int64(n) > maxScryptMemory/128/int64(r) || p > maxScryptParallel {
+			return nil, fmt.Errorf("scrypt parameters N=%d r=%d p=%d exceed the supported limits", n, r, p)
+		}
+		return scrypt.Key(passphrase, params.Salt, n, r, p, size)
+	}
+	if !kdf.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation function %s", kdf.Algorithm)
+	}
+
+	var params pbkdf2Params
+	if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("invalid PBKDF2 parameters: %w", err)
+	}
+	if params.KeyLength != 0 && params.KeyLength != size {
+		return nil, fmt.Errorf("PBKDF2 key length %d does not match the cipher", params.KeyLength)
+	}
+
+	if params.IterationCount <= 0 || params.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("PBKDF2 iteration count %d is outside the supported range of 1 to %d", params.IterationCount, maxPBKDF2Iterations)
+	}
+
+	var h func() hash.Hash
+	switch prf := params.PRF.Algorithm; {
+	case len(prf) == 0, prf.Equal(oidHMACSHA1):
+		h = sha1.New
+	case prf.Equal(oidHMACSHA256):
+		h = sha256.New
+	case prf.Equal(oidHMACSHA512):
+		h = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported PBKDF2 pseudorandom function %s", prf)
+	}
+
+	return pbkdf2.Key(h, string(passphrase), params.Salt, params.IterationCount, size)
+}
+
+// pad returns a copy of data with PKCS#7 padding
+func pad(data []byte, blockSize int) []byte {
+	n := blockSize - len(data)%blockSize
+	padded := make([]byte, len(data), len(data)+n)
+	copy(padded, data)
+	for range n {
+		padded = append(padded, byte(n))
+	}
+	return padded
+}
+
+// unpad removes PKCS#7 padding. Bad padding almost always means a wrong passphrase.
+func unpad(data []byte, blockSize int) ([]byte, error) {
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, ErrIncorrectPassphrase
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, ErrIncorrectPassphrase
+		}
+	}
+	return data[:len(data)-n], nil
+}
@@ -0,0 +1,82 @@
+package keyfile
+
+import (
+	"encoding/asn1"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+)
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecryptPKCS8_Invalid(t *testing.T) {
+	block, _ := pem.Decode(mustReadFile(t, "testdata/ec-aes256-sha256.pem"))
+
+	tests := map[string][]byte{
+		"invalid encrypted private key": []byte{0x30, 0x00},
+		"trailing data":                 append(append([]byte{}, block.Bytes...), 0x00),
+	}
+	for expected, der := range tests {
+		if _, err := DecryptPKCS8(der, []byte("secret")); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestDecryptPKCS8_Limits(t *testing.T) {
+	encrypt := func(kdf asn1.ObjectIdentifier, params any) []byte {
+		t.Helper()
+
+		kdfAlg, err := marshalAlgorithm(kdf, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scheme, err := marshalAlgorithm(oidAES256CBC, make([]byte, 16))
+		if err != nil {
+			t.Fatal(err)
+		}
+		alg, err := marshalAlgorithm(oidPBES2, pbes2Params{KeyDerivationFunc: kdfAlg, EncryptionScheme: scheme})
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := asn1.Marshal(encryptedPrivateKeyInfo{Algorithm: alg, EncryptedData: make([]byte, 16)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	salt := make([]byte, saltSize)
+	tests := map[string][]byte{
+		"scrypt parameters N=1048576 r=8 p=1 exceed the supported limits":  encrypt(oidScrypt, scryptParams{Salt: salt, CostParameter: 1 << 20, BlockSize: 8, ParallelizationParameter: 1}),
+		"scrypt parameters N=16384 r=8 p=64 exceed the supported limits":   encrypt(oidScrypt, scryptParams{Salt: salt, CostParameter: 1 << 14, BlockSize: 8, ParallelizationParameter: 64}),
+		"invalid scrypt parameters N=16384 r=0 p=1":                        encrypt(oidScrypt, scryptParams{Salt: salt, CostParameter: 1 << 14, BlockSize: 0, ParallelizationParameter: 1}),
+		"PBKDF2 iteration count 2147483647 is outside the supported range": encrypt(oidPBKDF2, pbkdf2Params{Salt: salt, IterationCount: 1<<31 - 1}),
+		"PBKDF2 iteration count 0 is outside the supported range":          encrypt(oidPBKDF2, pbkdf2Params{Salt: salt}),
+	}
+	for expected, der := range tests {
+		if _, err := DecryptPKCS8(der, []byte("secret")); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestUnpad(t *testing.T) {
+	if data, err := unpad([]byte{1, 2, 3, 3, 3}, 16); err != nil || len(data) != 2 {
+		t.Errorf("Expected padding to be removed, got %v (%v)", data, err)
+	}
+	for _, data := range [][]byte{{1, 2, 0}, {1, 2, 17}, {1, 3, 2}} {
+		if _, err := unpad(data, 16); err != ErrIncorrectPassphrase {
+			t.Errorf("Expected ErrIncorrectPassphrase for %v, got %v", data, err)
+		}
+	}
+}
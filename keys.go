@@ -0,0 +1,51 @@
+package go_yaml_to_x509
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+	"github.com/rschoonheim/go-yaml-to-x509/keyfile"
+	"github.com/rschoonheim/go-yaml-to-x509/signer"
+)
+
+// PassphraseFunc returns the passphrase that encrypts the private key of the named certificate
+type PassphraseFunc func(name string) ([]byte, error)
+
+// MarshalPrivateKey encodes a private key generated for the named certificate as a
+// PKCS#8 PEM block. The key is encrypted when the spec has a 'key_encryption' section
+// or opts.Passphrase is set, with the passphrase the section references or else the
+// one opts.Passphrase returns. Passphrases are never read from the YAML itself.
+func MarshalPrivateKey(name string, key crypto.PrivateKey, spec *CertificateSpec, opts Options) ([]byte, error) {
+	var encryption KeyEncryptionSpec
+	if spec != nil && spec.KeyEncryption != nil {
+		encryption = *spec.KeyEncryption
+	} else if opts.Passphrase == nil {
+		return keyfile.Marshal(key, nil, nil)
+	}
+
+	var passphrase []byte
+	var err error
+	switch {
+	case encryption.Passphrase != nil:
+		passphrase, err = signer.ReadSecret(opts.IncludeFS, encryption.Passphrase)
+	case opts.Passphrase != nil:
+		passphrase, err = opts.Passphrase(name)
+	default:
+		err = errors.New("no passphrase configured, reference one in 'passphrase' or set Options.Passphrase")
+	}
+	if err == nil && len(passphrase) == 0 {
+		err = errors.New("passphrase is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key encryption: %w", err)
+	}
+
+	// Seeded builds also reproduce the salt and IV
+	r := opts.Rand
+	if seed := opts.testSeed(spec); seed != nil {
+		r = internal.SeededReader(seed, "key_encryption:"+name)
+	}
+	return keyfile.Marshal(key, passphrase, &keyfile.EncryptOptions{KDF: encryption.KDF, Rand: r})
+}
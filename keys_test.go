@@ -0,0 +1,116 @@
+package go_yaml_to_x509
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rschoonheim/go-yaml-to-x509/keyfile"
+)
+
+func TestMarshalPrivateKey_PassphraseSources(t *testing.T) {
+	key, err := GenerateKey(0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	if err := os.WriteFile(passphraseFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_KEY_PASSPHRASE", "from-env")
+
+	callback := func(name string) ([]byte, error) {
+		return []byte("from-callback-" + name), nil
+	}
+
+	tests := []struct {
+		name       string
+		spec       *CertificateSpec
+		opts       Options
+		passphrase string
+	}{
+		{"unencrypted", &CertificateSpec{}, Options{}, ""},
+		{"env", &CertificateSpec{KeyEncryption: &KeyEncryptionSpec{Passphrase: &SecretSpec{Env: "TEST_KEY_PASSPHRASE"}}}, Options{}, "from-env"},
+		{"file", &CertificateSpec{KeyEncryption: &KeyEncryptionSpec{KDF: "pbkdf2", Passphrase: &SecretSpec{File: passphraseFile}}}, Options{}, "from-file"},
+		{"relative file", &CertificateSpec{KeyEncryption: &KeyEncryptionSpec{KDF: "pbkdf2", Passphrase: &SecretSpec{File: "secrets/passphrase"}}}, Options{IncludeFS: fstest.MapFS{"secrets/passphrase": {Data: []byte("from-document\n")}}}, "from-document"},
+		{"callback", &CertificateSpec{KeyEncryption: &KeyEncryptionSpec{}}, Options{Passphrase: callback}, "from-callback-www"},
+		{"callback without section", &CertificateSpec{}, NewOptions(WithPassphrase(callback)), "from-callback-www"},
+		{"reference wins", &CertificateSpec{KeyEncryption: &KeyEncryptionSpec{Passphrase: &SecretSpec{Env: "TEST_KEY_PASSPHRASE"}}}, Options{Passphrase: callback}, "from-env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := MarshalPrivateKey("www", key, tt.spec, tt.opts)
+			if err != nil {
+				t.Fatalf("Failed to marshal key: %v", err)
+			}
+			if keyfile.IsEncrypted(data) != (tt.passphrase != "") {
+				t.Fatalf("Expected encrypted %v", tt.passphrase != "")
+			}
+
+			parsed, err := keyfile.Parse(data, []byte(tt.passphrase))
+			if err != nil {
+				t.Fatalf("Failed to read key back: %v", err)
+			}
+			if !key.(*ecdsa.PrivateKey).Equal(parsed) {
+				t.Error("Expected the original key back")
+			}
+		})
+	}
+}
+
+func TestMarshalPrivateKey_Errors(t *testing.T) {
+	key, err := GenerateKey(0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing := func(string) ([]byte, error) { return nil, errors.New("prompt closed") }
+
+	tests := []struct {
+		name     string
+		spec     *CertificateSpec
+		opts     Options
+		expected string
+	}{
+		{"no passphrase", &CertificateSpec{KeyEncryption: &KeyEncryptionSpec{}}, Options{}, "key encryption: no passphrase configured"},
+		{"unset env", &CertificateSpec{KeyEncryption: &KeyEncryptionSpec{Passphrase: &SecretSpec{Env: "TEST_KEY_PASSPHRASE_UNSET"}}}, Options{}, "key encryption: environment variable 'TEST_KEY_PASSPHRASE_UNSET' is not set"},
+		{"callback error", &CertificateSpec{}, Options{Passphrase: failing}, "key encryption: prompt closed"},
+		{"empty", &CertificateSpec{}, Options{Passphrase: func(string) ([]byte, error) { return nil, nil }}, "key encryption: passphrase is empty"},
+		{"unknown kdf", &CertificateSpec{KeyEncryption: &KeyEncryptionSpec{KDF: "bcrypt"}}, Options{Passphrase: func(string) ([]byte, error) { return []byte("x"), nil }}, "unknown key derivation function 'bcrypt'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MarshalPrivateKey("www", key, tt.spec, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestKeyEncryption_FromYaml(t *testing.T) {
+	_, err := X509FromYaml([]byte(`
+subject:
+  common_name: "www.example.com"
+key_encryption:
+  passphrase: "hunter2"
+`))
+	if err == nil || !strings.Contains(err.Error(), "secrets cannot be written inline") {
+		t.Errorf("Expected inline passphrase to be rejected, got %v", err)
+	}
+
+	_, err = X509FromYaml([]byte(`
+subject:
+  common_name: "www.example.com"
+key_encryption:
+  kdf: bcrypt
+`), WithStrict())
+	if err == nil || !strings.Contains(err.Error(), "key_encryption.kdf: unknown value 'bcrypt'") {
+		t.Errorf("Expected unknown kdf to be rejected in strict mode, got %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+package kube
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issuer kinds of a cert-manager issuerRef
+const (
+	IssuerKind        = "Issuer"
+	ClusterIssuerKind = "ClusterIssuer"
+)
+
+// CertificateOptions names a cert-manager Certificate and the issuer that signs it
+type CertificateOptions struct {
+	Metadata
+
+	// SecretName is the Secret cert-manager stores the certificate in, Name when empty
+	SecretName string
+
+	// IssuerName, IssuerKind and IssuerGroup form the issuerRef; the kind is Issuer
+	// when empty and the group is left to cert-manager's default
+	IssuerName  string
+	IssuerKind  string
+	IssuerGroup string
+
+	// RenewBefore is how long before expiry cert-manager renews, its default when zero
+	RenewBefore time.Duration
+}
+
+// Unsupported is a field of a CertificateSpec that a cert-manager Certificate
+// cannot express, left out of the manifest
+type Unsupported = internal.Unsupported
+
+// certificate is a cert-manager.io/v1 Certificate manifest
+type certificate struct {
+	APIVersion string          `yaml:"apiVersion"`
+	Kind       string          `yaml:"kind"`
+	Metadata   Metadata        `yaml:"metadata"`
+	Spec       certificateSpec `yaml:"spec"`
+}
+
+type certificateSpec struct {
+	SecretName         string      `yaml:"secretName"`
+	CommonName         string      `yaml:"commonName,omitempty"`
+	Subject            *subject    `yaml:"subject,omitempty"`
+	DNSNames           []string    `yaml:"dnsNames,omitempty"`
+	IPAddresses        []string    `yaml:"ipAddresses,omitempty"`
+	URIs               []string    `yaml:"uris,omitempty"`
+	EmailAddresses     []string    `yaml:"emailAddresses,omitempty"`
+	Duration           string      `yaml:"duration,omitempty"`
+	RenewBefore        string      `yaml:"renewBefore,omitempty"`
+	IsCA               bool        `yaml:"isCA,omitempty"`
+	Usages             []string    `yaml:"usages,omitempty"`
+	PrivateKey         *privateKey `yaml:"privateKey,omitempty"`
+	SignatureAlgorithm string      `yaml:"signatureAlgorithm,omitempty"`
+	IssuerRef          issuerRef   `yaml:"issuerRef"`
+}
+
+type subject struct {
+	Organizations       []string `yaml:"organizations,omitempty"`
+	Countries           []string `yaml:"countries,omitempty"`
+	OrganizationalUnits []string `yaml:"organizationalUnits,omitempty"`
+	Localities          []string `yaml:"localities,omitempty"`
+	Provinces           []string `yaml:"provinces,omitempty"`
+	StreetAddresses     []string `yaml:"streetAddresses,omitempty"`
+	PostalCodes         []string `yaml:"postalCodes,omitempty"`
+	SerialNumber        string   `yaml:"serialNumber,omitempty"`
+}
+
+type privateKey struct {
+	Algorithm string `yaml:"algorithm"`
+	Size      int    `yaml:"size,omitempty"`
+	Encoding  string `yaml:"encoding"`
+}
+
+type issuerRef struct {
+	Name  string `yaml:"name"`
+	Kind  string `yaml:"kind"`
+	Group string `yaml:"group,omitempty"`
+}
+
+// certManagerUsages maps key usages and extended key usages to cert-manager's names
+var certManagerUsages = map[string]string{
+	internal.KeyUsageDigitalSignature:              "digital signature",
+	internal.KeyUsageContentCommitment:             "content commitment",
+	internal.KeyUsageKeyEncipherment:               "key encipherment",
+	internal.KeyUsageDataEncipherment:              "data encipherment",
+	internal.KeyUsageKeyAgreement:                  "key agreement",
+	internal.KeyUsageCertSign:                      "cert sign",
+	internal.KeyUsageCRLSign:                       "crl sign",
+	internal.KeyUsageEncipherOnly:                  "encipher only",
+	internal.KeyUsageDecipherOnly:                  "decipher only",
+	internal.ExtKeyUsageAny:                        "any",
+	internal.ExtKeyUsageServerAuth:                 "server auth",
+	internal.ExtKeyUsageClientAuth:                 "client auth",
+	internal.ExtKeyUsageCodeSigning:                "code signing",
+	internal.ExtKeyUsageEmailProtection:            "email protection",
+	internal.ExtKeyUsageIPSECEndSystem:             "ipsec end system",
+	internal.ExtKeyUsageIPSECTunnel:                "ipsec tunnel",
+	internal.ExtKeyUsageIPSECUser:                  "ipsec user",
+	internal.ExtKeyUsageTimeStamping:               "timestamping",
+	internal.ExtKeyUsageOCSPSigning:                "ocsp signing",
+	internal.ExtKeyUsageMicrosoftServerGatedCrypto: "microsoft sgc",
+	internal.ExtKeyUsageNetscapeServerGatedCrypto:  "netscape sgc",
+}
+
+// certManagerSignatureAlgorithms lists the signature algorithms cert-manager accepts
+var certManagerSignatureAlgorithms = map[string]bool{
+	internal.SigAlgSHA256WithRSA:   true,
+	internal.SigAlgSHA384WithRSA:   true,
+	internal.SigAlgSHA512WithRSA:   true,
+	internal.SigAlgECDSAWithSHA256: true,
+	internal.SigAlgECDSAWithSHA384: true,
+	internal.SigAlgECDSAWithSHA512: true,
+	internal.SigAlgPureEd25519:     true,
+}
+
+// Certificate converts a resolved CertificateSpec to a cert-manager.io/v1 Certificate
+// manifest. cert-manager generates the key and picks the serial number and validity
+// period itself, so fields it cannot express are left out and returned as Unsupported,
+// sorted by field.
+func Certificate(spec *internal.CertificateSpec, opts CertificateOptions) ([]byte, []Unsupported, error) {
+	if err := opts.Metadata.validate(); err != nil {
+		return nil, nil, err
+	}
+	if opts.IssuerName == "" {
+		return nil, nil, fmt.Errorf("an issuer name is required")
+	}
+	kind := opts.IssuerKind
+	if kind == "" {
+		kind = IssuerKind
+	}
+	if kind != IssuerKind && kind != ClusterIssuerKind && opts.IssuerGroup == "" {
+		return nil, nil, fmt.Errorf("issuer kind '%s' requires an issuer group", kind)
+	}
+	secretName := opts.SecretName
+	if secretName == "" {
+		secretName = opts.Name
+	}
+	if err := validateName("secret name", secretName); err != nil {
+		return nil, nil, err
+	}
+
+	c := certificateSpec{
+		SecretName:     secretName,
+		DNSNames:       spec.DNSNames,
+		IPAddresses:    spec.IPAddresses,
+		URIs:           spec.URIs,
+		EmailAddresses: spec.EmailAddresses,
+		IsCA:           spec.IsCA,
+		IssuerRef:      issuerRef{Name: opts.IssuerName, Kind: kind, Group: opts.IssuerGroup},
+	}
+	if opts.RenewBefore > 0 {
+		c.RenewBefore = opts.RenewBefore.String()
+	}
+
+	var unsupported []Unsupported
+	skip := func(field, reason string) {
+		unsupported = append(unsupported, Unsupported{Field: field, Reason: reason})
+	}
+
+	name := internal.ParsePkixName(spec.Subject)
+	c.CommonName = name.CommonName
+	s := subject{
+		Organizations:       name.Organization,
+		Countries:           name.Country,
+		OrganizationalUnits: name.OrganizationalUnit,
+		Localities:          name.Locality,
+		Provinces:           name.Province,
+		StreetAddresses:     name.StreetAddress,
+		PostalCodes:         name.PostalCode,
+		SerialNumber:        name.SerialNumber,
+	}
+	if !reflect.DeepEqual(s, subject{}) {
+		c.Subject = &s
+	}
+
+	duration, err := certificateDuration(spec, skip)
+	if err != nil {
+		return nil, nil, err
+	}
+	if duration > 0 {
+		c.Duration = duration.String()
+	}
+
+	for _, field := range []struct {
+		name   string
+		usages []string
+	}{
+		{"key_usage", spec.KeyUsage},
+		{"ext_key_usage", spec.ExtKeyUsage},
+	} {
+		for _, usage := range field.usages {
+			if u, ok := certManagerUsages[usage]; ok {
+				c.Usages = append(c.Usages, u)
+			} else {
+				skip(field.name, fmt.Sprintf("cert-manager has no usage for '%s'", usage))
+			}
+		}
+	}
+
+	switch spec.PublicKeyAlgorithm {
+	case internal.PubKeyAlgRSA:
+		c.PrivateKey = &privateKey{Algorithm: "RSA", Size: 2048, Encoding: "PKCS8"}
+	case internal.PubKeyAlgECDSA, "":
+		c.PrivateKey = &privateKey{Algorithm: "ECDSA", Size: 256, Encoding: "PKCS8"}
+	case internal.PubKeyAlgEd25519:
+		c.PrivateKey = &privateKey{Algorithm: "Ed25519", Encoding: "PKCS8"}
+	default:
+		skip("public_key_algorithm", fmt.Sprintf("cert-manager cannot generate %s keys", spec.PublicKeyAlgorithm))
+	}
+
+	if spec.SignatureAlgorithm != "" {
+		if certManagerSignatureAlgorithms[spec.SignatureAlgorithm] {
+			c.SignatureAlgorithm = spec.SignatureAlgorithm
+		} else {
+			skip("signature_algorithm", fmt.Sprintf("cert-manager does not support '%s'", spec.SignatureAlgorithm))
+		}
+	}
+
+	if spec.SerialNumber != "" {
+		skip("serial_number", "the issuer picks the serial number")
+	}
+	if len(spec.Issuer) > 0 {
+		skip("issuer", "the issuer name comes from issuerRef")
+	}
+	if spec.MaxPathLen != 0 || spec.MaxPathLenZero {
+		skip("max_path_len", "cert-manager does not set a path length constraint")
+	}
+	if spec.PublicKey != "" {
+		skip("public_key", "cert-manager generates the key of a Certificate")
+	}
+	if spec.Signer != nil {
+		skip("signer", "the issuer signs the certificate")
+	}
+	if spec.KeyEncryption != nil {
+		skip("key_encryption", "cert-manager stores keys unencrypted in the Secret")
+	}
+	if spec.InsecureTestSeed != "" {
+		skip("insecure_test_seed", "cert-manager generates keys at random")
+	}
+
+	sort.SliceStable(unsupported, func(i, j int) bool {
+		return unsupported[i].Field < unsupported[j].Field
+	})
+
+	out, err := yaml.Marshal(certificate{
+		APIVersion: "cert-manager.io/v1",
+		Kind:       "Certificate",
+		Metadata:   opts.Metadata,
+		Spec:       c,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, unsupported, nil
+}
+
+// certificateDuration returns the validity period of a spec, from validity.duration
+// or the distance between not_before and not_after. cert-manager starts the period
+// when it issues, so absolute start times are reported through skip.
+func certificateDuration(spec *internal.CertificateSpec, skip func(field, reason string)) (time.Duration, error) {
+	if v := spec.Validity; v != nil {
+		if v.Anchor != "" {
+			skip("validity.anchor", "cert-manager starts the validity period when it issues")
+		}
+		if v.Backdate != "" {
+			skip("validity.backdate", "cert-manager does not backdate certificates")
+		}
+		if v.Duration != "" {
+			d, err := internal.ParseDuration(v.Duration)
+			if err != nil {
+				return 0, fmt.Errorf("validity.duration: %w", err)
+			}
+			return d, nil
+		}
+	}
+
+	if spec.NotBefore == "" || spec.NotAfter == "" {
+		if spec.NotBefore != "" || spec.NotAfter != "" {
+			skip("not_before", "cert-manager needs both not_before and not_after to derive a duration")
+		}
+		return 0, nil
+	}
+	notBefore, err := time.Parse(time.RFC3339, spec.NotBefore)
+	if err != nil {
+		return 0, fmt.Errorf("invalid not_before '%s', expected an RFC3339 timestamp", spec.NotBefore)
+	}
+	notAfter, err := time.Parse(time.RFC3339, spec.NotAfter)
+	if err != nil {
+		return 0, fmt.Errorf("invalid not_after '%s', expected an RFC3339 timestamp", spec.NotAfter)
+	}
+	skip("not_before", "cert-manager starts the validity period when it issues, only the duration is kept")
+	return notAfter.Sub(notBefore), nil
+}
@@ -0,0 +1,132 @@
+package kube
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCertificate(t *testing.T) {
+	spec := &internal.CertificateSpec{
+		Subject: map[string]string{
+			internal.DNCommonName:   "www.example.com",
+			internal.DNOrganization: "Example",
+			internal.DNCountry:      "NL",
+		},
+		Validity:           &internal.ValiditySpec{Duration: "90d"},
+		KeyUsage:           []string{internal.KeyUsageDigitalSignature, internal.KeyUsageKeyEncipherment},
+		ExtKeyUsage:        []string{internal.ExtKeyUsageServerAuth, internal.ExtKeyUsageMicrosoftKernelCodeSigning},
+		DNSNames:           []string{"www.example.com", "example.com"},
+		IPAddresses:        []string{"192.0.2.1"},
+		PublicKeyAlgorithm: internal.PubKeyAlgRSA,
+		SignatureAlgorithm: internal.SigAlgSHA384WithRSA,
+		SerialNumber:       "42",
+	}
+
+	out, unsupported, err := Certificate(spec, CertificateOptions{
+		Metadata:    Metadata{Name: "www", Namespace: "web"},
+		IssuerName:  "letsencrypt",
+		IssuerKind:  ClusterIssuerKind,
+		RenewBefore: 30 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Certificate: %v", err)
+	}
+
+	var manifest certificate
+	if err := yaml.Unmarshal(out, &manifest); err != nil {
+		t.Fatalf("manifest is not YAML: %v", err)
+	}
+	if manifest.APIVersion != "cert-manager.io/v1" || manifest.Kind != "Certificate" {
+		t.Errorf("unexpected header %s %s", manifest.APIVersion, manifest.Kind)
+	}
+
+	expected := certificateSpec{
+		SecretName:  "www",
+		CommonName:  "www.example.com",
+		Subject:     &subject{Organizations: []string{"Example"}, Countries: []string{"NL"}},
+		DNSNames:    []string{"www.example.com", "example.com"},
+		IPAddresses: []string{"192.0.2.1"},
+		Duration:    "2160h0m0s",
+		RenewBefore: "720h0m0s",
+		Usages:      []string{"digital signature", "key encipherment", "server auth"},
+		PrivateKey:  &privateKey{Algorithm: "RSA", Size: 2048, Encoding: "PKCS8"},
+
+		SignatureAlgorithm: internal.SigAlgSHA384WithRSA,
+		IssuerRef:          issuerRef{Name: "letsencrypt", Kind: ClusterIssuerKind},
+	}
+	if !reflect.DeepEqual(manifest.Spec, expected) {
+		t.Errorf("unexpected spec\n got: %+v\nwant: %+v", manifest.Spec, expected)
+	}
+
+	var fields []string
+	for _, u := range unsupported {
+		fields = append(fields, u.Field)
+	}
+	if !reflect.DeepEqual(fields, []string{"ext_key_usage", "serial_number"}) {
+		t.Errorf("unexpected unsupported fields %v", unsupported)
+	}
+}
+
+func TestCertificate_Defaults(t *testing.T) {
+	spec := &internal.CertificateSpec{
+		Subject:   map[string]string{internal.DNCommonName: "Example CA"},
+		NotBefore: "2025-01-01T00:00:00Z",
+		NotAfter:  "2026-01-01T00:00:00Z",
+		IsCA:      true,
+	}
+
+	out, unsupported, err := Certificate(spec, CertificateOptions{
+		Metadata:   Metadata{Name: "ca"},
+		SecretName: "ca-key-pair",
+		IssuerName: "selfsigned",
+	})
+	if err != nil {
+		t.Fatalf("Certificate: %v", err)
+	}
+
+	var manifest certificate
+	if err := yaml.Unmarshal(out, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	s := manifest.Spec
+	if s.SecretName != "ca-key-pair" || !s.IsCA || s.Duration != "8760h0m0s" || s.Subject != nil {
+		t.Errorf("unexpected spec %+v", s)
+	}
+	if s.PrivateKey.Algorithm != "ECDSA" || s.PrivateKey.Size != 256 || s.IssuerRef.Kind != IssuerKind {
+		t.Errorf("expected an ECDSA P-256 key from an Issuer, got %+v %+v", s.PrivateKey, s.IssuerRef)
+	}
+	if len(unsupported) != 1 || unsupported[0].Field != "not_before" {
+		t.Errorf("expected the absolute start time to be reported, got %v", unsupported)
+	}
+}
+
+func TestCertificate_Errors(t *testing.T) {
+	spec := &internal.CertificateSpec{Subject: map[string]string{internal.DNCommonName: "www"}}
+
+	tests := []struct {
+		name string
+		spec *internal.CertificateSpec
+		opts CertificateOptions
+		err  string
+	}{
+		{"no issuer", spec, CertificateOptions{Metadata: Metadata{Name: "www"}}, "an issuer name is required"},
+		{"custom kind without group", spec, CertificateOptions{Metadata: Metadata{Name: "www"}, IssuerName: "vault", IssuerKind: "VaultIssuer"}, "issuer kind 'VaultIssuer' requires an issuer group"},
+		{"invalid secret name", spec, CertificateOptions{Metadata: Metadata{Name: "www"}, SecretName: "www.", IssuerName: "ca"}, "invalid secret name 'www.'"},
+		{"invalid duration", &internal.CertificateSpec{Validity: &internal.ValiditySpec{Duration: "soon"}}, CertificateOptions{Metadata: Metadata{Name: "www"}, IssuerName: "ca"}, "invalid duration 'soon'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Certificate(tt.spec, tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Package kube exports certificates as Kubernetes manifests: kubernetes.io/tls
+// Secrets holding issued certificates and keys, and cert-manager Certificate
+// resources converted from YAML profiles. Manifests are YAML and need no cluster.
+package kube
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Metadata names a Kubernetes object
+type Metadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// namePattern matches a lowercase RFC 1123 subdomain, the format of object names
+var namePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// validate checks the name and namespace of an object
+func (m Metadata) validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("object name is required")
+	}
+	if err := validateName("name", m.Name); err != nil {
+		return err
+	}
+	if m.Namespace != "" {
+		return validateName("namespace", m.Namespace)
+	}
+	return nil
+}
+
+func validateName(field, name string) error {
+	if len(name) > 253 || !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid %s '%s', expected a lowercase RFC 1123 subdomain", field, name)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package kube
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+
+	"github.com/rschoonheim/go-yaml-to-x509/keyfile"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Keys of a kubernetes.io/tls Secret
+const (
+	SecretTypeTLS = "kubernetes.io/tls"
+	TLSCertKey    = "tls.crt"
+	TLSKeyKey     = "tls.key"
+	CACertKey     = "ca.crt"
+)
+
+// secret is a Secret manifest
+type secret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   Metadata          `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	Data       map[string]string `yaml:"data"`
+}
+
+// TLSSecret returns a kubernetes.io/tls Secret manifest. tls.crt holds the chain,
+// the certificate followed by its intermediates, and tls.key the unencrypted PKCS#8
+// key. ca.crt holds the issuing CA and is omitted when ca is nil.
+func TLSSecret(meta Metadata, chain []*x509.Certificate, key crypto.PrivateKey, ca *x509.Certificate) ([]byte, error) {
+	if err := meta.validate(); err != nil {
+		return nil, err
+	}
+	if len(chain) == 0 {
+		return nil, errors.New("a TLS Secret requires a certificate")
+	}
+	if key == nil {
+		return nil, errors.New("a TLS Secret requires the certificate's private key")
+	}
+
+	keyPEM, err := keyfile.Marshal(key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]string{
+		TLSCertKey: encode(certificatesPEM(chain)),
+		TLSKeyKey:  encode(keyPEM),
+	}
+	if ca != nil {
+		data[CACertKey] = encode(certificatesPEM([]*x509.Certificate{ca}))
+	}
+
+	return yaml.Marshal(secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   meta,
+		Type:       SecretTypeTLS,
+		Data:       data,
+	})
+}
+
+func certificatesPEM(certs []*x509.Certificate) []byte {
+	var out []byte
+	for _, cert := range certs {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return out
+}
+
+func encode(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,138 @@
+package kube
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func testCertificate(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: isCA,
+		DNSNames:              []string{cn},
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestTLSSecret(t *testing.T) {
+	ca, caKey := testCertificate(t, "Test CA", true, nil, nil)
+	leaf, leafKey := testCertificate(t, "www.example.com", false, ca, caKey)
+
+	out, err := TLSSecret(Metadata{Name: "www-tls", Namespace: "web", Labels: map[string]string{"app": "www"}},
+		[]*x509.Certificate{leaf}, leafKey, ca)
+	if err != nil {
+		t.Fatalf("TLSSecret: %v", err)
+	}
+
+	var manifest secret
+	if err := yaml.Unmarshal(out, &manifest); err != nil {
+		t.Fatalf("manifest is not YAML: %v", err)
+	}
+	if manifest.APIVersion != "v1" || manifest.Kind != "Secret" || manifest.Type != SecretTypeTLS {
+		t.Errorf("unexpected header %s %s %s", manifest.APIVersion, manifest.Kind, manifest.Type)
+	}
+	if manifest.Metadata.Name != "www-tls" || manifest.Metadata.Namespace != "web" || manifest.Metadata.Labels["app"] != "www" {
+		t.Errorf("unexpected metadata %+v", manifest.Metadata)
+	}
+
+	decode := func(key string) []byte {
+		data, err := base64.StdEncoding.DecodeString(manifest.Data[key])
+		if err != nil {
+			t.Fatalf("%s is not base64: %v", key, err)
+		}
+		return data
+	}
+
+	// tls.crt and tls.key form a key pair, as the kubelet and ingress controllers load them
+	pair, err := tls.X509KeyPair(decode(TLSCertKey), decode(TLSKeyKey))
+	if err != nil {
+		t.Fatalf("tls.crt and tls.key do not form a key pair: %v", err)
+	}
+	if pair.Leaf.Subject.CommonName != "www.example.com" {
+		t.Errorf("unexpected certificate %s", pair.Leaf.Subject)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(decode(CACertKey)) {
+		t.Fatal("ca.crt holds no certificate")
+	}
+	if _, err := pair.Leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: "www.example.com"}); err != nil {
+		t.Errorf("expected the certificate to verify against ca.crt: %v", err)
+	}
+}
+
+func TestTLSSecret_WithoutCA(t *testing.T) {
+	cert, key := testCertificate(t, "www.example.com", false, nil, nil)
+
+	out, err := TLSSecret(Metadata{Name: "www"}, []*x509.Certificate{cert}, key, nil)
+	if err != nil {
+		t.Fatalf("TLSSecret: %v", err)
+	}
+	if strings.Contains(string(out), CACertKey) || strings.Contains(string(out), "namespace") {
+		t.Errorf("expected no ca.crt and no namespace, got:\n%s", out)
+	}
+}
+
+func TestTLSSecret_Errors(t *testing.T) {
+	cert, key := testCertificate(t, "www.example.com", false, nil, nil)
+	certs := []*x509.Certificate{cert}
+
+	tests := []struct {
+		name  string
+		meta  Metadata
+		certs []*x509.Certificate
+		key   *ecdsa.PrivateKey
+		err   string
+	}{
+		{"no name", Metadata{}, certs, key, "object name is required"},
+		{"invalid name", Metadata{Name: "WWW_TLS"}, certs, key, "invalid name 'WWW_TLS'"},
+		{"invalid namespace", Metadata{Name: "www", Namespace: "-web"}, certs, key, "invalid namespace '-web'"},
+		{"no certificate", Metadata{Name: "www"}, nil, key, "a TLS Secret requires a certificate"},
+		{"no key", Metadata{Name: "www"}, certs, nil, "requires the certificate's private key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var k interface{}
+			if tt.key != nil {
+				k = tt.key
+			}
+			_, err := TLSSecret(tt.meta, tt.certs, k, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
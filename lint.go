@@ -0,0 +1,29 @@
+package go_yaml_to_x509
+
+import (
+	"github.com/rschoonheim/go-yaml-to-x509/lint"
+)
+
+// Lint resolves and builds a YAML profile and checks both the resolved spec and
+// the built certificate against rules, or lint.Default() when rules is nil
+func Lint(yamlData []byte, rules lint.RuleSet, opts ...Option) ([]lint.Finding, error) {
+	return LintWithOptions(yamlData, rules, NewOptions(opts...))
+}
+
+// LintWithOptions lints like Lint, using opts to control how the profile is resolved and built
+func LintWithOptions(yamlData []byte, rules lint.RuleSet, opts Options) ([]lint.Finding, error) {
+	_, spec, err := resolveProfile(yamlData, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := BuildCertificate(spec, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if rules == nil {
+		rules = lint.Default()
+	}
+	return rules.Run(lint.Target{Spec: spec, Certificate: cert}), nil
+}
@@ -0,0 +1,154 @@
+package lint
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"net"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// MaxServerValidity is the longest validity period the CA/Browser Forum allows
+// for TLS server certificates
+const MaxServerValidity = 398 * 24 * time.Hour
+
+// MinRSAKeySize is the smallest RSA modulus the CA/Browser Forum allows
+const MinRSAKeySize = 2048
+
+// CABFBaseline returns rules from the CA/Browser Forum Baseline Requirements
+func CABFBaseline() RuleSet {
+	return RuleSet{
+		NewRule("cabf/weak-signature-algorithm", Error, weakSignatureAlgorithm),
+		NewRule("cabf/dsa-key", Error, dsaKey),
+		NewRule("cabf/rsa-key-size", Error, rsaKeySize),
+		NewRule("cabf/leaf-is-ca", Error, leafIsCA),
+		NewRule("cabf/server-missing-san", Error, serverMissingSAN),
+		NewRule("cabf/max-validity", Error, maxValidity),
+		NewRule("cabf/common-name-not-in-san", Warning, commonNameNotInSAN),
+	}
+}
+
+// weakSignatureAlgorithm reports MD2, MD5 and SHA-1 signatures
+func weakSignatureAlgorithm(t Target) []Finding {
+	c := t.Certificate
+	if c == nil {
+		return nil
+	}
+
+	switch c.SignatureAlgorithm {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		return []Finding{finding("signature_algorithm", "%s uses a broken hash function", internal.FormatSignatureAlgorithm(c.SignatureAlgorithm))}
+	}
+	return nil
+}
+
+// dsaKey reports DSA keys and signatures, which are not allowed
+func dsaKey(t Target) []Finding {
+	c := t.Certificate
+	if c == nil {
+		return nil
+	}
+
+	var findings []Finding
+	if c.PublicKeyAlgorithm == x509.DSA {
+		findings = append(findings, finding("public_key_algorithm", "DSA keys are not allowed"))
+	}
+	if c.SignatureAlgorithm == x509.DSAWithSHA1 || c.SignatureAlgorithm == x509.DSAWithSHA256 {
+		findings = append(findings, finding("signature_algorithm", "DSA signatures are not allowed"))
+	}
+	return findings
+}
+
+// rsaKeySize reports RSA keys below MinRSAKeySize, for certificates that carry a public key
+func rsaKeySize(t Target) []Finding {
+	if c := t.Certificate; c != nil {
+		if key, ok := c.PublicKey.(*rsa.PublicKey); ok && key.N.BitLen() < MinRSAKeySize {
+			return []Finding{finding("public_key_algorithm", "RSA key of %d bits is below the %d bit minimum", key.N.BitLen(), MinRSAKeySize)}
+		}
+	}
+	return nil
+}
+
+// leafIsCA reports subscriber certificates, identified by an end-entity extended
+// key usage, that are marked as a CA
+func leafIsCA(t Target) []Finding {
+	c := t.Certificate
+	if c == nil || !c.IsCA {
+		return nil
+	}
+
+	for _, usage := range c.ExtKeyUsage {
+		switch usage {
+		case x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageCodeSigning, x509.ExtKeyUsageEmailProtection:
+			return []Finding{finding("is_ca", "leaf certificates with end-entity extended key usages must not be CAs")}
+		}
+	}
+	return nil
+}
+
+// serverMissingSAN reports TLS server certificates without DNS or IP subject alternative names
+func serverMissingSAN(t Target) []Finding {
+	c := t.Certificate
+	if c == nil || !isServerCertificate(c) {
+		return nil
+	}
+	if len(c.DNSNames) == 0 && len(c.IPAddresses) == 0 {
+		return []Finding{finding("dns_names", "TLS server certificates must list their names in dns_names or ip_addresses")}
+	}
+	return nil
+}
+
+// maxValidity reports TLS server certificates valid for longer than MaxServerValidity
+func maxValidity(t Target) []Finding {
+	c := t.Certificate
+	if c == nil || !isServerCertificate(c) || c.NotBefore.IsZero() || c.NotAfter.IsZero() {
+		return nil
+	}
+
+	field := "validity"
+	if t.Spec != nil && t.Spec.Validity == nil {
+		field = "not_after"
+	}
+	if validity := c.NotAfter.Sub(c.NotBefore); validity > MaxServerValidity {
+		return []Finding{finding(field, "%d days exceeds the %d day maximum for TLS server certificates",
+			int(validity.Hours()/24), int(MaxServerValidity.Hours()/24))}
+	}
+	return nil
+}
+
+// commonNameNotInSAN reports a TLS server common name that is not repeated in the SANs
+func commonNameNotInSAN(t Target) []Finding {
+	c := t.Certificate
+	if c == nil || !isServerCertificate(c) || c.Subject.CommonName == "" {
+		return nil
+	}
+
+	cn := c.Subject.CommonName
+	for _, name := range c.DNSNames {
+		if name == cn {
+			return nil
+		}
+	}
+	if ip := net.ParseIP(cn); ip != nil {
+		for _, addr := range c.IPAddresses {
+			if addr.Equal(ip) {
+				return nil
+			}
+		}
+	}
+	return []Finding{finding("subject.common_name", "common name '%s' is not listed in the subject alternative names", cn)}
+}
+
+// isServerCertificate reports whether c is a TLS server leaf certificate
+func isServerCertificate(c *x509.Certificate) bool {
+	if c.IsCA {
+		return false
+	}
+	for _, usage := range c.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageServerAuth {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package lint
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// serverCertificate returns a TLS server certificate that passes every baseline rule
+func serverCertificate() *x509.Certificate {
+	return &x509.Certificate{
+		Subject:            pkix.Name{CommonName: "www.example.com"},
+		NotBefore:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:           time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:           x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:        []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:           []string{"www.example.com"},
+		SignatureAlgorithm: x509.ECDSAWithSHA256,
+		PublicKeyAlgorithm: x509.ECDSA,
+	}
+}
+
+func TestCABFBaseline_ValidServerCertificate(t *testing.T) {
+	if findings := CABFBaseline().Run(Target{Certificate: serverCertificate()}); len(findings) != 0 {
+		t.Errorf("Expected no findings, got %v", findings)
+	}
+}
+
+func TestCABFBaseline_Violations(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *x509.Certificate)
+		rule   string
+		field  string
+	}{
+		{"sha1 signature", func(c *x509.Certificate) { c.SignatureAlgorithm = x509.SHA1WithRSA }, "cabf/weak-signature-algorithm", "signature_algorithm"},
+		{"md5 signature", func(c *x509.Certificate) { c.SignatureAlgorithm = x509.MD5WithRSA }, "cabf/weak-signature-algorithm", "signature_algorithm"},
+		{"dsa key", func(c *x509.Certificate) { c.PublicKeyAlgorithm = x509.DSA }, "cabf/dsa-key", "public_key_algorithm"},
+		{"leaf is ca", func(c *x509.Certificate) { c.IsCA = true }, "cabf/leaf-is-ca", "is_ca"},
+		{"missing san", func(c *x509.Certificate) { c.DNSNames = nil }, "cabf/server-missing-san", "dns_names"},
+		{"long validity", func(c *x509.Certificate) { c.NotAfter = c.NotBefore.Add(399 * 24 * time.Hour) }, "cabf/max-validity", "validity"},
+		{"common name not in san", func(c *x509.Certificate) { c.DNSNames = []string{"example.com"} }, "cabf/common-name-not-in-san", "subject.common_name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := serverCertificate()
+			tt.modify(cert)
+
+			findings := CABFBaseline().Run(Target{Certificate: cert})
+			for _, f := range findings {
+				if f.Rule == tt.rule {
+					if f.Field != tt.field {
+						t.Errorf("Expected field %s, got %s", tt.field, f.Field)
+					}
+					return
+				}
+			}
+			t.Errorf("Expected finding from %s, got %v", tt.rule, ruleNames(findings))
+		})
+	}
+}
+
+func TestCABFBaseline_ValidityFieldFollowsSpec(t *testing.T) {
+	cert := serverCertificate()
+	cert.NotAfter = cert.NotBefore.Add(2 * 365 * 24 * time.Hour)
+
+	findings := CABFBaseline().Run(Target{Spec: &internal.CertificateSpec{NotAfter: "2026-01-01T00:00:00Z"}, Certificate: cert})
+
+	if len(findings) != 1 || findings[0].Field != "not_after" || findings[0].Message != "730 days exceeds the 398 day maximum for TLS server certificates" {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+}
+
+func TestCABFBaseline_MaxValidityBoundary(t *testing.T) {
+	cert := serverCertificate()
+	cert.NotAfter = cert.NotBefore.Add(MaxServerValidity)
+
+	if findings := CABFBaseline().Run(Target{Certificate: cert}); hasRule(findings, "cabf/max-validity") {
+		t.Errorf("Expected exactly 398 days to be allowed, got %v", findings)
+	}
+}
+
+func TestCABFBaseline_CommonNameAsIP(t *testing.T) {
+	cert := serverCertificate()
+	cert.Subject.CommonName = "192.0.2.1"
+	cert.IPAddresses = []net.IP{net.ParseIP("192.0.2.1")}
+
+	if findings := CABFBaseline().Run(Target{Certificate: cert}); len(findings) != 0 {
+		t.Errorf("Expected IP common name listed in ip_addresses to pass, got %v", findings)
+	}
+}
+
+func TestCABFBaseline_RSAKeySize(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Skipf("1024-bit RSA keys are not supported: %v", err)
+	}
+	cert := serverCertificate()
+	cert.PublicKey = &key.PublicKey
+
+	findings := CABFBaseline().Run(Target{Certificate: cert})
+
+	if !hasRule(findings, "cabf/rsa-key-size") {
+		t.Errorf("Expected RSA key size finding, got %v", findings)
+	}
+}
@@ -0,0 +1,160 @@
+// Package lint checks certificate profiles against RFC 5280 and the CA/Browser
+// Forum Baseline Requirements before issuance. Rules inspect the resolved YAML
+// spec, the certificate built from it, or both.
+package lint
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// Severity ranks how serious a finding is
+type Severity int
+
+// Severities in increasing order; the zero value is unset
+const (
+	Notice Severity = iota + 1
+	Warning
+	Error
+)
+
+// String returns the lowercase name of the severity
+func (s Severity) String() string {
+	switch s {
+	case Notice:
+		return "notice"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// ParseSeverity parses "notice", "warning" or "error"
+func ParseSeverity(s string) (Severity, error) {
+	for _, severity := range []Severity{Notice, Warning, Error} {
+		if strings.EqualFold(s, severity.String()) {
+			return severity, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity '%s'", s)
+}
+
+// Target is the profile being linted. Spec is the resolved YAML spec and
+// Certificate the certificate built or issued from it; either may be nil, in
+// which case rules that need it are skipped.
+type Target struct {
+	Spec        *internal.CertificateSpec
+	Certificate *x509.Certificate
+}
+
+// Finding is a single problem reported by a rule
+type Finding struct {
+	Rule     string
+	Severity Severity
+	// Field is the YAML field path the finding refers to, e.g. "key_usage"
+	Field   string
+	Message string
+}
+
+// String returns the finding as "severity: field: message (rule)"
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Severity, f.Field, f.Message, f.Rule)
+}
+
+// Rule checks a target and reports its findings
+type Rule interface {
+	// Name identifies the rule, e.g. "cabf/max-validity"
+	Name() string
+	// Check returns the findings for the target
+	Check(t Target) []Finding
+}
+
+// funcRule is a Rule implemented by a function
+type funcRule struct {
+	name     string
+	severity Severity
+	check    func(t Target) []Finding
+}
+
+// NewRule returns a rule named name that runs check. Findings returned by check
+// without a rule name or severity are given the rule's name and severity.
+func NewRule(name string, severity Severity, check func(t Target) []Finding) Rule {
+	return &funcRule{name: name, severity: severity, check: check}
+}
+
+func (r *funcRule) Name() string {
+	return r.name
+}
+
+func (r *funcRule) Check(t Target) []Finding {
+	findings := r.check(t)
+	for i := range findings {
+		if findings[i].Rule == "" {
+			findings[i].Rule = r.name
+		}
+		if findings[i].Severity == 0 {
+			findings[i].Severity = r.severity
+		}
+	}
+	return findings
+}
+
+// finding is a shorthand for a finding that takes the rule's name and severity
+func finding(field, format string, args ...interface{}) Finding {
+	return Finding{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// RuleSet is an ordered list of rules
+type RuleSet []Rule
+
+// Run checks the target against every rule and returns the findings in rule order
+func (rs RuleSet) Run(t Target) []Finding {
+	var findings []Finding
+	for _, rule := range rs {
+		findings = append(findings, rule.Check(t)...)
+	}
+	return findings
+}
+
+// Without returns the rule set without the named rules
+func (rs RuleSet) Without(names ...string) RuleSet {
+	var result RuleSet
+	for _, rule := range rs {
+		skip := false
+		for _, name := range names {
+			if rule.Name() == name {
+				skip = true
+			}
+		}
+		if !skip {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+// Default returns the profile, RFC 5280 and CA/Browser Forum baseline rules
+func Default() RuleSet {
+	var rules RuleSet
+	rules = append(rules, Profile()...)
+	rules = append(rules, RFC5280()...)
+	rules = append(rules, CABFBaseline()...)
+	return rules
+}
+
+// MaxSeverity returns the highest severity among the findings, or zero when there are none
+func MaxSeverity(findings []Finding) Severity {
+	var max Severity
+	for _, f := range findings {
+		if f.Severity > max {
+			max = f.Severity
+		}
+	}
+	return max
+}
@@ -0,0 +1,101 @@
+package lint
+
+import (
+	"crypto/x509"
+	"testing"
+)
+
+// ruleNames returns the rule names of the findings
+func ruleNames(findings []Finding) []string {
+	names := make([]string, len(findings))
+	for i, f := range findings {
+		names[i] = f.Rule
+	}
+	return names
+}
+
+// hasRule reports whether any finding was reported by the named rule
+func hasRule(findings []Finding, name string) bool {
+	for _, f := range findings {
+		if f.Rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNewRule_FillsNameAndSeverity(t *testing.T) {
+	rule := NewRule("custom/no-ca", Warning, func(t Target) []Finding {
+		if t.Certificate.IsCA {
+			return []Finding{
+				finding("is_ca", "CAs are issued elsewhere"),
+				{Field: "is_ca", Severity: Notice, Message: "explicit severity"},
+			}
+		}
+		return nil
+	})
+
+	findings := rule.Check(Target{Certificate: &x509.Certificate{IsCA: true}})
+
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %v", findings)
+	}
+	if findings[0].Rule != "custom/no-ca" || findings[0].Severity != Warning {
+		t.Errorf("Expected rule name and severity to be filled in, got %+v", findings[0])
+	}
+	if findings[1].Severity != Notice {
+		t.Errorf("Expected explicit severity to be kept, got %v", findings[1].Severity)
+	}
+	if s := findings[0].String(); s != "warning: is_ca: CAs are issued elsewhere (custom/no-ca)" {
+		t.Errorf("Unexpected string: %s", s)
+	}
+}
+
+func TestRuleSet_Without(t *testing.T) {
+	rules := CABFBaseline().Without("cabf/max-validity", "cabf/dsa-key")
+
+	for _, rule := range rules {
+		if rule.Name() == "cabf/max-validity" || rule.Name() == "cabf/dsa-key" {
+			t.Errorf("Expected %s to be removed", rule.Name())
+		}
+	}
+	if len(rules) != len(CABFBaseline())-2 {
+		t.Errorf("Expected 2 rules to be removed, got %d rules", len(rules))
+	}
+}
+
+func TestDefault_RuleNamesUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, rule := range Default() {
+		if seen[rule.Name()] {
+			t.Errorf("Duplicate rule name %s", rule.Name())
+		}
+		seen[rule.Name()] = true
+	}
+}
+
+func TestDefault_NilTarget(t *testing.T) {
+	if findings := Default().Run(Target{}); len(findings) != 0 {
+		t.Errorf("Expected no findings for an empty target, got %v", findings)
+	}
+}
+
+func TestMaxSeverity(t *testing.T) {
+	if s := MaxSeverity(nil); s != 0 {
+		t.Errorf("Expected zero severity without findings, got %v", s)
+	}
+	if s := MaxSeverity([]Finding{{Severity: Warning}, {Severity: Error}, {Severity: Notice}}); s != Error {
+		t.Errorf("Expected error, got %v", s)
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, s := range []Severity{Notice, Warning, Error} {
+		if parsed, err := ParseSeverity(s.String()); err != nil || parsed != s {
+			t.Errorf("Expected %v, got %v, %v", s, parsed, err)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil || err.Error() != "unknown severity 'fatal'" {
+		t.Errorf("Expected unknown severity error, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package lint
+
+import (
+	"net"
+	"net/url"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+	"github.com/rschoonheim/go-yaml-to-x509/signer"
+)
+
+// Profile returns rules that check the YAML spec for values the builder would
+// silently ignore
+func Profile() RuleSet {
+	return RuleSet{
+		NewRule("profile/unknown-value", Error, unknownValues),
+		NewRule("profile/invalid-san", Error, invalidSANs),
+		NewRule(InsecureTestSeedRule, Error, insecureTestSeed),
+	}
+}
+
+// InsecureTestSeedRule is the name of the rule reporting insecure_test_seed
+const InsecureTestSeedRule = "profile/insecure-test-seed"
+
+// unknownValues reports key usages, algorithms, DN fields, signer types and key
+// derivation functions that are not recognised
+func unknownValues(t Target) []Finding {
+	s := t.Spec
+	if s == nil {
+		return nil
+	}
+
+	var findings []Finding
+	check := func(field string, values []string, known []string) {
+		for _, value := range values {
+			if !contains(known, value) {
+				findings = append(findings, finding(field, "unknown value '%s'", value))
+			}
+		}
+	}
+
+	check("key_usage", s.KeyUsage, internal.KeyUsages)
+	check("ext_key_usage", s.ExtKeyUsage, internal.ExtKeyUsages)
+	if s.SignatureAlgorithm != "" {
+		check("signature_algorithm", []string{s.SignatureAlgorithm}, internal.SignatureAlgorithms)
+	}
+	if s.PublicKeyAlgorithm != "" {
+		check("public_key_algorithm", []string{s.PublicKeyAlgorithm}, internal.PublicKeyAlgorithms)
+	}
+	if s.Signer != nil {
+		// Providers are registered at build time, e.g. pkcs11 with the pkcs11 build tag
+		check("signer.type", []string{s.Signer.Type}, signer.Types())
+	}
+	if s.KeyEncryption != nil && s.KeyEncryption.KDF != "" {
+		check("key_encryption.kdf", []string{s.KeyEncryption.KDF}, internal.KeyDerivationFunctions)
+	}
+	for _, name := range []struct {
+		field  string
+		values map[string]string
+	}{{"subject", s.Subject}, {"issuer", s.Issuer}} {
+		for key := range name.values {
+			if !contains(internal.DNFields, key) {
+				findings = append(findings, finding(name.field+"."+key, "unknown distinguished name field '%s'", key))
+			}
+		}
+	}
+
+	return findings
+}
+
+// invalidSANs reports IP addresses and URIs that cannot be parsed
+func invalidSANs(t Target) []Finding {
+	s := t.Spec
+	if s == nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, ip := range s.IPAddresses {
+		if net.ParseIP(ip) == nil {
+			findings = append(findings, finding("ip_addresses", "invalid IP address '%s'", ip))
+		}
+	}
+	for _, uri := range s.URIs {
+		if _, err := url.Parse(uri); err != nil {
+			findings = append(findings, finding("uris", "invalid URI '%s'", uri))
+		}
+	}
+	return findings
+}
+
+// insecureTestSeed reports specs that derive their keys from a test seed
+func insecureTestSeed(t Target) []Finding {
+	if t.Spec == nil || t.Spec.InsecureTestSeed == "" {
+		return nil
+	}
+	return []Finding{finding("insecure_test_seed", "keys derived from a test seed are not secret, use it for test fixtures only")}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+func TestProfile_UnknownValues(t *testing.T) {
+	spec := &internal.CertificateSpec{
+		Subject:            map[string]string{"common_name": "example.com", "organisation": "Example"},
+		KeyUsage:           []string{"digital_signature", "digitl_signature"},
+		ExtKeyUsage:        []string{"server_auth", "serverAuth"},
+		SignatureAlgorithm: "SHA256WithECDSA",
+		PublicKeyAlgorithm: "ECDSA",
+		Signer:             &internal.SignerSpec{Type: "vault"},
+		KeyEncryption:      &internal.KeyEncryptionSpec{KDF: "bcrypt"},
+	}
+
+	findings := Profile().Run(Target{Spec: spec})
+
+	expected := map[string]string{
+		"subject.organisation": "unknown distinguished name field 'organisation'",
+		"key_usage":            "unknown value 'digitl_signature'",
+		"ext_key_usage":        "unknown value 'serverAuth'",
+		"signature_algorithm":  "unknown value 'SHA256WithECDSA'",
+		"signer.type":          "unknown value 'vault'",
+		"key_encryption.kdf":   "unknown value 'bcrypt'",
+	}
+	if len(findings) != len(expected) {
+		t.Fatalf("Expected %d findings, got %v", len(expected), findings)
+	}
+	for _, f := range findings {
+		if expected[f.Field] != f.Message || f.Rule != "profile/unknown-value" || f.Severity != Error {
+			t.Errorf("Unexpected finding: %v", f)
+		}
+	}
+}
+
+func TestProfile_InvalidSANs(t *testing.T) {
+	spec := &internal.CertificateSpec{
+		IPAddresses: []string{"192.0.2.1", "192.0.2.300"},
+		URIs:        []string{"spiffe://example.com/api", "http://[::1"},
+	}
+
+	findings := Profile().Run(Target{Spec: spec})
+
+	if len(findings) != 2 || findings[0].Field != "ip_addresses" || findings[1].Field != "uris" {
+		t.Errorf("Expected invalid IP and URI findings, got %v", findings)
+	}
+}
+
+func TestProfile_InsecureTestSeed(t *testing.T) {
+	findings := Profile().Run(Target{Spec: &internal.CertificateSpec{InsecureTestSeed: "golden-v1"}})
+
+	if len(findings) != 1 || findings[0].Rule != InsecureTestSeedRule || findings[0].Field != "insecure_test_seed" || findings[0].Severity != Error {
+		t.Errorf("Expected an insecure_test_seed error, got %v", findings)
+	}
+	if findings := Profile().Run(Target{Spec: &internal.CertificateSpec{}}); len(findings) != 0 {
+		t.Errorf("Expected no findings without a seed, got %v", findings)
+	}
+}
@@ -0,0 +1,101 @@
+package lint
+
+import (
+	"crypto/x509"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// RFC5280 returns rules for certificate structure required by RFC 5280
+func RFC5280() RuleSet {
+	return RuleSet{
+		NewRule("rfc5280/ca-without-basic-constraints", Error, caWithoutBasicConstraints),
+		NewRule("rfc5280/ca-without-cert-sign", Error, caWithoutCertSign),
+		NewRule("rfc5280/cert-sign-without-ca", Error, certSignWithoutCA),
+		NewRule("rfc5280/path-len-without-ca", Error, pathLenWithoutCA),
+		NewRule("rfc5280/empty-subject-without-san", Error, emptySubjectWithoutSAN),
+		NewRule("rfc5280/serial-number", Error, serialNumber),
+		NewRule("rfc5280/validity-order", Error, validityOrder),
+		NewRule("rfc5280/encipher-only-without-key-agreement", Warning, encipherOnlyWithoutKeyAgreement),
+	}
+}
+
+// caWithoutBasicConstraints reports is_ca without the basic constraints extension,
+// which makes x509 drop the CA flag entirely
+func caWithoutBasicConstraints(t Target) []Finding {
+	if c := t.Certificate; c != nil && c.IsCA && !c.BasicConstraintsValid {
+		return []Finding{finding("is_ca", "is_ca requires basic_constraints_valid")}
+	}
+	return nil
+}
+
+// caWithoutCertSign reports CA certificates that cannot sign certificates (RFC 5280 4.2.1.3)
+func caWithoutCertSign(t Target) []Finding {
+	if c := t.Certificate; c != nil && c.IsCA && c.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return []Finding{finding("key_usage", "CA certificates must include cert_sign")}
+	}
+	return nil
+}
+
+// certSignWithoutCA reports cert_sign on certificates that are not CAs (RFC 5280 4.2.1.9)
+func certSignWithoutCA(t Target) []Finding {
+	if c := t.Certificate; c != nil && c.KeyUsage&x509.KeyUsageCertSign != 0 && !(c.IsCA && c.BasicConstraintsValid) {
+		return []Finding{finding("key_usage", "cert_sign requires basic constraints with is_ca")}
+	}
+	return nil
+}
+
+// pathLenWithoutCA reports a path length constraint on a certificate that is not a CA (RFC 5280 4.2.1.9)
+func pathLenWithoutCA(t Target) []Finding {
+	if c := t.Certificate; c != nil && !c.IsCA && (c.MaxPathLen > 0 || c.MaxPathLenZero) {
+		return []Finding{finding("max_path_len", "max_path_len is only allowed on CA certificates")}
+	}
+	return nil
+}
+
+// emptySubjectWithoutSAN reports certificates that identify no subject (RFC 5280 4.1.2.6)
+func emptySubjectWithoutSAN(t Target) []Finding {
+	c := t.Certificate
+	if c == nil || c.Subject.String() != "" {
+		return nil
+	}
+	if len(c.DNSNames)+len(c.EmailAddresses)+len(c.IPAddresses)+len(c.URIs) == 0 {
+		return []Finding{finding("subject", "an empty subject requires subject alternative names")}
+	}
+	return nil
+}
+
+// serialNumber reports serial numbers that are not positive or longer than 20 octets (RFC 5280 4.1.2.2)
+func serialNumber(t Target) []Finding {
+	if c := t.Certificate; c != nil && c.SerialNumber != nil {
+		if err := internal.ValidateSerialNumber(c.SerialNumber); err != nil {
+			return []Finding{finding("serial_number", "%v", err)}
+		}
+	}
+	return nil
+}
+
+// validityOrder reports validity periods that end before they start
+func validityOrder(t Target) []Finding {
+	c := t.Certificate
+	if c == nil || c.NotBefore.IsZero() || c.NotAfter.IsZero() {
+		return nil
+	}
+	if c.NotAfter.Before(c.NotBefore) {
+		return []Finding{finding("not_after", "not_after is before not_before")}
+	}
+	return nil
+}
+
+// encipherOnlyWithoutKeyAgreement reports encipher_only or decipher_only without
+// key_agreement, where they are undefined (RFC 5280 4.2.1.3)
+func encipherOnlyWithoutKeyAgreement(t Target) []Finding {
+	c := t.Certificate
+	if c == nil || c.KeyUsage&x509.KeyUsageKeyAgreement != 0 {
+		return nil
+	}
+	if c.KeyUsage&(x509.KeyUsageEncipherOnly|x509.KeyUsageDecipherOnly) != 0 {
+		return []Finding{finding("key_usage", "encipher_only and decipher_only require key_agreement")}
+	}
+	return nil
+}
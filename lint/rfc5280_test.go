@@ -0,0 +1,61 @@
+package lint
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestRFC5280_ValidCA(t *testing.T) {
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Example CA"},
+		NotBefore:             time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2034, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		MaxPathLen:            1,
+	}
+
+	if findings := RFC5280().Run(Target{Certificate: ca}); len(findings) != 0 {
+		t.Errorf("Expected no findings, got %v", findings)
+	}
+}
+
+func TestRFC5280_Violations(t *testing.T) {
+	tests := []struct {
+		name string
+		cert *x509.Certificate
+		rule string
+	}{
+		{"ca without basic constraints", &x509.Certificate{Subject: pkix.Name{CommonName: "a"}, IsCA: true, KeyUsage: x509.KeyUsageCertSign}, "rfc5280/ca-without-basic-constraints"},
+		{"ca without cert sign", &x509.Certificate{Subject: pkix.Name{CommonName: "a"}, IsCA: true, BasicConstraintsValid: true}, "rfc5280/ca-without-cert-sign"},
+		{"cert sign without ca", &x509.Certificate{Subject: pkix.Name{CommonName: "a"}, KeyUsage: x509.KeyUsageCertSign}, "rfc5280/cert-sign-without-ca"},
+		{"path length on leaf", &x509.Certificate{Subject: pkix.Name{CommonName: "a"}, MaxPathLen: 2, BasicConstraintsValid: true}, "rfc5280/path-len-without-ca"},
+		{"empty subject", &x509.Certificate{}, "rfc5280/empty-subject-without-san"},
+		{"negative serial", &x509.Certificate{Subject: pkix.Name{CommonName: "a"}, SerialNumber: big.NewInt(-1)}, "rfc5280/serial-number"},
+		{"long serial", &x509.Certificate{Subject: pkix.Name{CommonName: "a"}, SerialNumber: new(big.Int).Lsh(big.NewInt(1), 160)}, "rfc5280/serial-number"},
+		{"validity order", &x509.Certificate{Subject: pkix.Name{CommonName: "a"}, NotBefore: time.Unix(100, 0), NotAfter: time.Unix(50, 0)}, "rfc5280/validity-order"},
+		{"encipher only", &x509.Certificate{Subject: pkix.Name{CommonName: "a"}, KeyUsage: x509.KeyUsageEncipherOnly}, "rfc5280/encipher-only-without-key-agreement"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := RFC5280().Run(Target{Certificate: tt.cert})
+			if !hasRule(findings, tt.rule) {
+				t.Errorf("Expected finding from %s, got %v", tt.rule, ruleNames(findings))
+			}
+		})
+	}
+}
+
+func TestRFC5280_EmptySubjectWithSAN(t *testing.T) {
+	cert := &x509.Certificate{DNSNames: []string{"example.com"}}
+
+	if findings := RFC5280().Run(Target{Certificate: cert}); hasRule(findings, "rfc5280/empty-subject-without-san") {
+		t.Errorf("Expected SANs to satisfy an empty subject, got %v", findings)
+	}
+}
@@ -0,0 +1,82 @@
+package go_yaml_to_x509
+
+import (
+	"testing"
+
+	"github.com/rschoonheim/go-yaml-to-x509/lint"
+)
+
+func TestLint_CleanProfile(t *testing.T) {
+	findings, err := Lint([]byte(`
+subject:
+  common_name: "www.example.com"
+validity:
+  duration: "90d"
+key_usage: [digital_signature]
+ext_key_usage: [server_auth]
+dns_names: ["www.example.com"]
+signature_algorithm: ECDSAWithSHA256
+public_key_algorithm: ECDSA
+`), nil)
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Expected no findings, got %v", findings)
+	}
+}
+
+func TestLint_BadProfile(t *testing.T) {
+	findings, err := Lint([]byte(`
+segments:
+  tls:
+    ext_key_usage: [server_auth]
+    key_usage: [digitl_signature, cert_sign]
+merge: [tls]
+config:
+  subject:
+    common_name: "www.example.com"
+  validity:
+    duration: "2y"
+  is_ca: true
+  basic_constraints_valid: true
+  signature_algorithm: SHA1WithRSA
+`), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"profile/unknown-value", "cabf/weak-signature-algorithm", "cabf/leaf-is-ca"}
+	for _, rule := range expected {
+		found := false
+		for _, f := range findings {
+			if f.Rule == rule {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected finding from %s, got %v", rule, findings)
+		}
+	}
+	if lint.MaxSeverity(findings) != lint.Error {
+		t.Errorf("Expected error severity, got %v", lint.MaxSeverity(findings))
+	}
+}
+
+func TestLintWithOptions_RuleSet(t *testing.T) {
+	findings, err := LintWithOptions([]byte(`
+subject:
+  common_name: "${host}"
+ext_key_usage: [server_auth]
+validity:
+  duration: "2y"
+`), lint.RFC5280(), Options{Vars: map[string][]string{"host": {"www.example.com"}}})
+
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("Expected CA/Browser Forum rules to be skipped, got %v", findings)
+	}
+}
@@ -0,0 +1,253 @@
+// Package openssl converts between OpenSSL configuration files and YAML profiles.
+// Import reads the [req], distinguished name and X.509v3 extension sections of an
+// openssl.cnf into segments, so that legacy configurations can be migrated one
+// profile at a time, and Export renders a profile for pipelines that call openssl.
+package openssl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultSection is the name of the unnamed section at the top of a file
+const DefaultSection = "default"
+
+// Config is a parsed OpenSSL configuration file
+type Config struct {
+	sections map[string]*Section
+	order    []string
+}
+
+// Section is a named section of a configuration file. Entries keep the order and
+// repetitions of the file, as distinguished names and alternative names rely on both.
+type Section struct {
+	Name    string
+	Entries []Entry
+}
+
+// Entry is a name = value line of a section
+type Entry struct {
+	Name  string
+	Value string
+	Line  int
+}
+
+// Get returns the value of the last entry with the name, like OpenSSL
+func (s *Section) Get(name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	for i := len(s.Entries) - 1; i >= 0; i-- {
+		if s.Entries[i].Name == name {
+			return s.Entries[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// Section returns the named section, or nil when the file has none
+func (c *Config) Section(name string) *Section {
+	return c.sections[name]
+}
+
+// Sections returns the names of every section in file order, starting with the
+// default section
+func (c *Config) Sections() []string {
+	return c.order
+}
+
+// Parse reads an OpenSSL configuration file. Comments, quoting, escapes, line
+// continuations and $var, ${var}, $section::var and $ENV::var references are
+// handled like OpenSSL does; .include directives are rejected.
+func Parse(data []byte) (*Config, error) {
+	c := &Config{sections: make(map[string]*Section)}
+	section := c.section(DefaultSection)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		start := lineNumber
+
+		// A trailing unescaped backslash continues the line
+		for strings.HasSuffix(line, `\`) && !strings.HasSuffix(line, `\\`) && scanner.Scan() {
+			lineNumber++
+			line = line[:len(line)-1] + scanner.Text()
+		}
+
+		line = strings.TrimSpace(stripComment(line))
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "["):
+			end := strings.Index(line, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: missing ']' in section header", start)
+			}
+			name := strings.TrimSpace(line[1:end])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty section name", start)
+			}
+			section = c.section(name)
+			continue
+		case strings.HasPrefix(line, ".include"):
+			return nil, fmt.Errorf("line %d: '.include' is not supported, merge the included file first", start)
+		case strings.HasPrefix(line, ".pragma"):
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("line %d: expected name = value, got '%s'", start, line)
+		}
+
+		value, err := c.expand(section, strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", start, err)
+		}
+		section.Entries = append(section.Entries, Entry{Name: name, Value: value, Line: start})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *Config) section(name string) *Section {
+	if s, ok := c.sections[name]; ok {
+		return s
+	}
+	s := &Section{Name: name}
+	c.sections[name] = s
+	c.order = append(c.order, name)
+	return s
+}
+
+// stripComment removes a '#' comment that is neither escaped nor quoted
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch ch := line[i]; {
+		case ch == '\\':
+			i++
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// expand removes quotes and escapes from a value and substitutes variable references
+func (c *Config) expand(section *Section, value string) (string, error) {
+	var out strings.Builder
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		ch := value[i]
+		switch {
+		case ch == '\\' && i+1 < len(value):
+			i++
+			switch value[i] {
+			case 'n':
+				out.WriteByte('\n')
+			case 'r':
+				out.WriteByte('\r')
+			case 't':
+				out.WriteByte('\t')
+			default:
+				out.WriteByte(value[i])
+			}
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else {
+				out.WriteByte(ch)
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '$':
+			ref, n := variableReference(value[i+1:])
+			if ref == "" {
+				out.WriteByte(ch)
+				continue
+			}
+			resolved, err := c.lookup(section, ref)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(resolved)
+			i += n
+		default:
+			out.WriteByte(ch)
+		}
+	}
+	if quote != 0 {
+		return "", fmt.Errorf("unterminated quote in '%s'", value)
+	}
+	return out.String(), nil
+}
+
+// variableReference returns the reference following a '$' and the number of bytes it
+// spans, e.g. "section::name" for "${section::name}"
+func variableReference(s string) (string, int) {
+	if strings.HasPrefix(s, "{") || strings.HasPrefix(s, "(") {
+		closing := "}"
+		if s[0] == '(' {
+			closing = ")"
+		}
+		end := strings.Index(s, closing)
+		if end < 0 {
+			return "", 0
+		}
+		return s[1:end], end + 1
+	}
+
+	n := 0
+	for n < len(s) && (isNameByte(s[n]) || s[n] == ':' && strings.HasPrefix(s[n:], "::")) {
+		if s[n] == ':' {
+			n++
+		}
+		n++
+	}
+	return s[:n], n
+}
+
+func isNameByte(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_' || ch == '.'
+}
+
+// lookup resolves a variable in the current section, then the default section. The
+// ENV section is the process environment.
+func (c *Config) lookup(section *Section, ref string) (string, error) {
+	if sectionName, name, ok := strings.Cut(ref, "::"); ok {
+		if sectionName == "ENV" {
+			if value, ok := os.LookupEnv(name); ok {
+				return value, nil
+			}
+			return "", fmt.Errorf("variable '%s' has no value", ref)
+		}
+		if value, ok := c.sections[sectionName].Get(name); ok {
+			return value, nil
+		}
+		return "", fmt.Errorf("variable '%s' has no value", ref)
+	}
+
+	if value, ok := section.Get(ref); ok {
+		return value, nil
+	}
+	if value, ok := c.sections[DefaultSection].Get(ref); ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("variable '%s' has no value", ref)
+}
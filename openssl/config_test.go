@@ -0,0 +1,95 @@
+package openssl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Setenv("OPENSSL_TEST_ORG", "Example B.V.")
+
+	c, err := Parse([]byte(`
+# Global settings
+dir = /etc/pki
+name = www
+
+[ req ]
+default_bits = 2048   # inline comment
+prompt = no
+
+[dn]
+CN = ${name}.example.com
+O = $ENV::OPENSSL_TEST_ORG
+OU = "Web # Team"
+L = 'Den Haag'
+street = Main\
+ Street 1
+path = $dir/$req::default_bits
+escaped = 100\% \$name
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if !reflect.DeepEqual(c.Sections(), []string{DefaultSection, "req", "dn"}) {
+		t.Errorf("unexpected sections %v", c.Sections())
+	}
+	if bits, _ := c.Section("req").Get("default_bits"); bits != "2048" {
+		t.Errorf("expected default_bits 2048, got %q", bits)
+	}
+
+	expected := map[string]string{
+		"CN":      "www.example.com",
+		"O":       "Example B.V.",
+		"OU":      "Web # Team",
+		"L":       "Den Haag",
+		"street":  "Main Street 1",
+		"path":    "/etc/pki/2048",
+		"escaped": "100% $name",
+	}
+	for name, value := range expected {
+		if got, ok := c.Section("dn").Get(name); !ok || got != value {
+			t.Errorf("%s: expected %q, got %q", name, value, got)
+		}
+	}
+	if c.Section("missing") != nil {
+		t.Error("expected no section for a missing name")
+	}
+}
+
+func TestSection_GetReturnsLastValue(t *testing.T) {
+	c, err := Parse([]byte("[s]\nkey = first\nkey = second\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.Section("s")
+	if value, _ := s.Get("key"); value != "second" || len(s.Entries) != 2 {
+		t.Errorf("expected both entries and the last value, got %q from %v", value, s.Entries)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"unclosed section", "[req\n", "line 1: missing ']' in section header"},
+		{"empty section", "[ ]\n", "line 1: empty section name"},
+		{"no value", "[req]\nprompt\n", "line 2: expected name = value, got 'prompt'"},
+		{"undefined variable", "a = $missing\n", "line 1: variable 'missing' has no value"},
+		{"undefined section variable", "a = ${req::missing}\n", "variable 'req::missing' has no value"},
+		{"unterminated quote", "a = \"open\n", "unterminated quote"},
+		{"include", ".include /etc/ssl/extra.cnf\n", "'.include' is not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package openssl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// Section names of an exported configuration
+const (
+	ExtensionsSection        = "v3_ext"
+	DistinguishedNameSection = "req_distinguished_name"
+	AltNamesSection          = "alt_names"
+)
+
+// exportKeyUsages maps key usages to OpenSSL's keyUsage names
+var exportKeyUsages = map[string]string{
+	internal.KeyUsageDigitalSignature:  "digitalSignature",
+	internal.KeyUsageContentCommitment: "nonRepudiation",
+	internal.KeyUsageKeyEncipherment:   "keyEncipherment",
+	internal.KeyUsageDataEncipherment:  "dataEncipherment",
+	internal.KeyUsageKeyAgreement:      "keyAgreement",
+	internal.KeyUsageCertSign:          "keyCertSign",
+	internal.KeyUsageCRLSign:           "cRLSign",
+	internal.KeyUsageEncipherOnly:      "encipherOnly",
+	internal.KeyUsageDecipherOnly:      "decipherOnly",
+}
+
+// exportExtKeyUsages maps extended key usages to OpenSSL's extendedKeyUsage names, or
+// the OID where OpenSSL has no name
+var exportExtKeyUsages = map[string]string{
+	internal.ExtKeyUsageAny:                            "anyExtendedKeyUsage",
+	internal.ExtKeyUsageServerAuth:                     "serverAuth",
+	internal.ExtKeyUsageClientAuth:                     "clientAuth",
+	internal.ExtKeyUsageCodeSigning:                    "codeSigning",
+	internal.ExtKeyUsageEmailProtection:                "emailProtection",
+	internal.ExtKeyUsageIPSECEndSystem:                 "ipsecEndSystem",
+	internal.ExtKeyUsageIPSECTunnel:                    "ipsecTunnel",
+	internal.ExtKeyUsageIPSECUser:                      "ipsecUser",
+	internal.ExtKeyUsageTimeStamping:                   "timeStamping",
+	internal.ExtKeyUsageOCSPSigning:                    "OCSPSigning",
+	internal.ExtKeyUsageMicrosoftServerGatedCrypto:     "msSGC",
+	internal.ExtKeyUsageNetscapeServerGatedCrypto:      "nsSGC",
+	internal.ExtKeyUsageMicrosoftCommercialCodeSigning: "msCodeCom",
+	internal.ExtKeyUsageMicrosoftKernelCodeSigning:     "1.3.6.1.4.1.311.61.1.1",
+}
+
+// exportDigests maps signature algorithms to a default_md digest; Ed25519 has none
+var exportDigests = map[string]string{
+	internal.SigAlgMD5WithRSA:      "md5",
+	internal.SigAlgSHA1WithRSA:     "sha1",
+	internal.SigAlgSHA256WithRSA:   "sha256",
+	internal.SigAlgSHA384WithRSA:   "sha384",
+	internal.SigAlgSHA512WithRSA:   "sha512",
+	internal.SigAlgECDSAWithSHA1:   "sha1",
+	internal.SigAlgECDSAWithSHA256: "sha256",
+	internal.SigAlgECDSAWithSHA384: "sha384",
+	internal.SigAlgECDSAWithSHA512: "sha512",
+	internal.SigAlgPureEd25519:     "",
+}
+
+// exportDN lists the subject fields in the order OpenSSL writes them, with their names
+var exportDN = []struct{ field, name string }{
+	{internal.DNCountry, "C"},
+	{internal.DNProvince, "ST"},
+	{internal.DNLocality, "L"},
+	{internal.DNStreetAddress, "street"},
+	{internal.DNPostalCode, "postalCode"},
+	{internal.DNOrganization, "O"},
+	{internal.DNOrganizationalUnit, "OU"},
+	{internal.DNCommonName, "CN"},
+	{internal.DNSerialNumber, "serialNumber"},
+}
+
+// Export renders a resolved CertificateSpec as an OpenSSL configuration for
+// 'openssl req', with the subject, key settings and the v3_ext extension section
+// that both x509_extensions and req_extensions name:
+//
+//	openssl req -new -x509 -config www.cnf -key www-key.pem -days 90 -out www.pem
+//
+// The validity period, serial number and key type are command-line options in
+// OpenSSL, so they and other fields without a configuration setting are returned as
+// Unsupported, with the option to use where there is one.
+func Export(spec *internal.CertificateSpec) ([]byte, []Unsupported, error) {
+	var unsupported []Unsupported
+	skip := func(field, reason string) {
+		unsupported = append(unsupported, Unsupported{Field: field, Reason: reason})
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "[ req ]\n")
+	fmt.Fprintf(&out, "prompt             = no\n")
+	fmt.Fprintf(&out, "utf8               = yes\n")
+	fmt.Fprintf(&out, "string_mask        = utf8only\n")
+	fmt.Fprintf(&out, "distinguished_name = %s\n", DistinguishedNameSection)
+	fmt.Fprintf(&out, "x509_extensions    = %s\n", ExtensionsSection)
+	fmt.Fprintf(&out, "req_extensions     = %s\n", ExtensionsSection)
+
+	switch spec.PublicKeyAlgorithm {
+	case internal.PubKeyAlgRSA:
+		fmt.Fprintf(&out, "default_bits       = 2048\n")
+	case internal.PubKeyAlgECDSA, "":
+		skip("public_key_algorithm", "generate the key with 'openssl genpkey -algorithm EC -pkeyopt ec_paramgen_curve:P-256' and pass it with -key")
+	case internal.PubKeyAlgEd25519:
+		skip("public_key_algorithm", "generate the key with 'openssl genpkey -algorithm ED25519' and pass it with -key")
+	default:
+		skip("public_key_algorithm", fmt.Sprintf("'%s' keys cannot be generated", spec.PublicKeyAlgorithm))
+	}
+	if spec.SignatureAlgorithm != "" {
+		digest, ok := exportDigests[spec.SignatureAlgorithm]
+		switch {
+		case !ok:
+			skip("signature_algorithm", fmt.Sprintf("'%s' has no default_md, pass the digest and -sigopt options on the command line", spec.SignatureAlgorithm))
+		case digest != "":
+			fmt.Fprintf(&out, "default_md         = %s\n", digest)
+		}
+	}
+
+	fmt.Fprintf(&out, "\n[ %s ]\n", DistinguishedNameSection)
+	for _, dn := range exportDN {
+		if value, ok := spec.Subject[dn.field]; ok && value != "" {
+			fmt.Fprintf(&out, "%s = %s\n", dn.name, escape(value))
+		}
+	}
+
+	fmt.Fprintf(&out, "\n[ %s ]\n", ExtensionsSection)
+	fmt.Fprintf(&out, "subjectKeyIdentifier = hash\n")
+	// Certificates built from the profile only carry basic constraints when they are valid
+	if spec.BasicConstraintsValid {
+		constraints := "critical, CA:FALSE"
+		if spec.IsCA {
+			constraints = "critical, CA:TRUE"
+			if spec.MaxPathLen > 0 {
+				constraints += fmt.Sprintf(", pathlen:%d", spec.MaxPathLen)
+			} else if spec.MaxPathLenZero {
+				constraints += ", pathlen:0"
+			}
+		}
+		fmt.Fprintf(&out, "basicConstraints = %s\n", constraints)
+	} else if spec.IsCA {
+		skip("is_ca", "has no effect without basic_constraints_valid, so basicConstraints is left out")
+	}
+	if names := exportNames("key_usage", spec.KeyUsage, exportKeyUsages, skip); len(names) > 0 {
+		fmt.Fprintf(&out, "keyUsage = critical, %s\n", strings.Join(names, ", "))
+	}
+	if names := exportNames("ext_key_usage", spec.ExtKeyUsage, exportExtKeyUsages, skip); len(names) > 0 {
+		fmt.Fprintf(&out, "extendedKeyUsage = %s\n", strings.Join(names, ", "))
+	}
+
+	var altNames []string
+	for _, san := range []struct {
+		kind  string
+		names []string
+	}{
+		{"DNS", spec.DNSNames},
+		{"IP", spec.IPAddresses},
+		{"email", spec.EmailAddresses},
+		{"URI", spec.URIs},
+	} {
+		for i, name := range san.names {
+			altNames = append(altNames, fmt.Sprintf("%s.%d = %s\n", san.kind, i+1, escape(name)))
+		}
+	}
+	if len(altNames) > 0 {
+		fmt.Fprintf(&out, "subjectAltName = @%s\n", AltNamesSection)
+		fmt.Fprintf(&out, "\n[ %s ]\n", AltNamesSection)
+		for _, name := range altNames {
+			out.WriteString(name)
+		}
+	}
+
+	if err := exportUnsupported(spec, skip); err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(unsupported, func(i, j int) bool {
+		return unsupported[i].Field < unsupported[j].Field
+	})
+	return out.Bytes(), unsupported, nil
+}
+
+// exportNames maps values to OpenSSL names, reporting values without one
+func exportNames(field string, values []string, names map[string]string, skip func(field, reason string)) []string {
+	var out []string
+	for _, value := range values {
+		name, ok := names[value]
+		if !ok {
+			skip(field, fmt.Sprintf("unknown value '%s'", value))
+			continue
+		}
+		out = append(out, name)
+	}
+	return out
+}
+
+// exportUnsupported reports the fields OpenSSL takes as command-line options or not at all
+func exportUnsupported(spec *internal.CertificateSpec, skip func(field, reason string)) error {
+	if spec.SerialNumber != "" {
+		skip("serial_number", fmt.Sprintf("pass -set_serial %s", spec.SerialNumber))
+	}
+	if v := spec.Validity; v != nil && v.Duration != "" {
+		d, err := internal.ParseDuration(v.Duration)
+		if err != nil {
+			return fmt.Errorf("validity.duration: %w", err)
+		}
+		if d%(24*time.Hour) == 0 {
+			skip("validity", fmt.Sprintf("pass -days %d", d/(24*time.Hour)))
+		} else {
+			skip("validity", fmt.Sprintf("openssl req takes whole days with -days, '%s' is not", v.Duration))
+		}
+	}
+	if spec.NotBefore != "" || spec.NotAfter != "" {
+		skip("not_before", "openssl req starts the validity period when it signs, pass -days")
+	}
+	if len(spec.Issuer) > 0 {
+		skip("issuer", "the issuer name comes from the signing certificate, -CA with 'openssl x509'")
+	}
+	if spec.PublicKey != "" {
+		skip("public_key", "pass the key with -key, or sign the request with 'openssl x509 -req'")
+	}
+	if spec.Signer != nil {
+		skip("signer", "pass the signing key with -key or -CAkey")
+	}
+	if spec.KeyEncryption != nil {
+		skip("key_encryption", "encrypt the key with 'openssl pkcs8 -topk8 -v2 aes-256-cbc'")
+	}
+	if spec.InsecureTestSeed != "" {
+		skip("insecure_test_seed", "OpenSSL keys and serial numbers are random")
+	}
+	return nil
+}
+
+// escape protects the characters a configuration value treats specially
+func escape(value string) string {
+	var out strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch ch := value[i]; ch {
+		case '\\', '#', '$', '"', '\'':
+			out.WriteByte('\\')
+			out.WriteByte(ch)
+		default:
+			out.WriteByte(ch)
+		}
+	}
+	// Leading and trailing spaces are trimmed unless quoted
+	if s := out.String(); s != strings.TrimSpace(s) {
+		return `"` + s + `"`
+	}
+	return out.String()
+}
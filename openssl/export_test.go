@@ -0,0 +1,227 @@
+package openssl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+	"github.com/rschoonheim/go-yaml-to-x509/keyfile"
+)
+
+func TestExport(t *testing.T) {
+	spec := &internal.CertificateSpec{
+		SerialNumber: "1000",
+		Subject: map[string]string{
+			internal.DNCommonName:   "www.example.com",
+			internal.DNOrganization: "Example #1",
+			internal.DNCountry:      "NL",
+		},
+		Validity:           &internal.ValiditySpec{Duration: "90d"},
+		KeyUsage:           []string{internal.KeyUsageDigitalSignature, internal.KeyUsageKeyEncipherment},
+		ExtKeyUsage:        []string{internal.ExtKeyUsageServerAuth},
+		DNSNames:           []string{"www.example.com", "example.com"},
+		IPAddresses:        []string{"192.0.2.1"},
+		PublicKeyAlgorithm: internal.PubKeyAlgRSA,
+		SignatureAlgorithm: internal.SigAlgSHA256WithRSA,
+	}
+
+	out, unsupported, err := Export(spec)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	for _, expected := range []string{
+		"default_bits       = 2048\n",
+		"default_md         = sha256\n",
+		"C = NL\nO = Example \\#1\nCN = www.example.com\n",
+		"keyUsage = critical, digitalSignature, keyEncipherment\n",
+		"extendedKeyUsage = serverAuth\n",
+		"subjectAltName = @alt_names\n",
+		"DNS.1 = www.example.com\nDNS.2 = example.com\nIP.1 = 192.0.2.1\n",
+	} {
+		if !strings.Contains(string(out), expected) {
+			t.Errorf("expected the config to contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	var reasons []string
+	for _, u := range unsupported {
+		reasons = append(reasons, u.String())
+	}
+	expected := []string{"serial_number: pass -set_serial 1000", "validity: pass -days 90"}
+	if !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("expected %v, got %v", expected, reasons)
+	}
+
+	// The exported configuration imports to the same profile
+	doc, _, err := Import(out)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	imported := internal.MergeSpecs(doc.Segments[ReqSegment], doc.Segments[ExtensionsSection])
+	if !reflect.DeepEqual(imported.Subject, spec.Subject) || !reflect.DeepEqual(imported.DNSNames, spec.DNSNames) ||
+		imported.SignatureAlgorithm != spec.SignatureAlgorithm {
+		t.Errorf("unexpected import of the export: %+v", imported)
+	}
+}
+
+func TestExport_CAWithoutBasicConstraints(t *testing.T) {
+	out, unsupported, err := Export(&internal.CertificateSpec{Subject: map[string]string{internal.DNCommonName: "ca"}, IsCA: true})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if strings.Contains(string(out), "basicConstraints") {
+		t.Errorf("expected no basicConstraints without basic_constraints_valid, got:\n%s", out)
+	}
+	found := false
+	for _, u := range unsupported {
+		found = found || u.Field == "is_ca"
+	}
+	if !found {
+		t.Errorf("expected is_ca to be unsupported, got %v", unsupported)
+	}
+}
+
+// TestExport_Usages checks that every key usage and extended key usage survives an
+// export and import
+func TestExport_Usages(t *testing.T) {
+	for _, spec := range usageSpecs() {
+		out, unsupported, err := Export(spec)
+		if err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+		if len(unsupported) != 1 || unsupported[0].Field != "public_key_algorithm" {
+			t.Errorf("%v%v: unexpected unsupported fields %v", spec.KeyUsage, spec.ExtKeyUsage, unsupported)
+		}
+
+		doc, _, err := Import(out)
+		if err != nil {
+			t.Fatalf("Import:\n%s\n%v", out, err)
+		}
+		ext := doc.Segments[ExtensionsSection]
+		if !reflect.DeepEqual(ext.KeyUsage, spec.KeyUsage) || !reflect.DeepEqual(ext.ExtKeyUsage, spec.ExtKeyUsage) {
+			t.Errorf("expected %v%v, imported %v%v", spec.KeyUsage, spec.ExtKeyUsage, ext.KeyUsage, ext.ExtKeyUsage)
+		}
+	}
+}
+
+// TestExport_OpenSSL signs every exported usage with the openssl command and checks
+// that the certificate's fields match the one Go issues from the same profile
+func TestExport_OpenSSL(t *testing.T) {
+	if _, err := exec.LookPath("openssl"); err != nil {
+		t.Skip("openssl not found in PATH")
+	}
+
+	dir := t.TempDir()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM, err := keyfile.Marshal(key, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without basic_constraints_valid, Go leaves the extension out despite is_ca
+	caOnly := &internal.CertificateSpec{
+		Subject:  map[string]string{internal.DNCommonName: "ca.example.com"},
+		KeyUsage: []string{internal.KeyUsageCertSign},
+		IsCA:     true,
+	}
+
+	for _, spec := range append(usageSpecs(), caOnly) {
+		name := strings.Join(append(append([]string{}, spec.KeyUsage...), spec.ExtKeyUsage...), ",")
+		if !spec.BasicConstraintsValid {
+			name += ",without_basic_constraints"
+		}
+		t.Run(name, func(t *testing.T) {
+			config, _, err := Export(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			configFile := filepath.Join(dir, "cert.cnf")
+			certFile := filepath.Join(dir, "cert.pem")
+			if err := os.WriteFile(configFile, config, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cmd := exec.Command("openssl", "req", "-new", "-x509", "-config", configFile, "-key", keyFile, "-days", "1", "-out", certFile)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("openssl req:\n%s\n%s", config, out)
+			}
+			data, err := os.ReadFile(certFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			block, _ := pem.Decode(data)
+			fromOpenSSL, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			template, err := yamltox509.BuildCertificate(spec, yamltox509.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			issued, err := yamltox509.Issue(yamltox509.IssueRequest{Template: template, Signer: key}, yamltox509.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			fromGo := issued.Certificate
+
+			if fromOpenSSL.KeyUsage != fromGo.KeyUsage {
+				t.Errorf("key usage: openssl %v, go %v", fromOpenSSL.KeyUsage, fromGo.KeyUsage)
+			}
+			if !reflect.DeepEqual(fromOpenSSL.ExtKeyUsage, fromGo.ExtKeyUsage) || len(fromOpenSSL.UnknownExtKeyUsage) != 0 {
+				t.Errorf("ext key usage: openssl %v %v, go %v", fromOpenSSL.ExtKeyUsage, fromOpenSSL.UnknownExtKeyUsage, fromGo.ExtKeyUsage)
+			}
+			if fromOpenSSL.BasicConstraintsValid != fromGo.BasicConstraintsValid || fromOpenSSL.IsCA != fromGo.IsCA ||
+				fromOpenSSL.MaxPathLen != fromGo.MaxPathLen || fromOpenSSL.MaxPathLenZero != fromGo.MaxPathLenZero {
+				t.Errorf("basic constraints: openssl %v/%v/%d, go %v/%v/%d", fromOpenSSL.BasicConstraintsValid, fromOpenSSL.IsCA, fromOpenSSL.MaxPathLen,
+					fromGo.BasicConstraintsValid, fromGo.IsCA, fromGo.MaxPathLen)
+			}
+			if fromOpenSSL.Subject.String() != fromGo.Subject.String() || !reflect.DeepEqual(fromOpenSSL.DNSNames, fromGo.DNSNames) {
+				t.Errorf("names: openssl %s %v, go %s %v", fromOpenSSL.Subject, fromOpenSSL.DNSNames, fromGo.Subject, fromGo.DNSNames)
+			}
+		})
+	}
+}
+
+// usageSpecs returns a profile for every key usage and every extended key usage of
+// internal/constants.go, alternating CAs and leaves
+func usageSpecs() []*internal.CertificateSpec {
+	var specs []*internal.CertificateSpec
+	add := func(keyUsage, extKeyUsage []string) {
+		spec := &internal.CertificateSpec{
+			Subject:               map[string]string{internal.DNCommonName: "usage.example.com", internal.DNOrganization: "Example"},
+			DNSNames:              []string{"usage.example.com"},
+			KeyUsage:              keyUsage,
+			ExtKeyUsage:           extKeyUsage,
+			BasicConstraintsValid: true,
+		}
+		if len(specs)%2 == 0 {
+			spec.IsCA = true
+			spec.MaxPathLenZero = true
+		}
+		specs = append(specs, spec)
+	}
+	for _, usage := range internal.KeyUsages {
+		add([]string{usage}, nil)
+	}
+	for _, usage := range internal.ExtKeyUsages {
+		add(nil, []string{usage})
+	}
+	return specs
+}
@@ -0,0 +1,460 @@
+package openssl
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// ReqSegment is the segment holding the subject and key settings of the [req] section
+const ReqSegment = "req"
+
+// Unsupported is a setting of an OpenSSL configuration that has no YAML equivalent,
+// left out of the imported document. Field is "section.name", or the section alone.
+type Unsupported = internal.Unsupported
+
+// dnFields maps OpenSSL's short and long attribute names to subject fields
+var dnFields = map[string]string{
+	"CN":                     internal.DNCommonName,
+	"commonName":             internal.DNCommonName,
+	"C":                      internal.DNCountry,
+	"countryName":            internal.DNCountry,
+	"O":                      internal.DNOrganization,
+	"organizationName":       internal.DNOrganization,
+	"OU":                     internal.DNOrganizationalUnit,
+	"organizationalUnitName": internal.DNOrganizationalUnit,
+	"L":                      internal.DNLocality,
+	"localityName":           internal.DNLocality,
+	"ST":                     internal.DNProvince,
+	"stateOrProvinceName":    internal.DNProvince,
+	"street":                 internal.DNStreetAddress,
+	"streetAddress":          internal.DNStreetAddress,
+	"postalCode":             internal.DNPostalCode,
+	"serialNumber":           internal.DNSerialNumber,
+}
+
+// keyUsages maps OpenSSL's keyUsage names to key usages
+var keyUsages = map[string]string{
+	"digitalSignature": internal.KeyUsageDigitalSignature,
+	"nonRepudiation":   internal.KeyUsageContentCommitment,
+	"keyEncipherment":  internal.KeyUsageKeyEncipherment,
+	"dataEncipherment": internal.KeyUsageDataEncipherment,
+	"keyAgreement":     internal.KeyUsageKeyAgreement,
+	"keyCertSign":      internal.KeyUsageCertSign,
+	"cRLSign":          internal.KeyUsageCRLSign,
+	"encipherOnly":     internal.KeyUsageEncipherOnly,
+	"decipherOnly":     internal.KeyUsageDecipherOnly,
+}
+
+// extKeyUsages maps OpenSSL's extendedKeyUsage short names and OIDs to extended key usages
+var extKeyUsages = map[string]string{
+	"anyExtendedKeyUsage":    internal.ExtKeyUsageAny,
+	"2.5.29.37.0":            internal.ExtKeyUsageAny,
+	"serverAuth":             internal.ExtKeyUsageServerAuth,
+	"1.3.6.1.5.5.7.3.1":      internal.ExtKeyUsageServerAuth,
+	"clientAuth":             internal.ExtKeyUsageClientAuth,
+	"1.3.6.1.5.5.7.3.2":      internal.ExtKeyUsageClientAuth,
+	"codeSigning":            internal.ExtKeyUsageCodeSigning,
+	"1.3.6.1.5.5.7.3.3":      internal.ExtKeyUsageCodeSigning,
+	"emailProtection":        internal.ExtKeyUsageEmailProtection,
+	"1.3.6.1.5.5.7.3.4":      internal.ExtKeyUsageEmailProtection,
+	"ipsecEndSystem":         internal.ExtKeyUsageIPSECEndSystem,
+	"1.3.6.1.5.5.7.3.5":      internal.ExtKeyUsageIPSECEndSystem,
+	"ipsecTunnel":            internal.ExtKeyUsageIPSECTunnel,
+	"1.3.6.1.5.5.7.3.6":      internal.ExtKeyUsageIPSECTunnel,
+	"ipsecUser":              internal.ExtKeyUsageIPSECUser,
+	"1.3.6.1.5.5.7.3.7":      internal.ExtKeyUsageIPSECUser,
+	"timeStamping":           internal.ExtKeyUsageTimeStamping,
+	"1.3.6.1.5.5.7.3.8":      internal.ExtKeyUsageTimeStamping,
+	"OCSPSigning":            internal.ExtKeyUsageOCSPSigning,
+	"1.3.6.1.5.5.7.3.9":      internal.ExtKeyUsageOCSPSigning,
+	"msSGC":                  internal.ExtKeyUsageMicrosoftServerGatedCrypto,
+	"1.3.6.1.4.1.311.10.3.3": internal.ExtKeyUsageMicrosoftServerGatedCrypto,
+	"nsSGC":                  internal.ExtKeyUsageNetscapeServerGatedCrypto,
+	"2.16.840.1.113730.4.1":  internal.ExtKeyUsageNetscapeServerGatedCrypto,
+	"msCodeCom":              internal.ExtKeyUsageMicrosoftCommercialCodeSigning,
+	"1.3.6.1.4.1.311.2.1.22": internal.ExtKeyUsageMicrosoftCommercialCodeSigning,
+	"msKernelCodeSigning":    internal.ExtKeyUsageMicrosoftKernelCodeSigning,
+	"1.3.6.1.4.1.311.61.1.1": internal.ExtKeyUsageMicrosoftKernelCodeSigning,
+}
+
+// extensions are the extension names Import converts. Key identifiers are always
+// added to issued certificates, so they are accepted without a YAML field.
+var extensions = map[string]bool{
+	"basicConstraints":       true,
+	"keyUsage":               true,
+	"extendedKeyUsage":       true,
+	"subjectAltName":         true,
+	"subjectKeyIdentifier":   true,
+	"authorityKeyIdentifier": true,
+}
+
+// reqSettings are [req] settings that only affect the openssl command itself
+var reqSettings = map[string]bool{
+	"prompt":             true,
+	"distinguished_name": true,
+	"x509_extensions":    true,
+	"req_extensions":     true,
+	"default_bits":       true,
+	"default_md":         true,
+	"default_keyfile":    true,
+	"encrypt_key":        true,
+	"encrypt_rsa_key":    true,
+	"string_mask":        true,
+	"utf8":               true,
+	"oid_file":           true,
+	"oid_section":        true,
+	"RANDFILE":           true,
+}
+
+// Import converts an OpenSSL configuration file to a document of segments. The
+// subject of the [req] distinguished_name section, with default_bits and default_md,
+// becomes the "req" segment, and every extension section, such as [v3_ca] or
+// [v3_req] with the [alt_names] it references, becomes a segment of the same name.
+// The extension sections named by x509_extensions and req_extensions also get a
+// certificate merging "req" and the section. Settings without a YAML equivalent are
+// left out and returned as Unsupported.
+func Import(data []byte) (*internal.ConfigDocument, []Unsupported, error) {
+	c, err := Parse(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ImportConfig(c)
+}
+
+// ImportConfig converts a parsed configuration like Import
+func ImportConfig(c *Config) (*internal.ConfigDocument, []Unsupported, error) {
+	im := &importer{config: c, used: map[string]bool{DefaultSection: true}}
+	doc := &internal.ConfigDocument{Segments: make(map[string]*internal.CertificateSpec)}
+
+	req := c.Section("req")
+	if req != nil {
+		im.used[req.Name] = true
+		spec, err := im.req(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("[req]: %w", err)
+		}
+		doc.Segments[ReqSegment] = spec
+	}
+
+	for _, name := range c.Sections() {
+		section := c.Section(name)
+		if im.used[name] || !isExtensionSection(section) {
+			continue
+		}
+		spec, err := im.extensions(section)
+		if err != nil {
+			return nil, nil, fmt.Errorf("[%s]: %w", name, err)
+		}
+		doc.Segments[name] = spec
+	}
+
+	// The [req] section names the extensions of self-signed certificates and requests
+	for _, setting := range []string{"x509_extensions", "req_extensions"} {
+		name, ok := req.Get(setting)
+		if !ok {
+			continue
+		}
+		if _, exists := doc.Segments[name]; !exists {
+			return nil, nil, fmt.Errorf("[req]: %s names section '%s', which has no extensions", setting, name)
+		}
+		if doc.Certificates == nil {
+			doc.Certificates = make(map[string]*internal.CertificateEntry)
+		}
+		doc.Certificates[name] = &internal.CertificateEntry{Merge: []string{ReqSegment, name}}
+	}
+	if doc.Certificates == nil && req != nil {
+		doc.Certificates = map[string]*internal.CertificateEntry{ReqSegment: {Merge: []string{ReqSegment}}}
+	}
+
+	for _, name := range c.Sections() {
+		if !im.used[name] && len(c.Section(name).Entries) > 0 {
+			im.skip(name, "", "not a [req], distinguished name or extension section")
+		}
+	}
+	if len(doc.Segments) == 0 {
+		return nil, nil, fmt.Errorf("no [req] or extension section found")
+	}
+
+	sort.SliceStable(im.unsupported, func(i, j int) bool {
+		return im.unsupported[i].Field < im.unsupported[j].Field
+	})
+	return doc, im.unsupported, nil
+}
+
+// importer tracks the sections used and the settings left out while importing
+type importer struct {
+	config      *Config
+	used        map[string]bool
+	unsupported []Unsupported
+}
+
+func (im *importer) skip(section, name, reason string) {
+	field := section
+	if name != "" {
+		field += "." + name
+	}
+	im.unsupported = append(im.unsupported, Unsupported{Field: field, Reason: reason})
+}
+
+// isExtensionSection reports whether a section holds X.509v3 extensions
+func isExtensionSection(s *Section) bool {
+	for _, entry := range s.Entries {
+		if extensions[entry.Name] {
+			return true
+		}
+	}
+	return false
+}
+
+// req converts the [req] section and its distinguished name
+func (im *importer) req(req *Section) (*internal.CertificateSpec, error) {
+	spec := &internal.CertificateSpec{}
+
+	if name, ok := req.Get("distinguished_name"); ok {
+		dn := im.config.Section(name)
+		if dn == nil {
+			return nil, fmt.Errorf("distinguished_name names missing section '%s'", name)
+		}
+		im.used[name] = true
+		prompt, _ := req.Get("prompt")
+		spec.Subject = im.subject(dn, prompt == "no")
+	}
+
+	if bits, ok := req.Get("default_bits"); ok {
+		spec.PublicKeyAlgorithm = internal.PubKeyAlgRSA
+		if bits != "2048" {
+			im.skip(req.Name, "default_bits", fmt.Sprintf("RSA keys are generated with 2048 bits, not %s", bits))
+		}
+	}
+	if md, ok := req.Get("default_md"); ok {
+		alg, supported := rsaSignatureAlgorithm(md)
+		if supported {
+			spec.SignatureAlgorithm = alg
+		} else {
+			im.skip(req.Name, "default_md", fmt.Sprintf("no signature algorithm for digest '%s'", md))
+		}
+	}
+
+	for _, entry := range req.Entries {
+		if reqSettings[entry.Name] {
+			continue
+		}
+		if entry.Name == "attributes" {
+			im.used[entry.Value] = true
+			im.skip(req.Name, entry.Name, "request attributes are not supported")
+			continue
+		}
+		im.skip(req.Name, entry.Name, "unknown [req] setting")
+	}
+	return spec, nil
+}
+
+// rsaSignatureAlgorithm maps a default_md digest to an RSA signature algorithm. The
+// key is RSA unless the openssl command is given another, as default_bits implies.
+func rsaSignatureAlgorithm(md string) (string, bool) {
+	switch strings.ToLower(md) {
+	case "sha256", "default":
+		return internal.SigAlgSHA256WithRSA, true
+	case "sha384":
+		return internal.SigAlgSHA384WithRSA, true
+	case "sha512":
+		return internal.SigAlgSHA512WithRSA, true
+	case "sha1":
+		return internal.SigAlgSHA1WithRSA, true
+	case "md5":
+		return internal.SigAlgMD5WithRSA, true
+	default:
+		return "", false
+	}
+}
+
+// subject converts a distinguished name section. With prompt = no the entries are the
+// values; otherwise they are prompts and the name_default entries hold the values.
+func (im *importer) subject(dn *Section, values bool) map[string]string {
+	subject := make(map[string]string)
+	for _, entry := range dn.Entries {
+		name := entry.Name
+		if !values {
+			var ok bool
+			if name, ok = strings.CutSuffix(name, "_default"); !ok {
+				// Prompts and their _min and _max lengths
+				continue
+			}
+		}
+		// Repeated attributes are numbered, e.g. 0.organizationName
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+
+		field, ok := dnFields[name]
+		switch {
+		case name == "emailAddress" || name == "Email":
+			im.skip(dn.Name, entry.Name, "email addresses in the subject are not supported, use email_addresses")
+		case !ok:
+			im.skip(dn.Name, entry.Name, fmt.Sprintf("no subject field for attribute '%s'", name))
+		case subject[field] != "":
+			im.skip(dn.Name, entry.Name, fmt.Sprintf("%s holds a single value, '%s' is kept", field, subject[field]))
+		default:
+			subject[field] = entry.Value
+		}
+	}
+	if len(subject) == 0 {
+		return nil
+	}
+	return subject
+}
+
+// extensions converts an extension section
+func (im *importer) extensions(s *Section) (*internal.CertificateSpec, error) {
+	im.used[s.Name] = true
+	spec := &internal.CertificateSpec{}
+
+	for _, entry := range s.Entries {
+		values, critical := splitList(entry.Value)
+		var err error
+		switch entry.Name {
+		case "basicConstraints":
+			err = basicConstraints(spec, values)
+		case "keyUsage":
+			err = keyUsage(spec, values)
+		case "extendedKeyUsage":
+			im.extKeyUsage(s, entry.Name, spec, values)
+			if critical {
+				im.skip(s.Name, entry.Name, "extendedKeyUsage is never marked critical")
+			}
+		case "subjectAltName":
+			err = im.subjectAltName(s, spec, values)
+		case "subjectKeyIdentifier", "authorityKeyIdentifier":
+			// Issued certificates always carry key identifiers
+		default:
+			im.skip(s.Name, entry.Name, "extension has no YAML equivalent")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name, err)
+		}
+	}
+	return spec, nil
+}
+
+// splitList splits a comma separated extension value, removing the critical flag
+func splitList(value string) ([]string, bool) {
+	var values []string
+	critical := false
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		switch {
+		case v == "":
+		case v == "critical":
+			critical = true
+		default:
+			values = append(values, v)
+		}
+	}
+	return values, critical
+}
+
+// basicConstraints converts e.g. "critical, CA:TRUE, pathlen:0". Issued certificates
+// always mark basic constraints critical.
+func basicConstraints(spec *internal.CertificateSpec, values []string) error {
+	spec.BasicConstraintsValid = true
+	for _, v := range values {
+		name, value, _ := strings.Cut(v, ":")
+		switch strings.TrimSpace(name) {
+		case "CA":
+			switch strings.ToUpper(strings.TrimSpace(value)) {
+			case "TRUE":
+				spec.IsCA = true
+			case "FALSE":
+				spec.IsCA = false
+			default:
+				return fmt.Errorf("invalid CA value '%s'", value)
+			}
+		case "pathlen":
+			n, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid pathlen '%s'", value)
+			}
+			spec.MaxPathLen = n
+			spec.MaxPathLenZero = n == 0
+		default:
+			return fmt.Errorf("unknown setting '%s'", v)
+		}
+	}
+	if (spec.MaxPathLen > 0 || spec.MaxPathLenZero) && !spec.IsCA {
+		return fmt.Errorf("pathlen requires CA:TRUE")
+	}
+	return nil
+}
+
+// keyUsage converts keyUsage names. Issued certificates always mark key usage critical.
+func keyUsage(spec *internal.CertificateSpec, values []string) error {
+	for _, v := range values {
+		usage, ok := keyUsages[v]
+		if !ok {
+			return fmt.Errorf("unknown key usage '%s'", v)
+		}
+		spec.KeyUsage = append(spec.KeyUsage, usage)
+	}
+	return nil
+}
+
+// extKeyUsage converts extendedKeyUsage names and OIDs, skipping those without a YAML value
+func (im *importer) extKeyUsage(s *Section, name string, spec *internal.CertificateSpec, values []string) {
+	for _, v := range values {
+		usage, ok := extKeyUsages[v]
+		if !ok {
+			im.skip(s.Name, name, fmt.Sprintf("no extended key usage for '%s'", v))
+			continue
+		}
+		spec.ExtKeyUsage = append(spec.ExtKeyUsage, usage)
+	}
+}
+
+// subjectAltName converts inline names, e.g. "DNS:example.com, IP:192.0.2.1", and
+// sections referenced with '@' whose entries are numbered, e.g. "DNS.1 = example.com"
+func (im *importer) subjectAltName(s *Section, spec *internal.CertificateSpec, values []string) error {
+	for _, v := range values {
+		if ref, ok := strings.CutPrefix(v, "@"); ok {
+			names := im.config.Section(ref)
+			if names == nil {
+				return fmt.Errorf("missing section '%s'", ref)
+			}
+			im.used[ref] = true
+			for _, entry := range names.Entries {
+				kind, _, _ := strings.Cut(entry.Name, ".")
+				im.altName(names.Name, entry.Name, kind, entry.Value, spec)
+			}
+			continue
+		}
+
+		kind, value, ok := strings.Cut(v, ":")
+		if !ok {
+			return fmt.Errorf("expected type:value, got '%s'", v)
+		}
+		im.altName(s.Name, "subjectAltName", strings.TrimSpace(kind), strings.TrimSpace(value), spec)
+	}
+	return nil
+}
+
+func (im *importer) altName(section, name, kind, value string, spec *internal.CertificateSpec) {
+	switch kind {
+	case "DNS":
+		spec.DNSNames = append(spec.DNSNames, value)
+	case "IP":
+		spec.IPAddresses = append(spec.IPAddresses, value)
+	case "URI":
+		spec.URIs = append(spec.URIs, value)
+	case "email":
+		if value == "copy" || value == "move" {
+			im.skip(section, name, fmt.Sprintf("email:%s is not supported, list the address instead", value))
+			return
+		}
+		spec.EmailAddresses = append(spec.EmailAddresses, value)
+	default:
+		im.skip(section, name, fmt.Sprintf("alternative names of type '%s' are not supported", kind))
+	}
+}
@@ -0,0 +1,238 @@
+package openssl
+
+import (
+	"crypto/x509"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+
+	"gopkg.in/yaml.v3"
+)
+
+const legacyConfig = `
+HOME = .
+
+[ req ]
+default_bits       = 2048
+default_md         = sha384
+distinguished_name = req_distinguished_name
+x509_extensions    = v3_ca
+req_extensions     = v3_req
+string_mask        = utf8only
+
+[ req_distinguished_name ]
+countryName                 = Country Name (2 letter code)
+countryName_default         = NL
+countryName_min             = 2
+countryName_max             = 2
+0.organizationName          = Organization Name
+0.organizationName_default  = Example B.V.
+organizationalUnitName      = Organizational Unit Name
+commonName                  = Common Name
+commonName_default          = www.example.com
+emailAddress                = Email Address
+emailAddress_default        = admin@example.com
+
+[ v3_ca ]
+subjectKeyIdentifier   = hash
+authorityKeyIdentifier = keyid:always,issuer
+basicConstraints       = critical, CA:true, pathlen:0
+keyUsage               = critical, keyCertSign, cRLSign
+
+[ v3_req ]
+basicConstraints = CA:FALSE
+keyUsage         = nonRepudiation, digitalSignature, keyEncipherment
+extendedKeyUsage = serverAuth, 1.3.6.1.5.5.7.3.2, msEFS
+subjectAltName   = @alt_names, URI:https://www.example.com/
+nsComment        = "Legacy certificate"
+
+[ alt_names ]
+DNS.1   = www.example.com
+DNS.2   = example.com
+IP.1    = 192.0.2.1
+email.1 = admin@example.com
+RID.1   = 1.2.3.4
+
+[ ca ]
+default_ca = CA_default
+`
+
+func TestImport(t *testing.T) {
+	doc, unsupported, err := Import([]byte(legacyConfig))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	expected := map[string]*internal.CertificateSpec{
+		ReqSegment: {
+			Subject: map[string]string{
+				internal.DNCountry:      "NL",
+				internal.DNOrganization: "Example B.V.",
+				internal.DNCommonName:   "www.example.com",
+			},
+			PublicKeyAlgorithm: internal.PubKeyAlgRSA,
+			SignatureAlgorithm: internal.SigAlgSHA384WithRSA,
+		},
+		"v3_ca": {
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			MaxPathLenZero:        true,
+			KeyUsage:              []string{internal.KeyUsageCertSign, internal.KeyUsageCRLSign},
+		},
+		"v3_req": {
+			BasicConstraintsValid: true,
+			KeyUsage:              []string{internal.KeyUsageContentCommitment, internal.KeyUsageDigitalSignature, internal.KeyUsageKeyEncipherment},
+			ExtKeyUsage:           []string{internal.ExtKeyUsageServerAuth, internal.ExtKeyUsageClientAuth},
+			DNSNames:              []string{"www.example.com", "example.com"},
+			IPAddresses:           []string{"192.0.2.1"},
+			EmailAddresses:        []string{"admin@example.com"},
+			URIs:                  []string{"https://www.example.com/"},
+		},
+	}
+	if !reflect.DeepEqual(doc.Segments, expected) {
+		for name, spec := range doc.Segments {
+			t.Logf("%s: %+v", name, spec)
+		}
+		t.Error("unexpected segments")
+	}
+
+	if len(doc.Certificates) != 2 ||
+		!reflect.DeepEqual(doc.Certificates["v3_ca"].Merge, []string{ReqSegment, "v3_ca"}) ||
+		!reflect.DeepEqual(doc.Certificates["v3_req"].Merge, []string{ReqSegment, "v3_req"}) {
+		t.Errorf("unexpected certificates %v", doc.Certificates)
+	}
+
+	var fields []string
+	for _, u := range unsupported {
+		fields = append(fields, u.Field)
+	}
+	expectedFields := []string{
+		"alt_names.RID.1",
+		"ca",
+		"req_distinguished_name.emailAddress_default",
+		"v3_req.extendedKeyUsage",
+		"v3_req.nsComment",
+	}
+	if !reflect.DeepEqual(fields, expectedFields) {
+		t.Errorf("expected unsupported %v, got %v", expectedFields, unsupported)
+	}
+}
+
+// TestImport_Issues checks that the imported document issues the certificates the
+// configuration describes
+func TestImport_Issues(t *testing.T) {
+	doc, _, err := Import([]byte(legacyConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certs, err := yamltox509.CertificatesFromYaml(data)
+	if err != nil {
+		t.Fatalf("CertificatesFromYaml:\n%s\n%v", data, err)
+	}
+
+	ca := certs["v3_ca"]
+	if !ca.IsCA || !ca.MaxPathLenZero || ca.KeyUsage != x509.KeyUsageCertSign|x509.KeyUsageCRLSign {
+		t.Errorf("unexpected CA template: is_ca %v, max_path_len_zero %v, key usage %v", ca.IsCA, ca.MaxPathLenZero, ca.KeyUsage)
+	}
+	if ca.Subject.CommonName != "www.example.com" || ca.SignatureAlgorithm != x509.SHA384WithRSA || ca.PublicKeyAlgorithm != x509.RSA {
+		t.Errorf("unexpected CA subject or algorithms: %s %v %v", ca.Subject, ca.SignatureAlgorithm, ca.PublicKeyAlgorithm)
+	}
+
+	leaf := certs["v3_req"]
+	if leaf.IsCA || !reflect.DeepEqual(leaf.DNSNames, []string{"www.example.com", "example.com"}) ||
+		len(leaf.IPAddresses) != 1 || !leaf.IPAddresses[0].Equal(net.ParseIP("192.0.2.1")) {
+		t.Errorf("unexpected leaf template: %v %v", leaf.DNSNames, leaf.IPAddresses)
+	}
+	if !reflect.DeepEqual(leaf.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}) {
+		t.Errorf("unexpected ext key usage %v", leaf.ExtKeyUsage)
+	}
+}
+
+func TestImport_PromptlessSubjectAndInlineNames(t *testing.T) {
+	doc, unsupported, err := Import([]byte(`
+[req]
+prompt = no
+distinguished_name = dn
+
+[dn]
+CN = api.example.com
+O = Example
+1.OU = Platform
+2.OU = Security
+
+[ext]
+subjectAltName = DNS:api.example.com, IP:2001:db8::1, email:copy
+extendedKeyUsage = critical, clientAuth
+`))
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	subject := doc.Segments[ReqSegment].Subject
+	expectedSubject := map[string]string{
+		internal.DNCommonName:         "api.example.com",
+		internal.DNOrganization:       "Example",
+		internal.DNOrganizationalUnit: "Platform",
+	}
+	if !reflect.DeepEqual(subject, expectedSubject) {
+		t.Errorf("unexpected subject %v", subject)
+	}
+
+	ext := doc.Segments["ext"]
+	if !reflect.DeepEqual(ext.DNSNames, []string{"api.example.com"}) || !reflect.DeepEqual(ext.IPAddresses, []string{"2001:db8::1"}) {
+		t.Errorf("unexpected names %v %v", ext.DNSNames, ext.IPAddresses)
+	}
+
+	// Sections not named by x509_extensions or req_extensions are segments only
+	if len(doc.Certificates) != 1 || doc.Certificates[ReqSegment] == nil {
+		t.Errorf("expected a single 'req' certificate, got %v", doc.Certificates)
+	}
+
+	var reasons []string
+	for _, u := range unsupported {
+		reasons = append(reasons, u.String())
+	}
+	expected := []string{
+		"dn.2.OU: organizational_unit holds a single value, 'Platform' is kept",
+		"ext.extendedKeyUsage: extendedKeyUsage is never marked critical",
+		"ext.subjectAltName: email:copy is not supported, list the address instead",
+	}
+	if !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("expected %v, got %v", expected, reasons)
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"empty", "# nothing\n", "no [req] or extension section found"},
+		{"missing distinguished name", "[req]\ndistinguished_name = dn\n", "[req]: distinguished_name names missing section 'dn'"},
+		{"missing extensions", "[req]\nx509_extensions = v3_ca\n", "[req]: x509_extensions names section 'v3_ca', which has no extensions"},
+		{"unknown key usage", "[ext]\nkeyUsage = signing\n", "[ext]: keyUsage: unknown key usage 'signing'"},
+		{"invalid CA flag", "[ext]\nbasicConstraints = CA:maybe\n", "[ext]: basicConstraints: invalid CA value 'maybe'"},
+		{"path length on a leaf", "[ext]\nbasicConstraints = CA:FALSE, pathlen:1\n", "pathlen requires CA:TRUE"},
+		{"missing alt names", "[ext]\nsubjectAltName = @names\n", "[ext]: subjectAltName: missing section 'names'"},
+		{"alt name without type", "[ext]\nsubjectAltName = example.com\n", "expected type:value, got 'example.com'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Import([]byte(tt.config))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
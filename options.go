@@ -0,0 +1,232 @@
+package go_yaml_to_x509
+
+import (
+	"crypto/x509"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+	"github.com/rschoonheim/go-yaml-to-x509/policy"
+	"github.com/rschoonheim/go-yaml-to-x509/store"
+)
+
+// MergeConflict describes a field that two merged segments set to different values
+type MergeConflict = internal.MergeConflict
+
+// ConflictError is returned when merged segments conflict in "error" conflict mode
+type ConflictError = internal.ConflictError
+
+// Options controls how X509FromYamlWithOptions resolves and builds a certificate
+type Options struct {
+	// ConflictMode overrides the document's 'conflicts' setting: "off", "warn" or "error"
+	ConflictMode string
+
+	// OnConflict is called for every merge conflict found in "warn" mode. Conflicts
+	// are written to the standard logger when it is nil.
+	OnConflict func(MergeConflict)
+
+	// Vars supplies values for ${name} references, taking precedence over the
+	// document's 'vars' defaults. A variable with several values expands to
+	// several list items when referenced on its own in a list.
+	Vars map[string][]string
+
+	// UseEnv enables looking up variables in the environment as EnvPrefix + name.
+	// Environment variables take precedence over 'vars' defaults but not over Vars.
+	UseEnv    bool
+	EnvPrefix string
+
+	// Now is the clock used to anchor relative validity periods. Defaults to time.Now.
+	Now func() time.Time
+
+	// Rand is the source of randomness for generated serial numbers. Defaults to crypto/rand.Reader.
+	Rand io.Reader
+
+	// SerialCounter supplies serial numbers for serial_number: "sequential"
+	SerialCounter SerialCounter
+
+	// Store records every certificate issued by Issue and rejects reused serial numbers
+	Store store.Store
+
+	// Policy is checked for every certificate built from YAML and every certificate
+	// issued. Violations of deny rules fail with a *policy.DeniedError, as do
+	// certificates derived from an insecure test seed unless the policy allows it.
+	Policy *policy.Policy
+
+	// OnPolicyWarning is called for every violation of a warn rule
+	OnPolicyWarning func(policy.Result)
+
+	// IncludeFS is the filesystem files listed in a document's 'include' are read from.
+	// Documents with includes fail to resolve when it is nil.
+	IncludeFS fs.FS
+
+	// Strict rejects unknown YAML keys and unknown or unparsable values, such as
+	// misspelled key usages, instead of ignoring them
+	Strict bool
+
+	// Passphrase supplies the passphrase MarshalPrivateKey encrypts keys with when
+	// the spec's 'key_encryption' section does not reference one
+	Passphrase PassphraseFunc
+
+	// InsecureTestSeed makes builds and issuance reproducible for test fixtures,
+	// taking precedence over a spec's 'insecure_test_seed'. See WithInsecureTestSeed.
+	InsecureTestSeed []byte
+}
+
+// Option configures the Options of X509FromYaml and the other YAML entry points
+type Option func(*Options)
+
+// WithOptions applies every field of o, replacing options applied before it
+func WithOptions(o Options) Option {
+	return func(opts *Options) { *opts = o }
+}
+
+// WithClock sets the clock used to anchor relative validity periods
+func WithClock(now func() time.Time) Option {
+	return func(opts *Options) { opts.Now = now }
+}
+
+// WithVar sets the values of a variable, taking precedence over the document's 'vars'
+func WithVar(name string, values ...string) Option {
+	return func(opts *Options) {
+		vars := make(map[string][]string, len(opts.Vars)+1)
+		for k, v := range opts.Vars {
+			vars[k] = v
+		}
+		vars[name] = values
+		opts.Vars = vars
+	}
+}
+
+// WithVars sets the values of several variables
+func WithVars(vars map[string][]string) Option {
+	return func(opts *Options) {
+		for name, values := range vars {
+			WithVar(name, values...)(opts)
+		}
+	}
+}
+
+// WithEnv enables looking up variables in the environment as prefix + name
+func WithEnv(prefix string) Option {
+	return func(opts *Options) {
+		opts.UseEnv = true
+		opts.EnvPrefix = prefix
+	}
+}
+
+// WithConflictMode overrides the document's 'conflicts' setting, calling onConflict
+// for every conflict found in "warn" mode. Conflicts go to the standard logger when
+// onConflict is nil.
+func WithConflictMode(mode string, onConflict func(MergeConflict)) Option {
+	return func(opts *Options) {
+		opts.ConflictMode = mode
+		opts.OnConflict = onConflict
+	}
+}
+
+// WithIncludeFS sets the filesystem files listed in 'include' are read from
+func WithIncludeFS(fsys fs.FS) Option {
+	return func(opts *Options) { opts.IncludeFS = fsys }
+}
+
+// WithStrict rejects unknown YAML keys and values instead of ignoring them
+func WithStrict() Option {
+	return func(opts *Options) { opts.Strict = true }
+}
+
+// WithRand sets the source of randomness for serial numbers and generated keys
+func WithRand(r io.Reader) Option {
+	return func(opts *Options) { opts.Rand = r }
+}
+
+// WithSerialCounter sets the counter used for serial_number: "sequential"
+func WithSerialCounter(counter SerialCounter) Option {
+	return func(opts *Options) { opts.SerialCounter = counter }
+}
+
+// WithPolicy checks every certificate against p, calling onWarning for every
+// violation of a warn rule when it is not nil
+func WithPolicy(p *policy.Policy, onWarning func(policy.Result)) Option {
+	return func(opts *Options) {
+		opts.Policy = p
+		opts.OnPolicyWarning = onWarning
+	}
+}
+
+// WithPassphrase encrypts private keys with the passphrase fn returns, see MarshalPrivateKey
+func WithPassphrase(fn PassphraseFunc) Option {
+	return func(opts *Options) { opts.Passphrase = fn }
+}
+
+// WithInsecureTestSeed derives generated keys, serial numbers and signatures from
+// seed, so that the same input produces the same certificates and keys byte for byte.
+// Relative validity periods are anchored to a fixed time unless a clock is set.
+//
+// TEST ONLY: anyone who knows the seed can recreate the private keys.
+func WithInsecureTestSeed(seed []byte) Option {
+	return func(opts *Options) { opts.InsecureTestSeed = seed }
+}
+
+// NewOptions applies opts in order to empty Options, for functions that take
+// Options such as BuildCertificate and Issue
+func NewOptions(opts ...Option) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// testSeed returns the seed of reproducible builds for spec, or nil
+func (o Options) testSeed(spec *CertificateSpec) []byte {
+	if o.InsecureTestSeed != nil {
+		return o.InsecureTestSeed
+	}
+	if spec != nil && spec.InsecureTestSeed != "" {
+		return []byte(spec.InsecureTestSeed)
+	}
+	return nil
+}
+
+// now returns the current time from the configured clock
+func (o Options) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+// checkPolicy checks a certificate for the named profile against the configured
+// policy, denying certificates derived from seed unless the policy allows it
+func (o Options) checkPolicy(profile string, cert *x509.Certificate, seed []byte) error {
+	if o.Policy == nil {
+		return nil
+	}
+	if seed != nil {
+		if err := o.Policy.CheckInsecureTestSeed(profile); err != nil {
+			return err
+		}
+	}
+
+	warnings, err := o.Policy.Check(profile, cert)
+	if o.OnPolicyWarning != nil {
+		for _, warning := range warnings {
+			o.OnPolicyWarning(warning)
+		}
+	}
+	return err
+}
+
+// resolveOptions converts Options to the options used by internal.ResolveConfigWithOptions
+func (o Options) resolveOptions() internal.ResolveOptions {
+	return internal.ResolveOptions{
+		ConflictMode: o.ConflictMode,
+		OnConflict:   o.OnConflict,
+		Vars:         o.Vars,
+		UseEnv:       o.UseEnv,
+		EnvPrefix:    o.EnvPrefix,
+		IncludeFS:    o.IncludeFS,
+		Strict:       o.Strict,
+	}
+}
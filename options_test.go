@@ -0,0 +1,170 @@
+package go_yaml_to_x509
+
+import (
+	"bytes"
+	"crypto/x509"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestX509FromYamlWithOptions_Variables(t *testing.T) {
+	yamlData := []byte(`
+vars:
+  env: staging
+  aliases:
+    - "api.example.com"
+    - "api.internal"
+
+subject:
+  common_name: "${host}.${env}.example.com"
+dns_names:
+  - "${host}.${env}.example.com"
+  - "${aliases}"
+`)
+
+	cert, err := X509FromYamlWithOptions(yamlData, Options{
+		Vars: map[string][]string{"host": {"api"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	if cert.Subject.CommonName != "api.staging.example.com" {
+		t.Errorf("Expected CommonName 'api.staging.example.com', got '%s'", cert.Subject.CommonName)
+	}
+	if len(cert.DNSNames) != 3 {
+		t.Errorf("Expected list variable to expand to 3 DNS names, got %v", cert.DNSNames)
+	}
+}
+
+func TestX509FromYamlWithOptions_EnvVariables(t *testing.T) {
+	t.Setenv("YAML2X509_env", "prod")
+
+	yamlData := []byte(`
+vars:
+  env: staging
+subject:
+  common_name: "api.${env}.example.com"
+`)
+
+	cert, err := X509FromYaml(yamlData)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	if cert.Subject.CommonName != "api.staging.example.com" {
+		t.Errorf("Expected default without UseEnv, got '%s'", cert.Subject.CommonName)
+	}
+
+	cert, err = X509FromYamlWithOptions(yamlData, Options{UseEnv: true, EnvPrefix: "YAML2X509_"})
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	if cert.Subject.CommonName != "api.prod.example.com" {
+		t.Errorf("Expected environment value with UseEnv, got '%s'", cert.Subject.CommonName)
+	}
+}
+
+func TestX509FromYaml_UndefinedVariable(t *testing.T) {
+	_, err := X509FromYaml([]byte(`
+subject:
+  common_name: "${host}"
+`))
+
+	expected := "config: subject.common_name: undefined variable 'host'"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error '%s', got %v", expected, err)
+	}
+}
+
+func TestX509FromYaml_FunctionalOptions(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"segments/tls.yaml": {Data: []byte("segments:\n  tls:\n    ext_key_usage: [server_auth]\n")},
+	}
+
+	cert, err := X509FromYaml([]byte(`
+include: [segments/tls.yaml]
+merge: [tls]
+config:
+  subject:
+    common_name: "${host}"
+  dns_names: ["${host}", "${aliases}"]
+  validity:
+    duration: "30d"
+`), WithVar("host", "www.example.com"), WithVars(map[string][]string{"aliases": {"a.example.com", "b.example.com"}}),
+		WithClock(func() time.Time { return now }), WithIncludeFS(fsys), WithStrict())
+	if err != nil {
+		t.Fatalf("Failed to build certificate: %v", err)
+	}
+
+	if cert.Subject.CommonName != "www.example.com" || len(cert.DNSNames) != 3 {
+		t.Errorf("Expected variables to be applied, got %q and %v", cert.Subject.CommonName, cert.DNSNames)
+	}
+	if !cert.NotBefore.Equal(now) {
+		t.Errorf("Expected validity anchored at the injected clock, got %v", cert.NotBefore)
+	}
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+		t.Errorf("Expected the included segment to be merged, got %v", cert.ExtKeyUsage)
+	}
+
+	if _, err := X509FromYaml([]byte("include: [tls.yaml]\nmerge: [tls]\n")); err == nil || err.Error() != "include 'tls.yaml' requires an include filesystem" {
+		t.Errorf("Expected include to require a filesystem, got %v", err)
+	}
+}
+
+func TestX509FromYaml_Strict(t *testing.T) {
+	tests := map[string]string{
+		"subject:\n  common_name: a\nkey_usage: [digitl_signature]\n": "key_usage: unknown value 'digitl_signature'",
+		"subject:\n  common_name: a\nip_addresses: [192.0.2.300]\n":   "ip_addresses: invalid IP address '192.0.2.300'",
+		"subject:\n  common_name: a\ndns_name: [a]\n":                 "line 3: unknown field 'dns_name'",
+	}
+
+	for data, expected := range tests {
+		if _, err := X509FromYaml([]byte(data)); err != nil {
+			t.Errorf("Expected lenient parsing to succeed for %q, got %v", data, err)
+		}
+		if _, err := X509FromYaml([]byte(data), WithStrict()); err == nil || err.Error() != expected {
+			t.Errorf("Expected error %q, got %v", expected, err)
+		}
+	}
+
+	_, err := CertificatesFromYaml([]byte("name: a\n---\nname: b\nsubjct: {}\n"), WithStrict())
+	if err == nil || err.Error() != "document 2: line 2: unknown field 'subjct'" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestX509FromYaml_StrictIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"segments/tls.yaml": {Data: []byte("segments:\n  tls:\n    ext_key_usages: [server_auth]\n")},
+		"empty.yaml":        {Data: []byte("")},
+	}
+	data := []byte("include: [segments/tls.yaml, empty.yaml]\nmerge: [tls]\nconfig:\n  subject:\n    common_name: a\n")
+
+	if _, err := X509FromYaml(data, WithIncludeFS(fsys)); err != nil {
+		t.Errorf("Expected lenient parsing to ignore unknown keys in includes, got %v", err)
+	}
+	_, err := X509FromYaml(data, WithIncludeFS(fsys), WithStrict())
+	if err == nil || err.Error() != "include 'segments/tls.yaml': line 3: unknown field 'ext_key_usages'" {
+		t.Errorf("Expected the include to be decoded strictly, got %v", err)
+	}
+}
+
+func TestWithOptions(t *testing.T) {
+	rand := bytes.NewReader(nil)
+	opts := NewOptions(
+		WithVar("a", "1"),
+		WithOptions(Options{ConflictMode: "error"}),
+		WithVar("b", "2"),
+		WithEnv("APP_"),
+		WithRand(rand),
+	)
+
+	if opts.ConflictMode != "error" || opts.Vars["a"] != nil || opts.Vars["b"][0] != "2" {
+		t.Errorf("Expected WithOptions to replace earlier options, got %+v", opts)
+	}
+	if !opts.UseEnv || opts.EnvPrefix != "APP_" || opts.Rand != rand {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+}
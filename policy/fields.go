@@ -0,0 +1,194 @@
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// kind describes how the values of a field are compared
+type kind int
+
+const (
+	kindString kind = iota
+	kindList
+	kindInt
+	kindDuration
+)
+
+// fieldKinds lists the fields a rule can constrain, besides subject.* and issuer.*
+var fieldKinds = map[string]kind{
+	"dns_names":            kindList,
+	"email_addresses":      kindList,
+	"ip_addresses":         kindList,
+	"uris":                 kindList,
+	"key_usage":            kindList,
+	"ext_key_usage":        kindList,
+	"signature_algorithm":  kindString,
+	"public_key_algorithm": kindString,
+	"is_ca":                kindString,
+	"max_path_len":         kindInt,
+	"key_size":             kindInt,
+	"validity":             kindDuration,
+}
+
+// fieldKind returns the kind of a field path, and false for unknown fields
+func fieldKind(field string) (kind, bool) {
+	if k, ok := fieldKinds[field]; ok {
+		return k, true
+	}
+	for _, prefix := range []string{"subject.", "issuer."} {
+		if name := strings.TrimPrefix(field, prefix); name != field {
+			for _, dn := range internal.DNFields {
+				if name == dn {
+					return kindString, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// fieldValues returns the values of a field, and false when the field cannot be
+// determined from the certificate
+func fieldValues(field string, cert *x509.Certificate, spec *internal.CertificateSpec) ([]string, bool) {
+	switch field {
+	case "dns_names":
+		return spec.DNSNames, true
+	case "email_addresses":
+		return spec.EmailAddresses, true
+	case "ip_addresses":
+		return spec.IPAddresses, true
+	case "uris":
+		return spec.URIs, true
+	case "key_usage":
+		return spec.KeyUsage, true
+	case "ext_key_usage":
+		return spec.ExtKeyUsage, true
+	case "signature_algorithm":
+		return optional(spec.SignatureAlgorithm), true
+	case "public_key_algorithm":
+		alg := spec.PublicKeyAlgorithm
+		if alg == "" && cert.PublicKey != nil {
+			alg = keyAlgorithm(cert.PublicKey)
+		}
+		return optional(alg), true
+	case "is_ca":
+		return []string{strconv.FormatBool(cert.IsCA)}, true
+	case "max_path_len":
+		if !cert.IsCA || (cert.MaxPathLen <= 0 && !cert.MaxPathLenZero) {
+			return nil, true
+		}
+		return []string{strconv.Itoa(cert.MaxPathLen)}, true
+	case "key_size":
+		size := keySize(cert.PublicKey)
+		if size == 0 {
+			return nil, false
+		}
+		return []string{strconv.Itoa(size)}, true
+	case "validity":
+		if cert.NotBefore.IsZero() || cert.NotAfter.IsZero() {
+			return nil, false
+		}
+		return []string{internal.FormatDuration(cert.NotAfter.Sub(cert.NotBefore))}, true
+	}
+
+	if name, ok := strings.CutPrefix(field, "subject."); ok {
+		return optional(spec.Subject[name]), true
+	}
+	if name, ok := strings.CutPrefix(field, "issuer."); ok {
+		return optional(spec.Issuer[name]), true
+	}
+	return nil, false
+}
+
+func optional(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// keyAlgorithm returns the public key algorithm name of a key
+func keyAlgorithm(key interface{}) string {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return internal.PubKeyAlgRSA
+	case *ecdsa.PublicKey:
+		return internal.PubKeyAlgECDSA
+	case ed25519.PublicKey:
+		return internal.PubKeyAlgEd25519
+	default:
+		return ""
+	}
+}
+
+// keySize returns the size of a public key in bits, or 0 when unknown
+func keySize(key interface{}) int {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	case *ecdsa.PublicKey:
+		return k.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return 256
+	default:
+		return 0
+	}
+}
+
+// parseBound parses a min or max value for a field of the given kind, with
+// durations in nanoseconds
+func parseBound(k kind, s string) (int64, error) {
+	switch k {
+	case kindDuration:
+		d, err := internal.ParseDuration(s)
+		if err != nil {
+			return 0, err
+		}
+		return int64(d), nil
+	default:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number '%s'", s)
+		}
+		return n, nil
+	}
+}
+
+// boundValue returns a value of a numeric field for comparison with a bound. The
+// validity is taken from the certificate, as its text form cannot represent
+// periods below a second or inverted periods as valid durations.
+func boundValue(k kind, value string, cert *x509.Certificate) (int64, error) {
+	if k == kindDuration {
+		return int64(cert.NotAfter.Sub(cert.NotBefore)), nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%s'", value)
+	}
+	return n, nil
+}
+
+// compareBound compares a field value with a bound, returning -1, 0 or 1
+func compareBound(k kind, value int64, bound string) (int, error) {
+	b, err := parseBound(k, bound)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case value < b:
+		return -1, nil
+	case value > b:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
@@ -0,0 +1,90 @@
+package policy
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func TestFieldKind(t *testing.T) {
+	for field, expected := range map[string]bool{
+		"dns_names":           true,
+		"validity":            true,
+		"subject.common_name": true,
+		"issuer.country":      true,
+		"subject.":            false,
+		"issuer.cn":           false,
+		"serial_number":       false,
+	} {
+		if _, ok := fieldKind(field); ok != expected {
+			t.Errorf("Expected fieldKind(%q) to be %v", field, expected)
+		}
+	}
+}
+
+func TestKeySize(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if size := keySize(&rsaKey.PublicKey); size != 1024 {
+		t.Errorf("Expected 1024 bit RSA key, got %d", size)
+	}
+	if size := keySize(edKey); size != 256 {
+		t.Errorf("Expected 256 bit Ed25519 key, got %d", size)
+	}
+	if size := keySize(nil); size != 0 {
+		t.Errorf("Expected 0 for missing key, got %d", size)
+	}
+	if alg := keyAlgorithm(&rsaKey.PublicKey); alg != "RSA" {
+		t.Errorf("Expected RSA, got %q", alg)
+	}
+}
+
+func TestCompareBound(t *testing.T) {
+	tests := []struct {
+		kind     kind
+		value    int64
+		bound    string
+		expected int
+	}{
+		{kindInt, 2048, "3072", -1},
+		{kindInt, 4096, "4096", 0},
+		{kindDuration, int64(90 * 24 * time.Hour), "30d", 1},
+		{kindDuration, int64(24 * time.Hour), "1d", 0},
+		{kindDuration, int64(12 * time.Hour), "1d", -1},
+		{kindDuration, 0, "1s", -1},
+		{kindDuration, int64(-time.Hour), "1s", -1},
+	}
+
+	for _, tt := range tests {
+		got, err := compareBound(tt.kind, tt.value, tt.bound)
+		if err != nil || got != tt.expected {
+			t.Errorf("compareBound(%d, %q) = %d, %v, expected %d", tt.value, tt.bound, got, err, tt.expected)
+		}
+	}
+
+	if _, err := compareBound(kindInt, 1, "many"); err == nil {
+		t.Error("Expected an error for an invalid bound")
+	}
+}
+
+func TestBoundValue(t *testing.T) {
+	notBefore := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cert := &x509.Certificate{NotBefore: notBefore, NotAfter: notBefore.Add(-time.Hour)}
+
+	if v, err := boundValue(kindDuration, "-1h", cert); err != nil || v != int64(-time.Hour) {
+		t.Errorf("Expected -1h from the certificate, got %d, %v", v, err)
+	}
+	if _, err := boundValue(kindInt, "big", cert); err == nil || err.Error() != "invalid number 'big'" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,391 @@
+// Package policy enforces an organisation's issuance policy: a YAML document of
+// rules constraining the values certificates may have, per field and per profile.
+//
+//	rules:
+//	  - name: corp-dns-only
+//	    field: dns_names
+//	    allow: ["*.corp.example.com"]
+//	  - name: short-lived-client-certs
+//	    when:
+//	      ext_key_usage: [client_auth]
+//	    field: validity
+//	    max: 30d
+//	  - name: ecdsa-p256-minimum
+//	    profiles: ["web-*"]
+//	    action: warn
+//	    field: key_size
+//	    min: "256"
+//
+// Certificates with keys derived from an insecure test seed are denied unless the
+// policy sets allow_insecure_test_seed.
+package policy
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Action constants
+const (
+	ActionDeny = "deny"
+	ActionWarn = "warn"
+)
+
+// Policy is a list of rules evaluated in order
+type Policy struct {
+	Rules []*Rule `yaml:"rules"`
+
+	// AllowInsecureTestSeed permits certificates whose keys, serial numbers and
+	// signatures are derived from an insecure test seed
+	AllowInsecureTestSeed bool `yaml:"allow_insecure_test_seed,omitempty"`
+}
+
+// InsecureTestSeedRule is the name of the rule denying insecure test seeds
+const InsecureTestSeedRule = "insecure-test-seed"
+
+// Rule constrains the values of a single field
+type Rule struct {
+	Name string `yaml:"name"`
+
+	// Action is "deny" (the default) or "warn"
+	Action string `yaml:"action,omitempty"`
+
+	// Profiles limits the rule to profiles whose name matches one of the patterns
+	Profiles []string `yaml:"profiles,omitempty"`
+
+	// When limits the rule to certificates where every listed field has a value
+	// matching one of the patterns
+	When map[string][]string `yaml:"when,omitempty"`
+
+	// Field is the field path the rule constrains, e.g. "dns_names" or "subject.country"
+	Field string `yaml:"field"`
+
+	// Allow lists the patterns every value must match
+	Allow []string `yaml:"allow,omitempty"`
+	// Deny lists the patterns no value may match
+	Deny []string `yaml:"deny,omitempty"`
+	// Require lists the patterns that some value must match
+	Require []string `yaml:"require,omitempty"`
+	// Required demands that the field has a value
+	Required bool `yaml:"required,omitempty"`
+
+	// Min and Max bound numeric fields, and the validity as a duration such as "30d"
+	Min string `yaml:"min,omitempty"`
+	Max string `yaml:"max,omitempty"`
+}
+
+// Result is a single policy violation
+type Result struct {
+	Rule   string
+	Action string
+	// Field is the field path that violates the rule
+	Field   string
+	Message string
+}
+
+// String returns the result as "action: field: message (rule 'name')"
+func (r Result) String() string {
+	return fmt.Sprintf("%s: %s: %s (rule '%s')", r.Action, r.Field, r.Message, r.Rule)
+}
+
+// DeniedError is returned when a certificate violates rules with the deny action
+type DeniedError struct {
+	Profile string
+	Results []Result
+}
+
+func (e *DeniedError) Error() string {
+	r := e.Results[0]
+	msg := fmt.Sprintf("policy denies %s: %s (rule '%s')", r.Field, r.Message, r.Rule)
+	if e.Profile != "" {
+		msg = fmt.Sprintf("profile '%s': %s", e.Profile, msg)
+	}
+	if len(e.Results) > 1 {
+		msg += fmt.Sprintf(" (and %d more violations)", len(e.Results)-1)
+	}
+	return msg
+}
+
+// Parse parses and validates a YAML policy document
+func Parse(data []byte) (*Policy, error) {
+	p := &Policy{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Load reads a YAML policy document from a file
+func Load(filename string) (*Policy, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Validate checks that every rule names a known field and has a valid constraint
+func (p *Policy) Validate() error {
+	for i, rule := range p.Rules {
+		if rule == nil {
+			return fmt.Errorf("rule %d is empty", i+1)
+		}
+		if rule.Name == "" {
+			rule.Name = strconv.Itoa(i + 1)
+		}
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rule '%s': %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) validate() error {
+	switch r.Action {
+	case "", ActionDeny, ActionWarn:
+	default:
+		return fmt.Errorf("unknown action '%s'", r.Action)
+	}
+
+	kind, ok := fieldKind(r.Field)
+	if !ok {
+		return fmt.Errorf("unknown field '%s'", r.Field)
+	}
+	for field := range r.When {
+		if _, ok := fieldKind(field); !ok {
+			return fmt.Errorf("unknown field '%s' in when", field)
+		}
+	}
+
+	patterns := append(append(append(append([]string{}, r.Profiles...), r.Allow...), r.Deny...), r.Require...)
+	for _, values := range r.When {
+		patterns = append(patterns, values...)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s'", pattern)
+		}
+	}
+
+	for _, bound := range []string{r.Min, r.Max} {
+		if bound == "" {
+			continue
+		}
+		if kind == kindList || kind == kindString {
+			return fmt.Errorf("min and max are not supported for field '%s'", r.Field)
+		}
+		if _, err := parseBound(kind, bound); err != nil {
+			return err
+		}
+	}
+
+	if len(r.Allow) == 0 && len(r.Deny) == 0 && len(r.Require) == 0 && !r.Required && r.Min == "" && r.Max == "" {
+		return fmt.Errorf("no constraint, expected allow, deny, require, required, min or max")
+	}
+	return nil
+}
+
+// Evaluate checks a certificate built or issued for the named profile against
+// every applicable rule. Fields that cannot be determined, such as the key size
+// of a template without a public key, are not checked.
+func (p *Policy) Evaluate(profile string, cert *x509.Certificate) []Result {
+	spec := internal.SpecFromCertificate(cert)
+
+	var results []Result
+	for _, rule := range p.Rules {
+		if rule.applies(profile, cert, spec) {
+			results = append(results, rule.check(cert, spec)...)
+		}
+	}
+	return results
+}
+
+// Check evaluates the policy and returns a *DeniedError for any deny results,
+// together with the warnings
+func (p *Policy) Check(profile string, cert *x509.Certificate) (warnings []Result, err error) {
+	var denied []Result
+	for _, result := range p.Evaluate(profile, cert) {
+		if result.Action == ActionWarn {
+			warnings = append(warnings, result)
+		} else {
+			denied = append(denied, result)
+		}
+	}
+
+	if len(denied) > 0 {
+		return warnings, &DeniedError{Profile: profile, Results: denied}
+	}
+	return warnings, nil
+}
+
+// CheckInsecureTestSeed returns a *DeniedError for a certificate of the named
+// profile derived from an insecure test seed, unless the policy allows it
+func (p *Policy) CheckInsecureTestSeed(profile string) error {
+	if p.AllowInsecureTestSeed {
+		return nil
+	}
+	return &DeniedError{Profile: profile, Results: []Result{{
+		Rule:    InsecureTestSeedRule,
+		Action:  ActionDeny,
+		Field:   "insecure_test_seed",
+		Message: "keys derived from a test seed are not secret, set allow_insecure_test_seed for test fixtures",
+	}}}
+}
+
+// applies reports whether the rule's profile patterns and conditions match
+func (r *Rule) applies(profile string, cert *x509.Certificate, spec *internal.CertificateSpec) bool {
+	if len(r.Profiles) > 0 && !matchAny("", r.Profiles, profile) {
+		return false
+	}
+
+	for field, patterns := range r.When {
+		values, _ := fieldValues(field, cert, spec)
+		matched := false
+		for _, value := range values {
+			if matchAny(field, patterns, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// check returns the violations of the rule
+func (r *Rule) check(cert *x509.Certificate, spec *internal.CertificateSpec) []Result {
+	values, known := fieldValues(r.Field, cert, spec)
+	if !known {
+		return nil
+	}
+
+	var results []Result
+	report := func(format string, args ...interface{}) {
+		action := r.Action
+		if action == "" {
+			action = ActionDeny
+		}
+		results = append(results, Result{Rule: r.Name, Action: action, Field: r.Field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if r.Required && len(values) == 0 {
+		report("a value is required")
+	}
+	for _, value := range values {
+		if len(r.Allow) > 0 && !matchAny(r.Field, r.Allow, value) {
+			report("'%s' is not allowed", value)
+		}
+		if matchAny(r.Field, r.Deny, value) {
+			report("'%s' is denied", value)
+		}
+	}
+	for _, pattern := range r.Require {
+		found := false
+		for _, value := range values {
+			if match(r.Field, pattern, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("a value matching '%s' is required", pattern)
+		}
+	}
+
+	kind, _ := fieldKind(r.Field)
+	for _, value := range values {
+		if r.Min == "" && r.Max == "" {
+			break
+		}
+		// Values that cannot be compared violate the rule rather than pass it
+		n, err := boundValue(kind, value, cert)
+		if err != nil {
+			report("%v", err)
+			continue
+		}
+		if r.Min != "" {
+			if c, err := compareBound(kind, n, r.Min); err != nil {
+				report("invalid minimum: %v", err)
+			} else if c < 0 {
+				report("%s is below the minimum of %s", value, r.Min)
+			}
+		}
+		if r.Max != "" {
+			if c, err := compareBound(kind, n, r.Max); err != nil {
+				report("invalid maximum: %v", err)
+			} else if c > 0 {
+				report("%s exceeds the maximum of %s", value, r.Max)
+			}
+		}
+	}
+
+	return results
+}
+
+// matchAny reports whether a value of the field matches one of the glob patterns
+func matchAny(field string, patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if match(field, pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether a value of the field matches a glob pattern. DNS names and
+// the domains of email addresses are compared case-insensitively and label by label:
+// '*' matches a single label like a certificate wildcard, '**' one or more labels.
+func match(field, pattern, value string) bool {
+	switch field {
+	case "dns_names":
+		return matchDomain(pattern, value)
+	case "email_addresses":
+		patternLocal, patternDomain, ok := strings.Cut(pattern, "@")
+		local, domain, found := strings.Cut(value, "@")
+		if !ok || !found {
+			return false
+		}
+		matched, _ := path.Match(patternLocal, local)
+		return matched && matchDomain(patternDomain, domain)
+	}
+	matched, _ := path.Match(pattern, value)
+	return matched
+}
+
+// matchDomain matches a domain against a pattern one label at a time
+func matchDomain(pattern, domain string) bool {
+	return matchLabels(strings.Split(strings.ToLower(pattern), "."), strings.Split(strings.ToLower(domain), "."))
+}
+
+func matchLabels(patterns, labels []string) bool {
+	if len(patterns) == 0 {
+		return len(labels) == 0
+	}
+	if patterns[0] == "**" {
+		for i := 1; i <= len(labels); i++ {
+			if matchLabels(patterns[1:], labels[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(labels) == 0 {
+		return false
+	}
+	matched, _ := path.Match(patterns[0], labels[0])
+	return matched && matchLabels(patterns[1:], labels[1:])
+}
@@ -0,0 +1,275 @@
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+func mustParse(t *testing.T, data string) *Policy {
+	t.Helper()
+
+	p, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	return p
+}
+
+func serverCertificate() *x509.Certificate {
+	notBefore := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "www.corp.example.com", Country: []string{"NL"}},
+		DNSNames:    []string{"www.corp.example.com", "www.example.org"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		NotBefore:   notBefore,
+		NotAfter:    notBefore.Add(90 * 24 * time.Hour),
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"rules:\n  - field: dns_names\n    action: block\n    allow: [a]":    "rule '1': unknown action 'block'",
+		"rules:\n  - name: x\n    field: dns_name\n    allow: [a]":           "rule 'x': unknown field 'dns_name'",
+		"rules:\n  - field: subject.organisation\n    allow: [a]":            "unknown field 'subject.organisation'",
+		"rules:\n  - field: dns_names\n    when: {eku: [a]}\n    allow: [a]": "unknown field 'eku' in when",
+		"rules:\n  - field: dns_names\n    allow: ['[a']":                    "invalid pattern '[a'",
+		"rules:\n  - field: dns_names\n    max: \"3\"":                       "min and max are not supported for field 'dns_names'",
+		"rules:\n  - field: validity\n    max: 30 days":                      "invalid duration",
+		"rules:\n  - field: key_size\n    min: big":                          "invalid number 'big'",
+		"rules:\n  - field: dns_names":                                       "no constraint",
+		"rules:\n  -":                                                        "rule 1 is empty",
+	}
+
+	for data, expected := range tests {
+		if _, err := Parse([]byte(data)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q for %q, got %v", expected, data, err)
+		}
+	}
+}
+
+func TestEvaluate_AllowDenyRequire(t *testing.T) {
+	p := mustParse(t, `
+rules:
+  - name: corp-dns-only
+    field: dns_names
+    allow: ["*.corp.example.com"]
+  - name: no-wildcards
+    field: dns_names
+    deny: ["\\*.**"]
+  - name: server-auth
+    field: ext_key_usage
+    require: [server_auth]
+  - name: country
+    field: subject.country
+    allow: [NL, BE]
+  - name: organisation
+    action: warn
+    field: subject.organization
+    required: true
+`)
+
+	results := p.Evaluate("www", serverCertificate())
+
+	expected := []Result{
+		{Rule: "corp-dns-only", Action: ActionDeny, Field: "dns_names", Message: "'www.example.org' is not allowed"},
+		{Rule: "organisation", Action: ActionWarn, Field: "subject.organization", Message: "a value is required"},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, results)
+	}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], results[i])
+		}
+	}
+
+	cert := serverCertificate()
+	cert.DNSNames = []string{"*.corp.example.com"}
+	cert.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	results = p.Evaluate("www", cert)
+	if len(results) != 3 || results[0].Rule != "no-wildcards" || results[1].Message != "a value matching 'server_auth' is required" {
+		t.Errorf("Expected wildcard and missing server_auth violations, got %v", results)
+	}
+}
+
+func TestEvaluate_ProfilesAndConditions(t *testing.T) {
+	p := mustParse(t, `
+rules:
+  - name: short-lived-client-certs
+    when:
+      ext_key_usage: [client_auth]
+    field: validity
+    max: 30d
+  - name: web-profiles
+    profiles: ["web-*"]
+    field: subject.country
+    allow: [BE]
+`)
+
+	if results := p.Evaluate("www", serverCertificate()); len(results) != 0 {
+		t.Errorf("Expected no applicable rules, got %v", results)
+	}
+
+	cert := serverCertificate()
+	cert.ExtKeyUsage = append(cert.ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+	results := p.Evaluate("web-frontend", cert)
+	if len(results) != 2 {
+		t.Fatalf("Expected both rules to apply, got %v", results)
+	}
+	if results[0].String() != "deny: validity: 90d exceeds the maximum of 30d (rule 'short-lived-client-certs')" {
+		t.Errorf("Unexpected result: %s", results[0])
+	}
+	if results[1].Field != "subject.country" {
+		t.Errorf("Expected subject.country violation, got %v", results[1])
+	}
+}
+
+func TestEvaluate_DomainPatterns(t *testing.T) {
+	p := mustParse(t, `
+rules:
+  - name: no-internal
+    field: dns_names
+    deny: ["*.internal.example.com"]
+  - name: corp-mail
+    field: email_addresses
+    allow: ["*@**.example.com"]
+`)
+
+	tests := []struct {
+		dnsNames []string
+		emails   []string
+		expected []string
+	}{
+		{[]string{"a.INTERNAL.Example.com"}, nil, []string{"'a.INTERNAL.Example.com' is denied"}},
+		{[]string{"a.b.internal.example.com", "internal.example.com"}, nil, nil},
+		{nil, []string{"ops@Mail.EXAMPLE.com", "ops@a.b.example.com"}, nil},
+		{nil, []string{"ops@example.com", "ops@example.org"}, []string{"'ops@example.com' is not allowed", "'ops@example.org' is not allowed"}},
+	}
+
+	for _, tt := range tests {
+		cert := serverCertificate()
+		cert.DNSNames = tt.dnsNames
+		cert.EmailAddresses = tt.emails
+
+		var messages []string
+		for _, result := range p.Evaluate("www", cert) {
+			messages = append(messages, result.Message)
+		}
+		if strings.Join(messages, "; ") != strings.Join(tt.expected, "; ") {
+			t.Errorf("%v %v: expected %v, got %v", tt.dnsNames, tt.emails, tt.expected, messages)
+		}
+	}
+
+	// A single-label wildcard does not cover deeper names, '**' does
+	p = mustParse(t, "rules:\n  - field: dns_names\n    deny: [\"**.internal.example.com\"]")
+	cert := serverCertificate()
+	cert.DNSNames = []string{"a.b.Internal.example.com"}
+	if results := p.Evaluate("www", cert); len(results) != 1 {
+		t.Errorf("Expected '**' to deny names several labels deep, got %v", results)
+	}
+}
+
+func TestCheck_KeySize(t *testing.T) {
+	p := mustParse(t, `
+rules:
+  - name: strong-keys
+    field: key_size
+    min: "384"
+`)
+
+	template := serverCertificate()
+	if _, err := p.Check("www", template); err != nil {
+		t.Errorf("Expected key size to be skipped without a public key, got %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.PublicKey = key.Public()
+
+	_, err = p.Check("www", template)
+	var denied *DeniedError
+	if !errors.As(err, &denied) || denied.Profile != "www" {
+		t.Fatalf("Expected *DeniedError for profile 'www', got %v", err)
+	}
+	if err.Error() != "profile 'www': policy denies key_size: 256 is below the minimum of 384 (rule 'strong-keys')" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCheck_ZeroAndNegativeValidity(t *testing.T) {
+	p := mustParse(t, `
+rules:
+  - name: minimum-validity
+    field: validity
+    min: 1h
+`)
+
+	tests := map[string]time.Duration{
+		"zero":     0,
+		"negative": -48 * time.Hour,
+	}
+	for name, validity := range tests {
+		t.Run(name, func(t *testing.T) {
+			cert := serverCertificate()
+			cert.NotAfter = cert.NotBefore.Add(validity)
+
+			_, err := p.Check("www", cert)
+			var denied *DeniedError
+			if !errors.As(err, &denied) || denied.Results[0].Rule != "minimum-validity" {
+				t.Errorf("Expected the minimum validity rule to deny %s, got %v", internal.FormatDuration(validity), err)
+			}
+		})
+	}
+}
+
+func TestCheck_Warnings(t *testing.T) {
+	p := mustParse(t, `
+rules:
+  - action: warn
+    field: dns_names
+    deny: ["**.org"]
+  - field: is_ca
+    allow: ["false"]
+  - field: subject.country
+    deny: [NL]
+`)
+
+	cert := serverCertificate()
+	cert.IsCA = true
+	warnings, err := p.Check("", cert)
+
+	if len(warnings) != 1 || warnings[0].Rule != "1" {
+		t.Errorf("Expected one warning from rule '1', got %v", warnings)
+	}
+	if err == nil || err.Error() != "policy denies is_ca: 'true' is not allowed (rule '2') (and 1 more violations)" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCheckInsecureTestSeed(t *testing.T) {
+	err := mustParse(t, "rules: []").CheckInsecureTestSeed("web")
+
+	var denied *DeniedError
+	if !errors.As(err, &denied) || denied.Results[0].Rule != InsecureTestSeedRule {
+		t.Fatalf("Expected a DeniedError from rule '%s', got %v", InsecureTestSeedRule, err)
+	}
+	if !strings.HasPrefix(err.Error(), "profile 'web': policy denies insecure_test_seed: ") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if err := mustParse(t, "allow_insecure_test_seed: true").CheckInsecureTestSeed("web"); err != nil {
+		t.Errorf("Expected the seed to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package go_yaml_to_x509
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rschoonheim/go-yaml-to-x509/policy"
+)
+
+const testPolicy = `
+rules:
+  - name: corp-dns-only
+    field: dns_names
+    allow: ["*.corp.example.com"]
+  - name: short-lived-client-certs
+    when:
+      ext_key_usage: [client_auth]
+    field: validity
+    max: 30d
+  - name: organisation
+    action: warn
+    field: subject.organization
+    required: true
+`
+
+func mustPolicy(t *testing.T, data string) *policy.Policy {
+	t.Helper()
+
+	p, err := policy.Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	return p
+}
+
+func TestX509FromYamlWithOptions_Policy(t *testing.T) {
+	var warnings []policy.Result
+	opts := Options{
+		Policy:          mustPolicy(t, testPolicy),
+		OnPolicyWarning: func(r policy.Result) { warnings = append(warnings, r) },
+	}
+
+	_, err := X509FromYamlWithOptions([]byte(`
+subject:
+  common_name: "api.corp.example.com"
+dns_names: ["api.corp.example.com"]
+ext_key_usage: [server_auth]
+validity:
+  duration: "90d"
+`), opts)
+	if err != nil {
+		t.Fatalf("Expected server certificate to satisfy the policy, got %v", err)
+	}
+	if len(warnings) != 1 || warnings[0].Field != "subject.organization" {
+		t.Errorf("Expected organisation warning, got %v", warnings)
+	}
+
+	_, err = X509FromYamlWithOptions([]byte(`
+subject:
+  common_name: "client"
+  organization: "Example"
+ext_key_usage: [client_auth]
+validity:
+  duration: "90d"
+`), opts)
+	var denied *policy.DeniedError
+	if !errors.As(err, &denied) || denied.Results[0].Field != "validity" {
+		t.Fatalf("Expected validity to be denied, got %v", err)
+	}
+}
+
+func TestCertificatesFromYamlWithOptions_Policy(t *testing.T) {
+	_, err := CertificatesFromYamlWithOptions([]byte(`
+certificates:
+  api:
+    config:
+      subject:
+        common_name: "api.corp.example.com"
+      dns_names: ["api.corp.example.com"]
+  www:
+    config:
+      subject:
+        common_name: "www.example.com"
+      dns_names: ["www.example.com"]
+`), Options{Policy: mustPolicy(t, testPolicy)})
+
+	if err == nil || err.Error() != "profile 'www': policy denies dns_names: 'www.example.com' is not allowed (rule 'corp-dns-only')" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestIssue_Policy(t *testing.T) {
+	template := mustTemplate(t, `
+subject:
+  common_name: "Example CA"
+validity:
+  duration: "1y"
+is_ca: true
+basic_constraints_valid: true
+key_usage: [cert_sign]
+`)
+	opts := Options{Policy: mustPolicy(t, `
+rules:
+  - name: strong-ca-keys
+    profiles: [ca]
+    field: key_size
+    min: "384"
+`)}
+
+	if _, err := Issue(IssueRequest{Template: template, Profile: "other"}, opts); err != nil {
+		t.Errorf("Expected rule to be limited to the 'ca' profile, got %v", err)
+	}
+
+	_, err := Issue(IssueRequest{Template: template, Profile: "ca"}, opts)
+	var denied *policy.DeniedError
+	if !errors.As(err, &denied) || denied.Results[0].Message != "256 is below the minimum of 384" {
+		t.Errorf("Expected key size to be denied at issuance, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package go_yaml_to_x509
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// LoadPublicKey reads the key referenced by a spec's 'public_key': a PEM public key,
+// certificate request or certificate, given inline or as a file path. A certificate
+// request must carry a valid signature, proving possession of the private key.
+// Relative paths are read from the working directory; BuildCertificate reads them
+// from Options.IncludeFS, next to the document.
+func LoadPublicKey(ref string) (crypto.PublicKey, error) {
+	return loadPublicKey(nil, ref)
+}
+
+// loadPublicKey reads a 'public_key' reference, reading relative paths from fsys
+func loadPublicKey(fsys fs.FS, ref string) (crypto.PublicKey, error) {
+	data := []byte(ref)
+	source := "inline PEM"
+	if !strings.HasPrefix(strings.TrimSpace(ref), "-----BEGIN") {
+		var err error
+		if data, err = internal.ReadFile(fsys, ref); err != nil {
+			return nil, err
+		}
+		source = ref
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s: no public key, certificate request or certificate found", source)
+		}
+
+		key, err := parsePublicKeyBlock(block)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		if key != nil {
+			return key, nil
+		}
+	}
+}
+
+// parsePublicKeyBlock returns the public key in a PEM block, or nil for other block types
+func parsePublicKeyBlock(block *pem.Block) (crypto.PublicKey, error) {
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE REQUEST", "NEW CERTIFICATE REQUEST":
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if err := csr.CheckSignature(); err != nil {
+			return nil, fmt.Errorf("certificate request signature: %w", err)
+		}
+		return csr.PublicKey, nil
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	default:
+		return nil, nil
+	}
+}
+
+// publicKeyAlgorithm returns the algorithm of a public key
+func publicKeyAlgorithm(key crypto.PublicKey) x509.PublicKeyAlgorithm {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return x509.RSA
+	case *ecdsa.PublicKey:
+		return x509.ECDSA
+	case ed25519.PublicKey:
+		return x509.Ed25519
+	default:
+		return x509.UnknownPublicKeyAlgorithm
+	}
+}
+
+// applyPublicKey loads the spec's 'public_key' into the template, checking that it
+// matches public_key_algorithm when that is set
+func applyPublicKey(cert *x509.Certificate, spec *CertificateSpec, opts Options) error {
+	key, err := loadPublicKey(opts.IncludeFS, spec.PublicKey)
+	if err != nil {
+		return fmt.Errorf("public_key: %w", err)
+	}
+
+	alg := publicKeyAlgorithm(key)
+	if alg == x509.UnknownPublicKeyAlgorithm {
+		return fmt.Errorf("public_key: unsupported key type %T", key)
+	}
+	if cert.PublicKeyAlgorithm != x509.UnknownPublicKeyAlgorithm && cert.PublicKeyAlgorithm != alg {
+		return fmt.Errorf("public_key_algorithm '%s' does not match the %s key in public_key", spec.PublicKeyAlgorithm, internal.FormatPublicKeyAlgorithm(alg))
+	}
+
+	cert.PublicKey = key
+	cert.PublicKeyAlgorithm = alg
+	return nil
+}
+
+// publicKeyEqual reports whether two public keys are the same
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
@@ -0,0 +1,160 @@
+package go_yaml_to_x509
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePublicKeyFiles writes key as a PEM public key, a certificate request and a
+// self-signed certificate, and returns the paths by kind
+func writePublicKeyFiles(t *testing.T, key *ecdsa.PrivateKey) map[string]string {
+	t.Helper()
+
+	pkix, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := Issue(IssueRequest{Template: mustTemplate(t, "subject: {common_name: device}\n"), Signer: key, PublicKey: key.Public()}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	paths := make(map[string]string)
+	for kind, block := range map[string]*pem.Block{
+		"public key":          {Type: "PUBLIC KEY", Bytes: pkix},
+		"certificate request": {Type: "CERTIFICATE REQUEST", Bytes: csr},
+		"certificate":         {Type: "CERTIFICATE", Bytes: cert.Certificate.Raw},
+	} {
+		paths[kind] = filepath.Join(dir, strings.ReplaceAll(kind, " ", "-")+".pem")
+		if err := os.WriteFile(paths[kind], pem.EncodeToMemory(block), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func TestPublicKey_Sources(t *testing.T) {
+	deviceKey, err := GenerateKey(x509.ECDSA, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := deviceKey.(*ecdsa.PrivateKey)
+	paths := writePublicKeyFiles(t, key)
+
+	ca, err := Issue(IssueRequest{Template: mustTemplate(t, "subject: {common_name: Example CA}\nis_ca: true\nbasic_constraints_valid: true\nkey_usage: [cert_sign]\n")}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inline, err := os.ReadFile(paths["public key"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := map[string]string{"inline PEM": "|\n  " + strings.ReplaceAll(strings.TrimSpace(string(inline)), "\n", "\n  ")}
+	for kind, path := range paths {
+		refs[kind] = `"` + path + `"`
+	}
+
+	for kind, ref := range refs {
+		t.Run(kind, func(t *testing.T) {
+			template, err := X509FromYaml([]byte("subject:\n  common_name: device\npublic_key_algorithm: ECDSA\npublic_key: " + ref + "\n"))
+			if err != nil {
+				t.Fatalf("Failed to build certificate: %v", err)
+			}
+
+			issued, err := Issue(IssueRequest{Template: template, Parent: ca.Certificate, Signer: ca.PrivateKey}, Options{})
+			if err != nil {
+				t.Fatalf("Failed to issue certificate: %v", err)
+			}
+			if issued.PrivateKey != nil {
+				t.Error("Expected no generated key for a supplied public key")
+			}
+			if !key.PublicKey.Equal(issued.Certificate.PublicKey) {
+				t.Error("Expected the certificate to certify the supplied key")
+			}
+		})
+	}
+
+	// A relative path is read from the include filesystem, next to the document
+	dir, file := filepath.Split(paths["public key"])
+	template, err := X509FromYaml([]byte("subject:\n  common_name: device\npublic_key: "+file+"\n"), WithIncludeFS(os.DirFS(dir)))
+	if err != nil || !key.PublicKey.Equal(template.PublicKey) {
+		t.Errorf("Expected the key to be read relative to the include filesystem, got %v", err)
+	}
+	_, err = X509FromYaml([]byte("subject:\n  common_name: device\npublic_key: ../"+file+"\n"), WithIncludeFS(os.DirFS(dir)))
+	if err == nil || !strings.Contains(err.Error(), "invalid path '../"+file+"'") {
+		t.Errorf("Expected a path outside the include filesystem to fail, got %v", err)
+	}
+}
+
+func TestPublicKey_Errors(t *testing.T) {
+	deviceKey, err := GenerateKey(x509.ECDSA, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := writePublicKeyFiles(t, deviceKey.(*ecdsa.PrivateKey))
+
+	// A request whose signature no longer matches its contents
+	data, err := os.ReadFile(paths["certificate request"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	block.Bytes[len(block.Bytes)-1] ^= 0xff
+	tampered := filepath.Join(t.TempDir(), "tampered.pem")
+	if err := os.WriteFile(tampered, pem.EncodeToMemory(block), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		yaml     string
+		expected string
+	}{
+		{"algorithm mismatch", "public_key_algorithm: RSA\npublic_key: " + paths["public key"], "public_key_algorithm 'RSA' does not match the ECDSA key in public_key"},
+		{"missing file", "public_key: testdata/missing.pem", "public_key: open testdata/missing.pem"},
+		{"no key", "public_key: keyfile/testdata/ec.pem", "public_key: keyfile/testdata/ec.pem: no public key, certificate request or certificate found"},
+		{"tampered request", "public_key: " + tampered, "certificate request signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := X509FromYaml([]byte("subject:\n  common_name: device\n" + tt.yaml + "\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestPublicKey_Ed25519SetsAlgorithm(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &CertificateSpec{PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))}
+	cert, err := BuildCertificate(spec, Options{})
+	if err != nil {
+		t.Fatalf("Failed to build certificate: %v", err)
+	}
+	if cert.PublicKeyAlgorithm != x509.Ed25519 || !pub.Equal(cert.PublicKey) {
+		t.Errorf("Expected the Ed25519 key and algorithm, got %v %T", cert.PublicKeyAlgorithm, cert.PublicKey)
+	}
+}
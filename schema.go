@@ -0,0 +1,19 @@
+package go_yaml_to_x509
+
+import (
+	"encoding/json"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) for the YAML format, generated
+// from the document types with enums for key usages, extended key usages and
+// algorithms. Editors such as VS Code (via the YAML extension) can use it for
+// autocompletion and validation.
+func JSONSchema() ([]byte, error) {
+	data, err := json.MarshalIndent(internal.Schema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,70 @@
+package go_yaml_to_x509
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const schemaFile = "yaml-to-x509.schema.json"
+
+func TestJSONSchema_InSyncWithFile(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	committed, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(schema, committed) {
+		t.Errorf("%s is out of date, regenerate it with: go run ./cmd/yaml2x509 schema > %s", schemaFile, schemaFile)
+	}
+}
+
+func TestJSONSchema_ExamplesUseKnownKeys(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	examples, err := filepath.Glob(filepath.Join(".examples", "*.yaml"))
+	if err != nil || len(examples) == 0 {
+		t.Fatalf("Expected example files, got %v", err)
+	}
+	for _, example := range examples {
+		data, err := os.ReadFile(example)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			var doc map[string]interface{}
+			if err := decoder.Decode(&doc); err != nil {
+				if !errors.Is(err, io.EOF) {
+					t.Errorf("%s: %v", example, err)
+				}
+				break
+			}
+			for key := range doc {
+				if _, ok := parsed.Properties[key]; !ok {
+					t.Errorf("%s: key '%s' is not in the schema", example, key)
+				}
+			}
+		}
+	}
+}
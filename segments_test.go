@@ -233,3 +233,99 @@ basic_constraints_valid: true
 		t.Error("Expected KeyUsageDigitalSignature")
 	}
 }
+
+func TestX509FromYaml_SegmentConflicts(t *testing.T) {
+	yamlData := []byte(`
+conflicts: error
+
+segments:
+  defaults:
+    signature_algorithm: "SHA256WithRSA"
+    not_after: "2026-01-01T00:00:00Z"
+  legacy:
+    signature_algorithm: "SHA1WithRSA"
+
+merge:
+  - defaults
+  - legacy
+
+config:
+  subject:
+    common_name: "example.com"
+`)
+
+	_, err := X509FromYaml(yamlData)
+	if err == nil {
+		t.Fatal("Expected conflict error, got nil")
+	}
+	expected := "segment 'legacy' sets signature_algorithm to 'SHA1WithRSA', conflicting with 'SHA256WithRSA' from segment 'defaults'"
+	if err.Error() != expected {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	var warnings []MergeConflict
+	cert, err := X509FromYamlWithOptions(yamlData, Options{
+		ConflictMode: "warn",
+		OnConflict: func(c MergeConflict) {
+			warnings = append(warnings, c)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error in warn mode, got %v", err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("Expected 1 warning, got %d", len(warnings))
+	}
+	if cert.SignatureAlgorithm != x509.SHA1WithRSA {
+		t.Errorf("Expected later segment to win in warn mode, got %v", cert.SignatureAlgorithm)
+	}
+}
+
+func TestX509FromYaml_ParameterisedSegments(t *testing.T) {
+	yamlData := []byte(`
+segments:
+  service:
+    params:
+      name:
+      namespace: default
+    subject:
+      common_name: "${name}.${namespace}.svc"
+    dns_names:
+      - "${name}.${namespace}.svc"
+      - "${name}.${namespace}.svc.cluster.local"
+
+merge:
+  - service(name=api, namespace=prod)
+`)
+
+	cert, err := X509FromYaml(yamlData)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	if cert.Subject.CommonName != "api.prod.svc" {
+		t.Errorf("Expected CommonName 'api.prod.svc', got '%s'", cert.Subject.CommonName)
+	}
+	if len(cert.DNSNames) != 2 || cert.DNSNames[1] != "api.prod.svc.cluster.local" {
+		t.Errorf("Unexpected DNS names: %v", cert.DNSNames)
+	}
+}
+
+func TestX509FromYaml_EmptySegment(t *testing.T) {
+	yamlData := []byte(`
+segments:
+  empty:
+merge: [empty]
+config:
+  subject:
+    common_name: example.com
+`)
+
+	cert, err := X509FromYaml(yamlData)
+	if err != nil {
+		t.Fatalf("Expected a segment without a body to be skipped, got %v", err)
+	}
+	if cert.Subject.CommonName != "example.com" {
+		t.Errorf("Expected common name 'example.com', got '%s'", cert.Subject.CommonName)
+	}
+}
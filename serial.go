@@ -0,0 +1,105 @@
+package go_yaml_to_x509
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// SerialCounter hands out serial numbers for the "sequential" serial_number strategy.
+// Implementations must never return the same value twice.
+type SerialCounter interface {
+	Next() (*big.Int, error)
+}
+
+// MemoryCounter is a SerialCounter kept in memory, starting after Last
+type MemoryCounter struct {
+	mu   sync.Mutex
+	Last *big.Int
+}
+
+// Next returns the next serial number
+func (c *MemoryCounter) Next() (*big.Int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Last == nil {
+		c.Last = new(big.Int)
+	}
+	c.Last = new(big.Int).Add(c.Last, big.NewInt(1))
+	return new(big.Int).Set(c.Last), nil
+}
+
+// FileCounter is a SerialCounter that persists the last issued serial number as a
+// decimal string in a file, so sequences survive restarts. It is safe for concurrent
+// use within a process, but not across processes sharing the same file.
+type FileCounter struct {
+	mu   sync.Mutex
+	Path string
+}
+
+// NewFileCounter returns a FileCounter storing its state in path
+func NewFileCounter(path string) *FileCounter {
+	return &FileCounter{Path: path}
+}
+
+// Next returns the next serial number and records it in the file
+func (c *FileCounter) Next() (*big.Int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last := new(big.Int)
+	data, err := os.ReadFile(c.Path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if _, ok := last.SetString(strings.TrimSpace(string(data)), 10); !ok {
+			return nil, fmt.Errorf("invalid serial counter file '%s'", c.Path)
+		}
+	}
+
+	next := last.Add(last, big.NewInt(1))
+
+	// Write to a temporary file and rename it so a crash never leaves a truncated counter
+	tmp := c.Path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(next.String()+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, c.Path); err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// parseSerialNumber resolves the serial_number field, which is either a literal
+// (decimal, 0x-prefixed hex or colon-separated hex) or a generation strategy
+func parseSerialNumber(value string, opts Options) (*big.Int, error) {
+	switch value {
+	case internal.SerialRandom:
+		return internal.RandomSerialNumber(opts.Rand)
+	case internal.SerialTimestamp:
+		return internal.TimestampSerialNumber(opts.now(), opts.Rand)
+	case internal.SerialSequential:
+		if opts.SerialCounter == nil {
+			return nil, fmt.Errorf("serial_number 'sequential' requires a SerialCounter")
+		}
+		serial, err := opts.SerialCounter.Next()
+		if err != nil {
+			return nil, fmt.Errorf("generating serial_number: %w", err)
+		}
+		if err := internal.ValidateSerialNumber(serial); err != nil {
+			return nil, err
+		}
+		return serial, nil
+	default:
+		return internal.ParseSerialNumber(value)
+	}
+}
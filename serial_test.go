@@ -0,0 +1,114 @@
+package go_yaml_to_x509
+
+import (
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestX509FromYaml_SerialNumberFormats(t *testing.T) {
+	tests := map[string]int64{
+		`serial_number: "255"`:   255,
+		`serial_number: "0xff"`:  255,
+		`serial_number: "00:ff"`: 255,
+	}
+
+	for yamlData, expected := range tests {
+		cert, err := X509FromYaml([]byte(yamlData))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", yamlData, err)
+			continue
+		}
+		if cert.SerialNumber.Int64() != expected {
+			t.Errorf("%s: expected %d, got %s", yamlData, expected, cert.SerialNumber)
+		}
+	}
+}
+
+func TestX509FromYaml_InvalidSerialNumber(t *testing.T) {
+	_, err := X509FromYaml([]byte(`serial_number: "0"`))
+
+	if err == nil || err.Error() != "serial_number must be positive" {
+		t.Errorf("Expected zero serial to be rejected, got %v", err)
+	}
+}
+
+func TestX509FromYaml_RandomSerialNumber(t *testing.T) {
+	first, err := X509FromYaml([]byte(`serial_number: random`))
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	second, err := X509FromYaml([]byte(`serial_number: random`))
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	if first.SerialNumber.Sign() <= 0 || first.SerialNumber.BitLen() > 159 {
+		t.Errorf("Expected positive serial of at most 159 bits, got %s", first.SerialNumber)
+	}
+	if first.SerialNumber.Cmp(second.SerialNumber) == 0 {
+		t.Error("Expected random serials to differ")
+	}
+}
+
+func TestX509FromYaml_SequentialSerialNumber(t *testing.T) {
+	_, err := X509FromYaml([]byte(`serial_number: sequential`))
+	if err == nil {
+		t.Error("Expected error without a SerialCounter")
+	}
+
+	opts := Options{SerialCounter: &MemoryCounter{Last: big.NewInt(41)}}
+	for _, expected := range []int64{42, 43} {
+		cert, err := X509FromYamlWithOptions([]byte(`serial_number: sequential`), opts)
+		if err != nil {
+			t.Fatalf("Failed to parse YAML: %v", err)
+		}
+		if cert.SerialNumber.Int64() != expected {
+			t.Errorf("Expected serial %d, got %s", expected, cert.SerialNumber)
+		}
+	}
+}
+
+func TestFileCounter_PersistsAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serial")
+
+	first, err := NewFileCounter(path).Next()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := NewFileCounter(path).Next()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if first.Int64() != 1 || second.Int64() != 2 {
+		t.Errorf("Expected serials 1 and 2, got %s and %s", first, second)
+	}
+}
+
+func TestFileCounter_Concurrent(t *testing.T) {
+	counter := NewFileCounter(filepath.Join(t.TempDir(), "serial"))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[int64]bool)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serial, err := counter.Next()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if seen[serial.Int64()] {
+				t.Errorf("Duplicate serial %s", serial)
+			}
+			seen[serial.Int64()] = true
+		}()
+	}
+	wg.Wait()
+}
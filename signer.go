@@ -0,0 +1,94 @@
+package go_yaml_to_x509
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rschoonheim/go-yaml-to-x509/signer"
+)
+
+// NewIssueRequest returns a request to issue template that signs through the spec's
+// 'signer' section. The signer's 'certificate' is the issuing CA certificate; without
+// one the certificate is self-signed and certifies the signer's own key. Without a
+// 'signer' section the request generates a key and self-signs. The spec's
+// 'insecure_test_seed' is carried into the request.
+//
+// Signers holding resources, such as PKCS#11 sessions, implement io.Closer and
+// should be closed once issuance is done. On error the signer is already closed.
+func NewIssueRequest(ctx context.Context, template *x509.Certificate, spec *CertificateSpec) (IssueRequest, error) {
+	return NewIssueRequestWithOptions(ctx, template, spec, Options{})
+}
+
+// NewIssueRequestWithOptions returns a request like NewIssueRequest, reading the
+// signer's relative paths from opts.IncludeFS so that they resolve against the
+// document like its includes
+func NewIssueRequestWithOptions(ctx context.Context, template *x509.Certificate, spec *CertificateSpec, opts Options) (IssueRequest, error) {
+	req := IssueRequest{Template: template}
+	if spec != nil && spec.InsecureTestSeed != "" {
+		req.InsecureTestSeed = []byte(spec.InsecureTestSeed)
+	}
+	if spec == nil || spec.Signer == nil {
+		return req, nil
+	}
+
+	if opts.IncludeFS != nil {
+		ctx = signer.WithFS(ctx, opts.IncludeFS)
+	}
+	s, err := signer.Open(ctx, spec.Signer)
+	if err != nil {
+		return req, err
+	}
+	if err := completeIssueRequest(ctx, &req, s, template, spec.Signer); err != nil {
+		// The caller only closes the signer of a request it gets back
+		if closer, ok := s.(io.Closer); ok {
+			closer.Close()
+		}
+		return IssueRequest{Template: template}, err
+	}
+	return req, nil
+}
+
+// completeIssueRequest sets the signer of a request, and its parent certificate or
+// the public key a self-signed certificate certifies
+func completeIssueRequest(ctx context.Context, req *IssueRequest, s crypto.Signer, template *x509.Certificate, spec *SignerSpec) error {
+	req.Signer = s
+
+	if spec.Certificate == "" {
+		if template.PublicKey != nil && !publicKeyEqual(template.PublicKey, s.Public()) {
+			return errors.New("public_key does not match the signer's key, which a self-signed certificate certifies")
+		}
+		req.PublicKey = s.Public()
+		return nil
+	}
+
+	parent, err := readCertificateFile(ctx, spec.Certificate)
+	if err != nil {
+		return fmt.Errorf("signer certificate: %w", err)
+	}
+	req.Parent = parent
+	return nil
+}
+
+// readCertificateFile reads the first certificate of a PEM file
+func readCertificateFile(ctx context.Context, path string) (*x509.Certificate, error) {
+	data, err := signer.ReadFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%s: no certificate found", path)
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package signer
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+
+	"github.com/rschoonheim/go-yaml-to-x509/keyfile"
+)
+
+// openFile opens an unencrypted PEM key file
+func openFile(ctx context.Context, spec *Spec) (crypto.Signer, error) {
+	if spec.Path == "" {
+		return nil, errors.New("path is required")
+	}
+
+	data, err := ReadFile(ctx, spec.Path)
+	if err != nil {
+		return nil, err
+	}
+	if keyfile.IsEncrypted(data) {
+		return nil, fmt.Errorf("%s: key is encrypted, use the encrypted-pem signer", spec.Path)
+	}
+	return parseKey(spec.Path, data, nil)
+}
+
+// openEncryptedPEM opens an encrypted PKCS#8 PEM key file with the spec's passphrase
+func openEncryptedPEM(ctx context.Context, spec *Spec) (crypto.Signer, error) {
+	if spec.Path == "" {
+		return nil, errors.New("path is required")
+	}
+
+	data, err := ReadFile(ctx, spec.Path)
+	if err != nil {
+		return nil, err
+	}
+	if !keyfile.IsEncrypted(data) {
+		return nil, fmt.Errorf("%s: key is not encrypted, use the file signer", spec.Path)
+	}
+
+	passphrase, err := ReadSecret(contextFS(ctx), spec.Passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("passphrase: %w", err)
+	}
+	return parseKey(spec.Path, data, passphrase)
+}
+
+// parseKey parses the key read from path, naming the file in errors like keyfile.Read
+func parseKey(path string, data, passphrase []byte) (crypto.Signer, error) {
+	key, err := keyfile.Parse(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,29 @@
+package signer
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+type fsKey struct{}
+
+// WithFS returns a context under which providers read relative paths, such as a key
+// file's 'path', from fsys instead of the working directory. Documents pass the
+// filesystem their includes are read from, so that paths resolve against them.
+func WithFS(ctx context.Context, fsys fs.FS) context.Context {
+	return context.WithValue(ctx, fsKey{}, fsys)
+}
+
+// ReadFile reads a file for a provider: relative paths from the filesystem set with
+// WithFS, absolute paths and every path without one from the operating system
+func ReadFile(ctx context.Context, name string) ([]byte, error) {
+	return internal.ReadFile(contextFS(ctx), name)
+}
+
+// contextFS returns the filesystem set with WithFS, or nil
+func contextFS(ctx context.Context) fs.FS {
+	fsys, _ := ctx.Value(fsKey{}).(fs.FS)
+	return fsys
+}
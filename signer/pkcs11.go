@@ -0,0 +1,304 @@
+//go:build pkcs11
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+)
+
+func init() {
+	Register("pkcs11", ProviderFunc(openPKCS11))
+}
+
+// Named curve object identifiers of the PKCS#11 CKA_EC_PARAMS attribute
+var (
+	oidP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidP384 = asn1.ObjectIdentifier{1, 3, 132, 0, 34}
+	oidP521 = asn1.ObjectIdentifier{1, 3, 132, 0, 35}
+)
+
+// digestInfoPrefixes are the DER encoded DigestInfo headers prepended to the
+// digest for RSA PKCS#1 v1.5 signatures with CKM_RSA_PKCS
+var digestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// modules counts the open signers of every PKCS#11 module. A module is initialised
+// and logged in to once per process, so only the last signer to close logs out and
+// finalizes it.
+var modules = struct {
+	sync.Mutex
+	open map[string]int
+}{open: make(map[string]int)}
+
+// pkcs11Signer signs with a private key held by a PKCS#11 token. A session can
+// only run one operation at a time, so signing is serialised.
+type pkcs11Signer struct {
+	mu      sync.Mutex
+	ctx     *pkcs11.Ctx
+	module  string
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	public  crypto.PublicKey
+}
+
+// openPKCS11 logs in to the token and finds the private and public key with the spec's label
+func openPKCS11(ctx context.Context, spec *Spec) (crypto.Signer, error) {
+	if spec.Module == "" || spec.Token == "" || spec.Label == "" {
+		return nil, errors.New("module, token and label are required")
+	}
+	pin, err := ReadSecret(contextFS(ctx), spec.PIN)
+	if err != nil {
+		return nil, fmt.Errorf("pin: %w", err)
+	}
+
+	p := pkcs11.New(spec.Module)
+	if p == nil {
+		return nil, fmt.Errorf("cannot load PKCS#11 module '%s'", spec.Module)
+	}
+	modules.Lock()
+	if err := p.Initialize(); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		modules.Unlock()
+		p.Destroy()
+		return nil, err
+	}
+	modules.open[spec.Module]++
+	modules.Unlock()
+
+	s := &pkcs11Signer{ctx: p, module: spec.Module}
+	if err := s.open(spec, string(pin)); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *pkcs11Signer) open(spec *Spec, pin string) error {
+	slot, err := findSlot(s.ctx, spec.Token)
+	if err != nil {
+		return err
+	}
+	if s.session, err = s.ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION); err != nil {
+		return err
+	}
+	if err := s.ctx.Login(s.session, pkcs11.CKU_USER, pin); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		return fmt.Errorf("login to token '%s': %w", spec.Token, err)
+	}
+
+	if s.key, err = s.findObject(pkcs11.CKO_PRIVATE_KEY, spec.Label); err != nil {
+		return err
+	}
+	public, err := s.findObject(pkcs11.CKO_PUBLIC_KEY, spec.Label)
+	if err != nil {
+		return err
+	}
+	s.public, err = s.publicKey(public)
+	return err
+}
+
+// findSlot returns the slot holding the token with the given label
+func findSlot(p *pkcs11.Ctx, token string) (uint, error) {
+	slots, err := p.GetSlotList(true)
+	if err != nil {
+		return 0, err
+	}
+	for _, slot := range slots {
+		info, err := p.GetTokenInfo(slot)
+		if err == nil && info.Label == token {
+			return slot, nil
+		}
+	}
+	return 0, fmt.Errorf("token '%s' not found", token)
+}
+
+// findObject returns the single object of a class with the given label
+func (s *pkcs11Signer) findObject(class uint, label string) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, err
+	}
+	objects, _, err := s.ctx.FindObjects(s.session, 2)
+	if finalErr := s.ctx.FindObjectsFinal(s.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	kind := "private"
+	if class == pkcs11.CKO_PUBLIC_KEY {
+		kind = "public"
+	}
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("%s key '%s' not found", kind, label)
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, fmt.Errorf("several %s keys are labelled '%s'", kind, label)
+	}
+}
+
+// publicKey reads an RSA or EC public key object
+func (s *pkcs11Signer) publicKey(object pkcs11.ObjectHandle) (crypto.PublicKey, error) {
+	attrs, err := s.ctx.GetAttributeValue(s.session, object, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil)})
+	if err != nil {
+		return nil, err
+	}
+
+	switch keyType := ulong(attrs[0].Value); keyType {
+	case pkcs11.CKK_RSA:
+		attrs, err := s.ctx.GetAttributeValue(s.session, object, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attrs[0].Value),
+			E: int(new(big.Int).SetBytes(attrs[1].Value).Int64()),
+		}, nil
+
+	case pkcs11.CKK_EC:
+		attrs, err := s.ctx.GetAttributeValue(s.session, object, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var oid asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(attrs[0].Value, &oid); err != nil {
+			return nil, fmt.Errorf("invalid EC parameters: %w", err)
+		}
+		var curve elliptic.Curve
+		switch {
+		case oid.Equal(oidP256):
+			curve = elliptic.P256()
+		case oid.Equal(oidP384):
+			curve = elliptic.P384()
+		case oid.Equal(oidP521):
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", oid)
+		}
+
+		var point []byte
+		if _, err := asn1.Unmarshal(attrs[1].Value, &point); err != nil {
+			return nil, fmt.Errorf("invalid EC point: %w", err)
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %d", keyType)
+	}
+}
+
+// Public returns the public key of the token's key pair
+func (s *pkcs11Signer) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs a digest with ECDSA, or with RSA PKCS#1 v1.5
+func (s *pkcs11Signer) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	var mechanism uint
+	var data []byte
+
+	switch s.public.(type) {
+	case *ecdsa.PublicKey:
+		mechanism, data = pkcs11.CKM_ECDSA, digest
+	case *rsa.PublicKey:
+		if _, ok := opts.(*rsa.PSSOptions); ok {
+			return nil, errors.New("RSA-PSS signatures are not supported")
+		}
+		prefix, ok := digestInfoPrefixes[opts.HashFunc()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported hash function %s", opts.HashFunc())
+		}
+		mechanism, data = pkcs11.CKM_RSA_PKCS, append(append([]byte{}, prefix...), digest...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(mechanism, nil)}, s.key); err != nil {
+		return nil, err
+	}
+	signature, err := s.ctx.Sign(s.session, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if mechanism == pkcs11.CKM_ECDSA {
+		// PKCS#11 returns r || s, x509 expects an ASN.1 sequence
+		half := len(signature) / 2
+		return asn1.Marshal(struct{ R, S *big.Int }{
+			new(big.Int).SetBytes(signature[:half]),
+			new(big.Int).SetBytes(signature[half:]),
+		})
+	}
+	return signature, nil
+}
+
+// Close closes the session and unloads the module. The last signer of a module
+// also logs out and finalizes it, as both apply to every session of the process.
+func (s *pkcs11Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		return nil
+	}
+
+	modules.Lock()
+	defer modules.Unlock()
+	modules.open[s.module]--
+	last := modules.open[s.module] == 0
+	if last {
+		delete(modules.open, s.module)
+	}
+
+	if s.session != 0 {
+		if last {
+			s.ctx.Logout(s.session)
+		}
+		s.ctx.CloseSession(s.session)
+	}
+	if last {
+		s.ctx.Finalize()
+	}
+	s.ctx.Destroy()
+	s.ctx = nil
+	return nil
+}
+
+// ulong decodes a CK_ULONG attribute value, which is in native byte order
+func ulong(b []byte) uint64 {
+	switch len(b) {
+	case 8:
+		return binary.NativeEndian.Uint64(b)
+	case 4:
+		return uint64(binary.NativeEndian.Uint32(b))
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,118 @@
+//go:build pkcs11
+
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+)
+
+// TestOpen_PKCS11 runs against SoftHSM. Initialise a token and point the test at it:
+//
+//	softhsm2-util --init-token --free --label yaml2x509 --pin 1234 --so-pin 1234
+//	PKCS11_MODULE=/usr/lib/softhsm/libsofthsm2.so PKCS11_TOKEN=yaml2x509 PKCS11_PIN=1234 \
+//	    go test -tags pkcs11 ./signer
+func TestOpen_PKCS11(t *testing.T) {
+	module, token := os.Getenv("PKCS11_MODULE"), os.Getenv("PKCS11_TOKEN")
+	if module == "" || token == "" {
+		t.Skip("PKCS11_MODULE and PKCS11_TOKEN are not set")
+	}
+	// A fresh label per run, as labels must identify a single key
+	label := "test-ca-" + rand.Text()
+	generateTokenKey(t, module, token, os.Getenv("PKCS11_PIN"), label)
+
+	s, err := Open(context.Background(), &Spec{
+		Type:   "pkcs11",
+		Module: module,
+		Token:  token,
+		Label:  label,
+		PIN:    &Secret{Env: "PKCS11_PIN"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to open signer: %v", err)
+	}
+	defer s.(io.Closer).Close()
+
+	digest := sha256.Sum256([]byte("to be signed"))
+	signature, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	if !ecdsa.VerifyASN1(s.Public().(*ecdsa.PublicKey), digest[:], signature) {
+		t.Error("Expected a valid ECDSA signature")
+	}
+
+	// Closing a second signer of the module keeps the first logged in
+	other, err := Open(context.Background(), &Spec{
+		Type:   "pkcs11",
+		Module: module,
+		Token:  token,
+		Label:  label,
+		PIN:    &Secret{Env: "PKCS11_PIN"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to open a second signer: %v", err)
+	}
+	other.(io.Closer).Close()
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA256); err != nil {
+		t.Errorf("Expected the first signer to keep working, got %v", err)
+	}
+}
+
+// generateTokenKey creates a P-256 key pair with the given label on the token
+func generateTokenKey(t *testing.T, module, token, pin, label string) {
+	t.Helper()
+
+	p := pkcs11.New(module)
+	if p == nil {
+		t.Fatalf("Cannot load %s", module)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Destroy()
+	defer p.Finalize()
+
+	slot, err := findSlot(p, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := p.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.CloseSession(session)
+	if err := p.Login(session, pkcs11.CKU_USER, pin); err != nil {
+		t.Fatal(err)
+	}
+	defer p.Logout(session)
+
+	p256, err := asn1.Marshal(oidP256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = p.GenerateKeyPair(session,
+		[]*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, p256),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		},
+		[]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
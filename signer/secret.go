@@ -0,0 +1,36 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// ReadSecret returns the value of a secret from its environment variable or file.
+// Relative file paths are read from fsys, or the working directory when it is nil.
+// A single trailing newline is removed from file contents.
+func ReadSecret(fsys fs.FS, s *Secret) ([]byte, error) {
+	switch {
+	case s == nil || (s.Env == "" && s.File == ""):
+		return nil, errors.New("no secret configured, expected env or file")
+	case s.Env != "" && s.File != "":
+		return nil, errors.New("secret cannot set both env and file")
+	case s.Env != "":
+		value, ok := os.LookupEnv(s.Env)
+		if !ok {
+			return nil, fmt.Errorf("environment variable '%s' is not set", s.Env)
+		}
+		return []byte(value), nil
+	default:
+		data, err := internal.ReadFile(fsys, s.File)
+		if err != nil {
+			return nil, err
+		}
+		data = bytes.TrimSuffix(data, []byte("\n"))
+		return bytes.TrimSuffix(data, []byte("\r")), nil
+	}
+}
@@ -0,0 +1,44 @@
+package signer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadSecret(t *testing.T) {
+	t.Setenv("TEST_SECRET", "from-env")
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, err := ReadSecret(nil, &Secret{Env: "TEST_SECRET"}); err != nil || string(value) != "from-env" {
+		t.Errorf("Expected secret from the environment, got %q (%v)", value, err)
+	}
+	if value, err := ReadSecret(nil, &Secret{File: path}); err != nil || string(value) != "from-file" {
+		t.Errorf("Expected secret from the file without newline, got %q (%v)", value, err)
+	}
+
+	fsys := fstest.MapFS{"secrets/pin": {Data: []byte("from-fs\r\n")}}
+	if value, err := ReadSecret(fsys, &Secret{File: "secrets/pin"}); err != nil || string(value) != "from-fs" {
+		t.Errorf("Expected relative secret from the filesystem, got %q (%v)", value, err)
+	}
+	if value, err := ReadSecret(fsys, &Secret{File: path}); err != nil || string(value) != "from-file" {
+		t.Errorf("Expected absolute secret from the operating system, got %q (%v)", value, err)
+	}
+
+	for secret, expected := range map[*Secret]string{
+		nil:                              "no secret configured",
+		{}:                               "no secret configured",
+		{Env: "TEST_SECRET", File: path}: "cannot set both env and file",
+		{Env: "TEST_SECRET_MISSING"}:     "environment variable 'TEST_SECRET_MISSING' is not set",
+		{File: path + ".missing"}:        "no such file or directory",
+	} {
+		if _, err := ReadSecret(nil, secret); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Package signer opens the keys that sign issued certificates through pluggable
+// providers, so that CA keys can live in encrypted files or hardware tokens
+// instead of plain PEM files. Profiles select a provider in their 'signer' section:
+//
+//	signer:
+//	  type: encrypted-pem
+//	  certificate: ca.pem
+//	  path: ca-key.pem
+//	  passphrase:
+//	    env: CA_KEY_PASSPHRASE
+//
+// The "file" and "encrypted-pem" providers are always available. The "pkcs11"
+// provider is included when building with the pkcs11 build tag, and further
+// providers can be added with Register.
+package signer
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// Spec is the 'signer' section of a profile
+type Spec = internal.SignerSpec
+
+// Secret refers to a passphrase or PIN kept in an environment variable or file
+type Secret = internal.SecretSpec
+
+// Provider opens the crypto.Signer a spec refers to
+type Provider interface {
+	Open(ctx context.Context, spec *Spec) (crypto.Signer, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface
+type ProviderFunc func(ctx context.Context, spec *Spec) (crypto.Signer, error)
+
+// Open calls f
+func (f ProviderFunc) Open(ctx context.Context, spec *Spec) (crypto.Signer, error) {
+	return f(ctx, spec)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]Provider{
+		"file":          ProviderFunc(openFile),
+		"encrypted-pem": ProviderFunc(openEncryptedPEM),
+	}
+)
+
+// Register makes a provider available under the given type. It panics when the
+// type is already registered.
+func Register(typ string, p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, exists := providers[typ]; exists {
+		panic(fmt.Sprintf("signer: provider '%s' is already registered", typ))
+	}
+	providers[typ] = p
+}
+
+// Types returns the registered provider types in sorted order
+func Types() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	types := make([]string, 0, len(providers))
+	for typ := range providers {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Open opens the signer a spec refers to with the provider registered for its type
+func Open(ctx context.Context, spec *Spec) (crypto.Signer, error) {
+	if spec == nil {
+		return nil, fmt.Errorf("no signer configured")
+	}
+
+	mu.RLock()
+	p, ok := providers[spec.Type]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signer type '%s'", spec.Type)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s, err := p.Open(ctx, spec)
+	if err != nil {
+		return nil, fmt.Errorf("signer '%s': %w", spec.Type, err)
+	}
+	return s, nil
+}
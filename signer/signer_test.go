@@ -0,0 +1,105 @@
+package signer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestOpen_File(t *testing.T) {
+	s, err := Open(context.Background(), &Spec{Type: "file", Path: "../keyfile/testdata/ec.pem"})
+	if err != nil {
+		t.Fatalf("Failed to open signer: %v", err)
+	}
+	if _, ok := s.Public().(*ecdsa.PublicKey); !ok {
+		t.Errorf("Expected ECDSA key, got %T", s.Public())
+	}
+
+	_, err = Open(context.Background(), &Spec{Type: "file", Path: "../keyfile/testdata/ec-aes256-sha256.pem"})
+	if err == nil || !strings.Contains(err.Error(), "key is encrypted, use the encrypted-pem signer") {
+		t.Errorf("Expected the file signer to reject encrypted keys, got %v", err)
+	}
+}
+
+func TestOpen_EncryptedPEM(t *testing.T) {
+	t.Setenv("TEST_CA_PASSPHRASE", "secret")
+
+	s, err := Open(context.Background(), &Spec{
+		Type:       "encrypted-pem",
+		Path:       "../keyfile/testdata/ec-aes256-sha256.pem",
+		Passphrase: &Secret{Env: "TEST_CA_PASSPHRASE"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to open signer: %v", err)
+	}
+	if _, ok := s.Public().(*ecdsa.PublicKey); !ok {
+		t.Errorf("Expected ECDSA key, got %T", s.Public())
+	}
+
+	tests := []struct {
+		spec     *Spec
+		expected string
+	}{
+		{&Spec{Type: "encrypted-pem", Path: "../keyfile/testdata/ec-aes256-sha256.pem"}, "signer 'encrypted-pem': passphrase: no secret configured"},
+		{&Spec{Type: "encrypted-pem", Path: "../keyfile/testdata/ec.pem"}, "key is not encrypted, use the file signer"},
+		{&Spec{Type: "encrypted-pem"}, "path is required"},
+	}
+	for _, tt := range tests {
+		if _, err := Open(context.Background(), tt.spec); err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error containing %q, got %v", tt.expected, err)
+		}
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	if _, err := Open(context.Background(), nil); err == nil || err.Error() != "no signer configured" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := Open(context.Background(), &Spec{Type: "vault"}); err == nil || err.Error() != "unknown signer type 'vault'" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Open(ctx, &Spec{Type: "file", Path: "../keyfile/testdata/ec.pem"}); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Register("test-memory", ProviderFunc(func(_ context.Context, spec *Spec) (crypto.Signer, error) {
+		if spec.Params["key"] != "ca" {
+			t.Errorf("Expected params to be passed to the provider, got %v", spec.Params)
+		}
+		return key, nil
+	}))
+
+	s, err := Open(context.Background(), &Spec{Type: "test-memory", Params: map[string]string{"key": "ca"}})
+	if err != nil || s != key {
+		t.Errorf("Expected the registered provider's signer, got %v (%v)", s, err)
+	}
+
+	found := false
+	for _, typ := range Types() {
+		found = found || typ == "test-memory"
+	}
+	if !found {
+		t.Errorf("Expected 'test-memory' in %v", Types())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a type twice to panic")
+		}
+	}()
+	Register("file", ProviderFunc(openFile))
+}
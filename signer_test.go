@@ -0,0 +1,188 @@
+package go_yaml_to_x509
+
+import (
+	"context"
+	"crypto"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rschoonheim/go-yaml-to-x509/signer"
+)
+
+func TestNewIssueRequest_FileSigner(t *testing.T) {
+	ctx := context.Background()
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+
+	// A self-signed CA certifies the signer's own key
+	caSpec, err := ResolveConfig(mustDocument(t, `
+subject:
+  common_name: "Example CA"
+is_ca: true
+basic_constraints_valid: true
+key_usage: [cert_sign]
+signer:
+  type: file
+  path: keyfile/testdata/ec.pem
+`), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate, err := BuildCertificate(caSpec, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := NewIssueRequest(ctx, caTemplate, caSpec)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	if req.Parent != nil || req.PublicKey == nil {
+		t.Fatalf("Expected a self-signed request for the signer's key, got %+v", req)
+	}
+	ca, err := Issue(req, Options{})
+	if err != nil {
+		t.Fatalf("Failed to issue CA: %v", err)
+	}
+	if ca.PrivateKey != nil {
+		t.Error("Expected no generated key for a signer-held key")
+	}
+	if err := os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate.Raw}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A leaf is signed by the CA key and names the CA certificate as its issuer
+	leafSpec := &CertificateSpec{
+		Subject: map[string]string{"common_name": "www.example.com"},
+		Signer:  &SignerSpec{Type: "file", Path: "keyfile/testdata/ec.pem", Certificate: caPath},
+	}
+	leafTemplate, err := BuildCertificate(leafSpec, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err = NewIssueRequest(ctx, leafTemplate, leafSpec)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	leaf, err := Issue(req, Options{})
+	if err != nil {
+		t.Fatalf("Failed to issue certificate: %v", err)
+	}
+	if err := leaf.Certificate.CheckSignatureFrom(ca.Certificate); err != nil {
+		t.Errorf("Expected certificate signed by the CA, got %v", err)
+	}
+
+	// The signer's key and certificate resolve against the include filesystem
+	relative := &CertificateSpec{Signer: &SignerSpec{Type: "file", Path: "testdata/ec.pem", Certificate: filepath.Base(caPath)}}
+	fsys := os.DirFS(filepath.Dir(caPath))
+	if err := os.Mkdir(filepath.Join(filepath.Dir(caPath), "testdata"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	key, err := os.ReadFile("keyfile/testdata/ec.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(caPath), "testdata", "ec.pem"), key, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	req, err = NewIssueRequestWithOptions(ctx, leafTemplate, relative, Options{IncludeFS: fsys})
+	if err != nil {
+		t.Fatalf("Failed to create request with relative paths: %v", err)
+	}
+	if req.Parent == nil || !req.Parent.Equal(ca.Certificate) {
+		t.Error("Expected the signer certificate to be read from the include filesystem")
+	}
+}
+
+func TestNewIssueRequest_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	req, err := NewIssueRequest(ctx, mustTemplate(t, "subject:\n  common_name: a\n"), &CertificateSpec{})
+	if err != nil || req.Signer != nil {
+		t.Errorf("Expected a plain request without a signer section, got %+v (%v)", req, err)
+	}
+
+	other, err := GenerateKey(0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := mustTemplate(t, "subject:\n  common_name: a\n")
+	template.PublicKey = other.Public()
+	_, err = NewIssueRequest(ctx, template, &CertificateSpec{Signer: &SignerSpec{Type: "file", Path: "keyfile/testdata/ec.pem"}})
+	if err == nil || err.Error() != "public_key does not match the signer's key, which a self-signed certificate certifies" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	_, err = NewIssueRequest(ctx, nil, &CertificateSpec{Signer: &SignerSpec{Type: "file", Path: "keyfile/testdata/ec.pem", Certificate: "keyfile/testdata/ec.pem"}})
+	if err == nil || !strings.Contains(err.Error(), "signer certificate: keyfile/testdata/ec.pem: no certificate found") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// closingSigner records whether it was closed
+type closingSigner struct {
+	crypto.Signer
+	closed bool
+}
+
+func (s *closingSigner) Close() error {
+	s.closed = true
+	return nil
+}
+
+// openedClosingSigners holds the signers opened by the "test-closing" provider
+var openedClosingSigners []*closingSigner
+
+// testContextKey marks the context a test passes down to the test-context provider
+type testContextKey struct{}
+
+func init() {
+	signer.Register("test-closing", signer.ProviderFunc(func(context.Context, *signer.Spec) (crypto.Signer, error) {
+		key, err := GenerateKey(0, nil)
+		if err != nil {
+			return nil, err
+		}
+		s := &closingSigner{Signer: key}
+		openedClosingSigners = append(openedClosingSigners, s)
+		return s, nil
+	}))
+	// test-context fails, naming the value the caller's context carries
+	signer.Register("test-context", signer.ProviderFunc(func(ctx context.Context, _ *signer.Spec) (crypto.Signer, error) {
+		return nil, fmt.Errorf("opened under '%v'", ctx.Value(testContextKey{}))
+	}))
+}
+
+func TestNewIssueRequest_ClosesSignerOnError(t *testing.T) {
+	openedClosingSigners = nil
+	defer func() { openedClosingSigners = nil }()
+
+	spec := &CertificateSpec{Signer: &SignerSpec{Type: "test-closing", Certificate: "testdata/missing.pem"}}
+	req, err := NewIssueRequest(context.Background(), mustTemplate(t, "subject:\n  common_name: a\n"), spec)
+	if err == nil || req.Signer != nil {
+		t.Fatalf("Expected an error without a signer, got %+v (%v)", req, err)
+	}
+	if opened := openedClosingSigners; len(opened) != 1 || !opened[0].closed {
+		t.Error("Expected the signer to be closed when the request fails")
+	}
+
+	spec.Signer.Certificate = ""
+	if _, err := NewIssueRequest(context.Background(), mustTemplate(t, "subject:\n  common_name: a\n"), spec); err != nil {
+		t.Fatal(err)
+	}
+	if opened := openedClosingSigners; len(opened) != 2 || opened[1].closed {
+		t.Error("Expected the signer of a returned request to stay open")
+	}
+}
+
+func mustDocument(t *testing.T, yamlData string) *ConfigDocument {
+	t.Helper()
+
+	doc, err := ParseDocument([]byte(yamlData))
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	return doc
+}
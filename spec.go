@@ -0,0 +1,146 @@
+package go_yaml_to_x509
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CertificateSpec describes a certificate with the fields of the YAML format. Specs
+// can be built in code, merged with MergeSpecs and turned into a template with
+// BuildCertificate.
+type CertificateSpec = internal.CertificateSpec
+
+// ValiditySpec describes a validity period relative to an anchor time
+type ValiditySpec = internal.ValiditySpec
+
+// ConfigDocument is a YAML document: a config with the segments it merges, or a
+// 'certificates' map of named entries sharing the segments
+type ConfigDocument = internal.ConfigDocument
+
+// CertificateEntry is a named certificate in a document's 'certificates' map
+type CertificateEntry = internal.CertificateEntry
+
+// SignerSpec is the 'signer' section selecting the key that signs issued certificates
+type SignerSpec = internal.SignerSpec
+
+// KeyEncryptionSpec is the 'key_encryption' section protecting generated private keys
+type KeyEncryptionSpec = internal.KeyEncryptionSpec
+
+// SecretSpec refers to a passphrase or PIN in an environment variable or file
+type SecretSpec = internal.SecretSpec
+
+// VarValue is the default value of a document variable
+type VarValue = internal.VarValue
+
+// ParseDocument parses a single YAML document. Documents in the simple format are
+// returned as a ConfigDocument whose config is the whole document.
+func ParseDocument(yamlData []byte) (*ConfigDocument, error) {
+	return ParseDocumentWithOptions(yamlData, Options{})
+}
+
+// ParseDocumentWithOptions parses like ParseDocument, rejecting unknown keys when
+// opts.Strict is set
+func ParseDocumentWithOptions(yamlData []byte, opts Options) (*ConfigDocument, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(yamlData, &node); err != nil {
+		return nil, err
+	}
+	return decodeDocument(&node, opts)
+}
+
+// ParseDocuments parses a multi-document YAML stream separated by '---'
+func ParseDocuments(yamlData []byte) ([]*ConfigDocument, error) {
+	return ParseDocumentsWithOptions(yamlData, Options{})
+}
+
+// ParseDocumentsWithOptions parses like ParseDocuments, rejecting unknown keys when
+// opts.Strict is set
+func ParseDocumentsWithOptions(yamlData []byte, opts Options) ([]*ConfigDocument, error) {
+	var docs []*ConfigDocument
+
+	decoder := yaml.NewDecoder(bytes.NewReader(yamlData))
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		doc, err := decodeDocument(&node, opts)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", len(docs)+1, err)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, nil
+}
+
+// decodeDocument decodes a YAML document node, strictly when opts.Strict is set
+func decodeDocument(node *yaml.Node, opts Options) (*ConfigDocument, error) {
+	if opts.Strict {
+		return internal.DecodeDocumentStrict(node)
+	}
+	return internal.DecodeDocument(node)
+}
+
+// MergeSpecs merges specs in order, with later specs overriding earlier ones.
+// Lists are combined without duplicates.
+func MergeSpecs(specs ...*CertificateSpec) *CertificateSpec {
+	return internal.MergeSpecs(specs...)
+}
+
+// ResolveConfig resolves a document that defines a single certificate into its
+// merged spec, interpolating variables and detecting conflicts as configured by opts
+func ResolveConfig(doc *ConfigDocument, opts Options) (*CertificateSpec, error) {
+	_, spec, err := resolveSingle(doc, opts)
+	return spec, err
+}
+
+// ResolveCertificates resolves every certificate a document defines, keyed by name.
+// A document without a 'certificates' map resolves to a single spec named after
+// its 'name' field.
+func ResolveCertificates(doc *ConfigDocument, opts Options) (map[string]*CertificateSpec, error) {
+	return internal.ResolveDocument(doc, "", opts.resolveOptions())
+}
+
+// resolveProfile parses and resolves YAML data that defines exactly one certificate
+// and returns its name and spec
+func resolveProfile(yamlData []byte, opts Options) (string, *CertificateSpec, error) {
+	doc, err := ParseDocumentWithOptions(yamlData, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	return resolveSingle(doc, opts)
+}
+
+// resolveSingle resolves a document that defines exactly one certificate and
+// returns its name and spec
+func resolveSingle(doc *ConfigDocument, opts Options) (string, *CertificateSpec, error) {
+	specs, err := ResolveCertificates(doc, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(specs) != 1 {
+		return "", nil, fmt.Errorf("document defines %d certificates, use CertificatesFromYaml", len(specs))
+	}
+
+	for name, spec := range specs {
+		return name, spec, nil
+	}
+	return "", nil, nil
+}
+
+// SpecFromCertificate returns the spec describing an existing certificate
+func SpecFromCertificate(cert *x509.Certificate) *CertificateSpec {
+	return internal.SpecFromCertificate(cert)
+}
@@ -0,0 +1,170 @@
+package go_yaml_to_x509_test
+
+import (
+	"crypto/x509"
+	"fmt"
+	"testing"
+
+	go_yaml_to_x509 "github.com/rschoonheim/go-yaml-to-x509"
+)
+
+func TestBuildCertificate_ProgrammaticSpec(t *testing.T) {
+	spec := &go_yaml_to_x509.CertificateSpec{
+		Subject:     map[string]string{"common_name": "www.example.com"},
+		Validity:    &go_yaml_to_x509.ValiditySpec{Duration: "90d"},
+		KeyUsage:    []string{"digital_signature"},
+		ExtKeyUsage: []string{"server_auth"},
+		DNSNames:    []string{"www.example.com"},
+	}
+
+	cert, err := go_yaml_to_x509.BuildCertificate(spec, go_yaml_to_x509.Options{})
+	if err != nil {
+		t.Fatalf("Failed to build certificate: %v", err)
+	}
+
+	if cert.Subject.CommonName != "www.example.com" || cert.KeyUsage != x509.KeyUsageDigitalSignature {
+		t.Errorf("Unexpected certificate: %+v", cert)
+	}
+	if days := cert.NotAfter.Sub(cert.NotBefore).Hours() / 24; days != 90 {
+		t.Errorf("Expected 90 day validity, got %v", days)
+	}
+}
+
+func TestResolveConfig_CustomSegments(t *testing.T) {
+	doc, err := go_yaml_to_x509.ParseDocument([]byte(`
+segments:
+  defaults:
+    key_usage: [digital_signature]
+merge: [defaults]
+config:
+  subject:
+    common_name: "${host}"
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse document: %v", err)
+	}
+
+	// Add a segment in code and merge it after the document's own
+	doc.Segments["tls"] = &go_yaml_to_x509.CertificateSpec{ExtKeyUsage: []string{"server_auth"}}
+	doc.Merge = append(doc.Merge, "tls")
+
+	spec, err := go_yaml_to_x509.ResolveConfig(doc, go_yaml_to_x509.Options{Vars: map[string][]string{"host": {"www.example.com"}}})
+	if err != nil {
+		t.Fatalf("Failed to resolve document: %v", err)
+	}
+
+	if spec.Subject["common_name"] != "www.example.com" {
+		t.Errorf("Expected interpolated common name, got %q", spec.Subject["common_name"])
+	}
+	if len(spec.KeyUsage) != 1 || len(spec.ExtKeyUsage) != 1 || spec.ExtKeyUsage[0] != "server_auth" {
+		t.Errorf("Expected merged usages, got %v and %v", spec.KeyUsage, spec.ExtKeyUsage)
+	}
+}
+
+func TestResolveCertificates(t *testing.T) {
+	docs, err := go_yaml_to_x509.ParseDocuments([]byte(`
+certificates:
+  www:
+    config:
+      subject:
+        common_name: "www.example.com"
+  api:
+    config:
+      subject:
+        common_name: "api.example.com"
+---
+name: ca
+is_ca: true
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse documents: %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("Expected 2 documents, got %d", len(docs))
+	}
+
+	specs, err := go_yaml_to_x509.ResolveCertificates(docs[0], go_yaml_to_x509.Options{})
+	if err != nil {
+		t.Fatalf("Failed to resolve certificates: %v", err)
+	}
+	if len(specs) != 2 || specs["api"].Subject["common_name"] != "api.example.com" {
+		t.Errorf("Unexpected specs: %v", specs)
+	}
+
+	if _, err := go_yaml_to_x509.ResolveConfig(docs[0], go_yaml_to_x509.Options{}); err == nil {
+		t.Error("Expected ResolveConfig to reject a document with several certificates")
+	}
+
+	specs, err = go_yaml_to_x509.ResolveCertificates(docs[1], go_yaml_to_x509.Options{})
+	if err != nil || specs["ca"] == nil || !specs["ca"].IsCA {
+		t.Errorf("Expected single spec named 'ca', got %v (%v)", specs, err)
+	}
+}
+
+func TestMergeSpecs(t *testing.T) {
+	merged := go_yaml_to_x509.MergeSpecs(
+		&go_yaml_to_x509.CertificateSpec{Subject: map[string]string{"country": "NL"}, DNSNames: []string{"a.example.com"}},
+		nil,
+		&go_yaml_to_x509.CertificateSpec{Subject: map[string]string{"common_name": "b"}, DNSNames: []string{"b.example.com"}},
+	)
+
+	if merged.Subject["country"] != "NL" || merged.Subject["common_name"] != "b" {
+		t.Errorf("Expected merged subject, got %v", merged.Subject)
+	}
+	if len(merged.DNSNames) != 2 {
+		t.Errorf("Expected combined DNS names, got %v", merged.DNSNames)
+	}
+}
+
+func TestSpecFromCertificate(t *testing.T) {
+	cert, err := go_yaml_to_x509.X509FromYaml([]byte(`
+subject:
+  common_name: "www.example.com"
+dns_names: ["www.example.com"]
+not_before: "2025-01-01T00:00:00Z"
+not_after: "2026-01-01T00:00:00Z"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := go_yaml_to_x509.SpecFromCertificate(cert)
+	if spec.Subject["common_name"] != "www.example.com" || spec.NotAfter != "2026-01-01T00:00:00Z" {
+		t.Errorf("Unexpected spec: %+v", spec)
+	}
+}
+
+func ExampleBuildCertificate() {
+	doc, err := go_yaml_to_x509.ParseDocument([]byte(`
+segments:
+  tls:
+    ext_key_usage: [server_auth]
+merge: [tls]
+config:
+  subject:
+    common_name: "www.example.com"
+`))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	spec, err := go_yaml_to_x509.ResolveConfig(doc, go_yaml_to_x509.Options{})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	// Post-process the resolved spec before building the template
+	spec.DNSNames = append(spec.DNSNames, spec.Subject["common_name"])
+
+	cert, err := go_yaml_to_x509.BuildCertificate(spec, go_yaml_to_x509.Options{})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	fmt.Printf("DNS Names: %v\n", cert.DNSNames)
+	// Output:
+	// DNS Names: [www.example.com]
+}
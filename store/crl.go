@@ -0,0 +1,38 @@
+package store
+
+import (
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+)
+
+// CreateCRL creates a DER-encoded certificate revocation list, signed by signer,
+// containing every revoked certificate in the store that was issued by issuer
+func CreateCRL(rand io.Reader, s Store, issuer *x509.Certificate, signer crypto.Signer, number *big.Int, thisUpdate, nextUpdate time.Time) ([]byte, error) {
+	records, err := s.List(Query{Issuer: issuer.Subject.String(), RevokedOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.RevocationList{
+		Number:     number,
+		ThisUpdate: thisUpdate,
+		NextUpdate: nextUpdate,
+	}
+	for _, record := range records {
+		serial := record.SerialNumber()
+		if serial == nil {
+			return nil, fmt.Errorf("invalid serial '%s' in store", record.Serial)
+		}
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: *record.RevokedAt,
+			ReasonCode:     record.RevocationReason,
+		})
+	}
+
+	return x509.CreateRevocationList(rand, template, issuer, signer)
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestCreateCRL(t *testing.T) {
+	ca, caKey := issueTestCertificate(t, 1, "Example CA")
+	s := NewMemoryStore()
+
+	revokedAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for serial, revoked := range map[string]bool{"a": true, "b": false, "c": true} {
+		record := Record{Serial: serial, Issuer: ca.Subject.String()}
+		if revoked {
+			record.RevokedAt = &revokedAt
+			record.RevocationReason = 1
+		}
+		_ = s.Add(record)
+	}
+	_ = s.Add(Record{Serial: "d", Issuer: "CN=Other CA", RevokedAt: &revokedAt})
+
+	der, err := CreateCRL(rand.Reader, s, ca, caKey, big.NewInt(1), revokedAt, revokedAt.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatalf("Failed to parse CRL: %v", err)
+	}
+	if err := crl.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("Expected CRL signed by CA, got %v", err)
+	}
+	if len(crl.RevokedCertificateEntries) != 2 {
+		t.Fatalf("Expected 2 revoked entries from this issuer, got %d", len(crl.RevokedCertificateEntries))
+	}
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Int64() != 10 && entry.SerialNumber.Int64() != 12 {
+			t.Errorf("Unexpected revoked serial %s", entry.SerialNumber)
+		}
+		if entry.ReasonCode != 1 {
+			t.Errorf("Expected reason code 1, got %d", entry.ReasonCode)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileStore is a Store persisted as a JSON lines file. Every change appends a full
+// record; when a serial number appears more than once the last line wins, so
+// revocations never rewrite existing lines. It is safe for concurrent use within a
+// process, but not across processes sharing the same file.
+type FileStore struct {
+	mu     sync.Mutex
+	path   string
+	memory *MemoryStore
+}
+
+// OpenFileStore opens or creates the JSON lines file at path
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, memory: NewMemoryStore()}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		s.memory.put(record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Add records a newly issued certificate
+func (s *FileStore) Add(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.memory.Get(record.SerialNumber()); err == nil {
+		return ErrDuplicateSerial
+	}
+	if err := s.append(record); err != nil {
+		return err
+	}
+	return s.memory.Add(record)
+}
+
+// Get returns the record for a serial number
+func (s *FileStore) Get(serial *big.Int) (Record, error) {
+	return s.memory.Get(serial)
+}
+
+// List returns the records matching the query
+func (s *FileStore) List(query Query) ([]Record, error) {
+	return s.memory.List(query)
+}
+
+// Revoke marks a certificate as revoked
+func (s *FileStore) Revoke(serial *big.Int, at time.Time, reason int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.memory.Get(serial)
+	if err != nil {
+		return err
+	}
+	record.RevokedAt = &at
+	record.RevocationReason = reason
+
+	if err := s.append(record); err != nil {
+		return err
+	}
+	return s.memory.Revoke(serial, at, reason)
+}
+
+// append writes a record as a single line at the end of the file
+func (s *FileStore) append(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
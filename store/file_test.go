@@ -0,0 +1,76 @@
+package store
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileStore_PersistsRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	cert, _ := issueTestCertificate(t, 100, "www.example.com")
+	if err := s.Add(NewRecord(cert, "web-server", time.Now())); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Revoke(big.NewInt(100), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), 4); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	reopened, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("Expected no error reopening store, got %v", err)
+	}
+	record, err := reopened.Get(big.NewInt(100))
+	if err != nil {
+		t.Fatalf("Expected record after reopening, got %v", err)
+	}
+	if record.Profile != "web-server" || !record.Revoked() || record.RevocationReason != 4 {
+		t.Errorf("Expected revoked web-server record, got %+v", record)
+	}
+
+	records, _ := reopened.List(Query{})
+	if len(records) != 1 {
+		t.Errorf("Expected revocation to update the record, got %d records", len(records))
+	}
+	if err := reopened.Add(record); !errors.Is(err, ErrDuplicateSerial) {
+		t.Errorf("Expected ErrDuplicateSerial after reopening, got %v", err)
+	}
+}
+
+func TestFileStore_AppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+	s, _ := OpenFileStore(path)
+
+	_ = s.Add(Record{Serial: "1"})
+	_ = s.Add(Record{Serial: "2"})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], `{"serial":"1"`) {
+		t.Errorf("Expected one JSON record per line, got %q", string(data))
+	}
+}
+
+func TestOpenFileStore_InvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "issued.jsonl")
+	if err := os.WriteFile(path, []byte("{\"serial\":\"1\"}\nnot json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := OpenFileStore(path)
+	if err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("Expected error pointing at line 2, got %v", err)
+	}
+}
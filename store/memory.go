@@ -0,0 +1,85 @@
+package store
+
+import (
+	"math/big"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store kept in memory
+type MemoryStore struct {
+	mu      sync.Mutex
+	records []Record
+	index   map[string]int
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{index: make(map[string]int)}
+}
+
+// Add records a newly issued certificate
+func (s *MemoryStore) Add(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.index[record.Serial]; exists {
+		return ErrDuplicateSerial
+	}
+	s.index[record.Serial] = len(s.records)
+	s.records = append(s.records, record)
+	return nil
+}
+
+// Get returns the record for a serial number
+func (s *MemoryStore) Get(serial *big.Int) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, exists := s.index[SerialString(serial)]
+	if !exists {
+		return Record{}, ErrNotFound
+	}
+	return s.records[i], nil
+}
+
+// List returns the records matching the query
+func (s *MemoryStore) List(query Query) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Record
+	for _, record := range s.records {
+		if query.Matches(record) {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
+
+// Revoke marks a certificate as revoked
+func (s *MemoryStore) Revoke(serial *big.Int, at time.Time, reason int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, exists := s.index[SerialString(serial)]
+	if !exists {
+		return ErrNotFound
+	}
+	s.records[i].RevokedAt = &at
+	s.records[i].RevocationReason = reason
+	return nil
+}
+
+// put inserts a record, replacing an existing record with the same serial number
+func (s *MemoryStore) put(record Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i, exists := s.index[record.Serial]; exists {
+		s.records[i] = record
+		return
+	}
+	s.index[record.Serial] = len(s.records)
+	s.records = append(s.records, record)
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_AddGetList(t *testing.T) {
+	s := NewMemoryStore()
+
+	if err := s.Add(Record{Serial: "1", Profile: "a"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Add(Record{Serial: "2", Profile: "b"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	record, err := s.Get(big.NewInt(2))
+	if err != nil || record.Profile != "b" {
+		t.Errorf("Expected record with profile 'b', got %+v, %v", record, err)
+	}
+
+	records, err := s.List(Query{})
+	if err != nil || len(records) != 2 || records[0].Serial != "1" {
+		t.Errorf("Expected both records in issuance order, got %v, %v", records, err)
+	}
+}
+
+func TestMemoryStore_DuplicateSerial(t *testing.T) {
+	s := NewMemoryStore()
+	_ = s.Add(Record{Serial: "1"})
+
+	if err := s.Add(Record{Serial: "1"}); !errors.Is(err, ErrDuplicateSerial) {
+		t.Errorf("Expected ErrDuplicateSerial, got %v", err)
+	}
+}
+
+func TestMemoryStore_Revoke(t *testing.T) {
+	s := NewMemoryStore()
+	_ = s.Add(Record{Serial: "a"})
+	at := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if err := s.Revoke(big.NewInt(10), at, 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := s.Revoke(big.NewInt(11), at, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	record, _ := s.Get(big.NewInt(10))
+	if !record.Revoked() || record.RevocationReason != 1 {
+		t.Errorf("Expected record to be revoked with reason 1, got %+v", record)
+	}
+}
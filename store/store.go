@@ -0,0 +1,136 @@
+// Package store records certificates issued from YAML profiles, enforcing serial
+// number uniqueness and providing inventory queries and CRL generation.
+package store
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDuplicateSerial is returned when a serial number has already been issued
+	ErrDuplicateSerial = errors.New("serial number already issued")
+
+	// ErrNotFound is returned when no certificate with the serial number was issued
+	ErrNotFound = errors.New("serial number not found")
+)
+
+// Record describes an issued certificate
+type Record struct {
+	Serial           string     `json:"serial"`
+	Subject          string     `json:"subject"`
+	Issuer           string     `json:"issuer"`
+	DNSNames         []string   `json:"dns_names,omitempty"`
+	IPAddresses      []string   `json:"ip_addresses,omitempty"`
+	EmailAddresses   []string   `json:"email_addresses,omitempty"`
+	URIs             []string   `json:"uris,omitempty"`
+	NotBefore        time.Time  `json:"not_before"`
+	NotAfter         time.Time  `json:"not_after"`
+	Profile          string     `json:"profile,omitempty"`
+	Fingerprint      string     `json:"fingerprint"`
+	IssuedAt         time.Time  `json:"issued_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevocationReason int        `json:"revocation_reason,omitempty"`
+}
+
+// NewRecord creates a Record for an issued certificate
+func NewRecord(cert *x509.Certificate, profile string, issuedAt time.Time) Record {
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	record := Record{
+		Serial:         SerialString(cert.SerialNumber),
+		Subject:        cert.Subject.String(),
+		Issuer:         cert.Issuer.String(),
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		NotBefore:      cert.NotBefore,
+		NotAfter:       cert.NotAfter,
+		Profile:        profile,
+		Fingerprint:    hex.EncodeToString(fingerprint[:]),
+		IssuedAt:       issuedAt,
+	}
+	for _, ip := range cert.IPAddresses {
+		record.IPAddresses = append(record.IPAddresses, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		record.URIs = append(record.URIs, uri.String())
+	}
+
+	return record
+}
+
+// SerialString formats a serial number as lowercase hex, the key used by stores
+func SerialString(serial *big.Int) string {
+	return serial.Text(16)
+}
+
+// SerialNumber parses the record's serial number
+func (r Record) SerialNumber() *big.Int {
+	serial, _ := new(big.Int).SetString(r.Serial, 16)
+	return serial
+}
+
+// Revoked reports whether the certificate has been revoked
+func (r Record) Revoked() bool {
+	return r.RevokedAt != nil
+}
+
+// Query selects records in Store.List. Zero-valued fields match every record.
+type Query struct {
+	Profile     string
+	Issuer      string
+	DNSName     string
+	ValidAt     time.Time
+	RevokedOnly bool
+}
+
+// Matches reports whether a record satisfies the query
+func (q Query) Matches(r Record) bool {
+	if q.Profile != "" && r.Profile != q.Profile {
+		return false
+	}
+	if q.Issuer != "" && r.Issuer != q.Issuer {
+		return false
+	}
+	if q.DNSName != "" && !containsFold(r.DNSNames, q.DNSName) {
+		return false
+	}
+	if !q.ValidAt.IsZero() && (q.ValidAt.Before(r.NotBefore) || q.ValidAt.After(r.NotAfter) || r.Revoked()) {
+		return false
+	}
+	if q.RevokedOnly && !r.Revoked() {
+		return false
+	}
+	return true
+}
+
+// Store records issued certificates
+type Store interface {
+	// Add records a newly issued certificate, returning ErrDuplicateSerial if its
+	// serial number was issued before. The check and the record are atomic, as
+	// Issue relies on Add alone to keep serial numbers unique.
+	Add(record Record) error
+
+	// Get returns the record for a serial number, or ErrNotFound
+	Get(serial *big.Int) (Record, error)
+
+	// List returns the records matching the query in issuance order
+	List(query Query) ([]Record, error)
+
+	// Revoke marks a certificate as revoked with an RFC 5280 reason code
+	Revoke(serial *big.Int, at time.Time, reason int) error
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// issueTestCertificate creates a self-signed certificate for store tests
+func issueTestCertificate(t *testing.T, serial int64, cn string) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              []string{cn},
+		IPAddresses:           []net.IP{net.ParseIP("10.0.0.1")},
+		NotBefore:             time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+func TestNewRecord(t *testing.T) {
+	cert, _ := issueTestCertificate(t, 255, "www.example.com")
+	issuedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	record := NewRecord(cert, "web-server", issuedAt)
+
+	if record.Serial != "ff" {
+		t.Errorf("Expected serial 'ff', got '%s'", record.Serial)
+	}
+	if record.Subject != "CN=www.example.com" {
+		t.Errorf("Expected subject 'CN=www.example.com', got '%s'", record.Subject)
+	}
+	if len(record.DNSNames) != 1 || len(record.IPAddresses) != 1 || record.IPAddresses[0] != "10.0.0.1" {
+		t.Errorf("Unexpected SANs: %v %v", record.DNSNames, record.IPAddresses)
+	}
+	if record.Profile != "web-server" || !record.IssuedAt.Equal(issuedAt) {
+		t.Error("Expected profile and issuance time to be recorded")
+	}
+	if len(record.Fingerprint) != 64 {
+		t.Errorf("Expected SHA-256 hex fingerprint, got '%s'", record.Fingerprint)
+	}
+	if record.SerialNumber().Int64() != 255 {
+		t.Errorf("Expected SerialNumber 255, got %s", record.SerialNumber())
+	}
+}
+
+func TestQuery_Matches(t *testing.T) {
+	revokedAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	record := Record{
+		Profile:   "web-server",
+		Issuer:    "CN=Example CA",
+		DNSNames:  []string{"www.example.com"},
+		NotBefore: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	revoked := record
+	revoked.RevokedAt = &revokedAt
+
+	tests := []struct {
+		name     string
+		query    Query
+		record   Record
+		expected bool
+	}{
+		{"empty query", Query{}, record, true},
+		{"profile", Query{Profile: "web-server"}, record, true},
+		{"other profile", Query{Profile: "client"}, record, false},
+		{"issuer", Query{Issuer: "CN=Other CA"}, record, false},
+		{"dns name case-insensitive", Query{DNSName: "WWW.example.com"}, record, true},
+		{"other dns name", Query{DNSName: "api.example.com"}, record, false},
+		{"valid at", Query{ValidAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}, record, true},
+		{"expired", Query{ValidAt: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)}, record, false},
+		{"revoked is not valid", Query{ValidAt: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)}, revoked, false},
+		{"revoked only", Query{RevokedOnly: true}, record, false},
+		{"revoked only match", Query{RevokedOnly: true}, revoked, true},
+	}
+
+	for _, tt := range tests {
+		if result := tt.query.Matches(tt.record); result != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, result)
+		}
+	}
+}
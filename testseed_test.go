@@ -0,0 +1,159 @@
+package go_yaml_to_x509
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+	"github.com/rschoonheim/go-yaml-to-x509/policy"
+)
+
+// issueSeeded runs the whole pipeline for a document, returning the certificate and key PEM
+func issueSeeded(t *testing.T, yamlData string, opts Options) ([]byte, []byte) {
+	t.Helper()
+
+	doc, err := ParseDocument([]byte(yamlData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := ResolveConfig(doc, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template, err := BuildCertificate(spec, opts)
+	if err != nil {
+		t.Fatalf("Failed to build certificate: %v", err)
+	}
+	req, err := NewIssueRequest(t.Context(), template, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued, err := Issue(req, opts)
+	if err != nil {
+		t.Fatalf("Failed to issue certificate: %v", err)
+	}
+	key, err := MarshalPrivateKey("test", issued.PrivateKey, spec, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return issued.Certificate.Raw, key
+}
+
+func TestInsecureTestSeed_Reproducible(t *testing.T) {
+	for _, alg := range []string{"RSA", "ECDSA", "Ed25519"} {
+		t.Run(alg, func(t *testing.T) {
+			yamlData := `
+subject:
+  common_name: "fixture.example.com"
+validity:
+  duration: 90d
+public_key_algorithm: ` + alg + `
+insecure_test_seed: "golden-v1"
+`
+			cert, key := issueSeeded(t, yamlData, Options{})
+			again, againKey := issueSeeded(t, yamlData, Options{})
+			if !bytes.Equal(cert, again) || !bytes.Equal(key, againKey) {
+				t.Fatal("Expected the same certificate and key from the same seed")
+			}
+
+			other, otherKey := issueSeeded(t, strings.Replace(yamlData, "golden-v1", "golden-v2", 1), Options{})
+			if bytes.Equal(cert, other) || bytes.Equal(key, otherKey) {
+				t.Error("Expected a different certificate and key from a different seed")
+			}
+
+			parsed, err := x509.ParseCertificate(cert)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !parsed.NotBefore.Equal(internal.TestSeedEpoch) {
+				t.Errorf("Expected validity anchored to the test epoch, got %v", parsed.NotBefore)
+			}
+		})
+	}
+}
+
+func TestInsecureTestSeed_CallerSeedAndChain(t *testing.T) {
+	opts := NewOptions(WithInsecureTestSeed([]byte("caller")))
+	issueChain := func() ([]byte, []byte) {
+		ca, err := Issue(IssueRequest{Template: mustTemplateWithOptions(t, "subject: {common_name: Example CA}\nis_ca: true\nbasic_constraints_valid: true\nkey_usage: [cert_sign]\n", opts)}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := Issue(IssueRequest{
+			Template: mustTemplateWithOptions(t, "subject: {common_name: www.example.com}\ndns_names: [www.example.com]\n", opts),
+			Parent:   ca.Certificate,
+			Signer:   ca.PrivateKey,
+		}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ca.Certificate.Raw, leaf.Certificate.Raw
+	}
+
+	ca, leaf := issueChain()
+	againCA, againLeaf := issueChain()
+	if !bytes.Equal(ca, againCA) || !bytes.Equal(leaf, againLeaf) {
+		t.Error("Expected the same chain from the same caller seed")
+	}
+}
+
+func TestInsecureTestSeed_RejectsPSS(t *testing.T) {
+	template := mustTemplate(t, "subject: {common_name: a}\npublic_key_algorithm: RSA\nsignature_algorithm: SHA256WithRSAPSS\n")
+
+	_, err := Issue(IssueRequest{Template: template}, Options{InsecureTestSeed: []byte("seed")})
+	if err == nil || !strings.Contains(err.Error(), "cannot reproduce RSA-PSS signatures") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func mustTemplateWithOptions(t *testing.T, yamlData string, opts Options) *x509.Certificate {
+	t.Helper()
+
+	cert, err := X509FromYamlWithOptions([]byte(yamlData), opts)
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+	return cert
+}
+
+func TestInsecureTestSeed_StrictAndPolicy(t *testing.T) {
+	yamlData := []byte(`
+subject:
+  common_name: "fixture.example.com"
+insecure_test_seed: "golden-v1"
+`)
+
+	_, err := X509FromYaml(yamlData, WithStrict())
+	if err == nil || !strings.Contains(err.Error(), "insecure_test_seed: ") {
+		t.Errorf("Expected strict mode to reject the seed, got %v", err)
+	}
+	if _, err := X509FromYaml(yamlData, WithStrict(), WithInsecureTestSeed([]byte("golden-v1"))); err != nil {
+		t.Errorf("Expected strict mode to accept an explicit seed, got %v", err)
+	}
+
+	p, err := policy.Parse([]byte("rules: []"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = X509FromYaml(yamlData, WithPolicy(p, nil))
+	var denied *policy.DeniedError
+	if !errors.As(err, &denied) || denied.Results[0].Rule != policy.InsecureTestSeedRule {
+		t.Errorf("Expected the policy to deny the seed, got %v", err)
+	}
+
+	template, err := X509FromYaml([]byte("subject:\n  common_name: fixture.example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Issue(IssueRequest{Template: template}, Options{Policy: p, InsecureTestSeed: []byte("golden-v1")}); !errors.As(err, &denied) {
+		t.Errorf("Expected the policy to deny seeded issuance, got %v", err)
+	}
+
+	p.AllowInsecureTestSeed = true
+	if _, err := X509FromYaml(yamlData, WithPolicy(p, nil)); err != nil {
+		t.Errorf("Expected the policy to allow the seed, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package go_yaml_to_x509
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+	"github.com/rschoonheim/go-yaml-to-x509/keyfile"
+)
+
+// TLSSpec is an entry of a document's 'tls' section
+type TLSSpec = internal.TLSSpec
+
+// TLSConfigsFromYaml builds a *tls.Config for every entry of the 'tls' sections in a
+// YAML stream, keyed by name:
+//
+//	certificates:
+//	  ca:
+//	    config: {subject: {common_name: "Dev CA"}, is_ca: true, basic_constraints_valid: true, key_usage: [cert_sign]}
+//	  server:
+//	    config: {subject: {common_name: "localhost"}, dns_names: [localhost], ext_key_usage: [server_auth]}
+//	  client:
+//	    config: {subject: {common_name: "dev"}, ext_key_usage: [client_auth]}
+//	tls:
+//	  server:
+//	    role: server
+//	    certificate: server
+//	    issuer: ca
+//	    roots: [ca]
+//	    client_auth: require_and_verify
+//	  client:
+//	    role: client
+//	    certificate: client
+//	    issuer: ca
+//	    roots: [ca]
+//
+// Certificates named by 'certificate', 'issuer' and 'roots' are issued once per call,
+// with generated keys that never touch the disk, so every entry of the stream trusts
+// the same CA. Roots and certificates may also come from PEM files. Encrypted key
+// files are decrypted with opts.Passphrase, called with the entry name.
+func TLSConfigsFromYaml(yamlData []byte, opts ...Option) (map[string]*tls.Config, error) {
+	return TLSConfigsFromYamlWithOptions(yamlData, NewOptions(opts...))
+}
+
+// TLSConfigsFromYamlWithOptions builds TLS configurations like TLSConfigsFromYaml
+func TLSConfigsFromYamlWithOptions(yamlData []byte, opts Options) (map[string]*tls.Config, error) {
+	return TLSConfigsFromYamlContext(context.Background(), yamlData, opts)
+}
+
+// TLSConfigsFromYamlContext builds TLS configurations like TLSConfigsFromYaml,
+// opening signers under ctx and returning ctx.Err() once it is done
+func TLSConfigsFromYamlContext(ctx context.Context, yamlData []byte, opts Options) (map[string]*tls.Config, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	templates, specs, docs, err := buildStream(yamlData, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*TLSSpec)
+	for _, doc := range docs {
+		for name, entry := range doc.TLS {
+			if _, exists := entries[name]; exists {
+				return nil, fmt.Errorf("tls '%s' is defined more than once", name)
+			}
+			if entry == nil {
+				entry = &TLSSpec{}
+			}
+			entries[name] = entry
+		}
+	}
+
+	h, err := streamHierarchy(docs, templates, specs, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer h.close()
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	configs := make(map[string]*tls.Config, len(entries))
+	for _, name := range names {
+		config, err := tlsConfig(ctx, h, name, entries[name])
+		if err != nil {
+			return nil, fmt.Errorf("tls '%s': %w", name, err)
+		}
+		configs[name] = config
+	}
+
+	return configs, nil
+}
+
+// tlsConfig builds the tls.Config of a single entry
+func tlsConfig(ctx context.Context, h *hierarchy, name string, entry *TLSSpec) (*tls.Config, error) {
+	if entry.Role != internal.TLSRoleServer && entry.Role != internal.TLSRoleClient {
+		return nil, fmt.Errorf("role must be '%s' or '%s'", internal.TLSRoleServer, internal.TLSRoleClient)
+	}
+	if entry.Certificate != "" && (entry.CertFile != "" || entry.KeyFile != "") {
+		return nil, errors.New("certificate cannot be combined with cert_file and key_file")
+	}
+	if (entry.CertFile == "") != (entry.KeyFile == "") {
+		return nil, errors.New("cert_file and key_file must be used together")
+	}
+	if entry.Issuer != "" && entry.Certificate == "" {
+		return nil, errors.New("issuer requires a certificate")
+	}
+
+	config := &tls.Config{
+		ServerName: entry.ServerName,
+		NextProtos: entry.ALPN,
+	}
+
+	var err error
+	if config.MinVersion, err = parseOptionalTLSVersion(entry.MinVersion); err != nil {
+		return nil, err
+	}
+	if config.MaxVersion, err = parseOptionalTLSVersion(entry.MaxVersion); err != nil {
+		return nil, err
+	}
+	if config.MinVersion != 0 && config.MaxVersion != 0 && config.MinVersion > config.MaxVersion {
+		return nil, fmt.Errorf("min_version '%s' is above max_version '%s'", entry.MinVersion, entry.MaxVersion)
+	}
+	if config.CipherSuites, err = internal.ParseCipherSuites(entry.CipherSuites); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case entry.Certificate != "":
+		issued, err := h.issue(ctx, entry.Certificate, nil)
+		if err != nil {
+			return nil, err
+		}
+		h.use(issued.key)
+
+		certificate := tls.Certificate{PrivateKey: issued.key, Leaf: issued.cert}
+		for _, cert := range issued.chain {
+			certificate.Certificate = append(certificate.Certificate, cert.Raw)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	case entry.CertFile != "":
+		certificate, err := loadKeyPair(name, entry, h.opts)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	case entry.Role == internal.TLSRoleServer:
+		return nil, errors.New("a server requires a certificate or cert_file")
+	}
+
+	var roots *x509.CertPool
+	if len(entry.Roots) > 0 {
+		if roots, err = h.pool(ctx, "root", entry.Roots); err != nil {
+			return nil, err
+		}
+	}
+
+	if entry.Role == internal.TLSRoleClient {
+		if entry.ClientAuth != "" {
+			return nil, errors.New("client_auth only applies to servers")
+		}
+		config.RootCAs = roots
+		return config, nil
+	}
+
+	if config.ClientAuth, err = internal.ParseClientAuth(entry.ClientAuth); err != nil {
+		return nil, err
+	}
+	if config.ClientAuth >= tls.VerifyClientCertIfGiven && roots == nil {
+		return nil, fmt.Errorf("client_auth '%s' requires roots to verify client certificates with", entry.ClientAuth)
+	}
+	config.ClientCAs = roots
+	return config, nil
+}
+
+// loadKeyPair reads cert_file and key_file, decrypting an encrypted key with the
+// passphrase opts.Passphrase returns for the entry
+func loadKeyPair(name string, entry *TLSSpec, opts Options) (tls.Certificate, error) {
+	certs, err := readCertificates(opts.IncludeFS, entry.CertFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	certificate := tls.Certificate{Leaf: certs[0]}
+	for _, cert := range certs {
+		certificate.Certificate = append(certificate.Certificate, cert.Raw)
+	}
+
+	keyData, err := internal.ReadFile(opts.IncludeFS, entry.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	var passphrase []byte
+	if keyfile.IsEncrypted(keyData) && opts.Passphrase != nil {
+		if passphrase, err = opts.Passphrase(name); err != nil {
+			return tls.Certificate{}, fmt.Errorf("%s: %w", entry.KeyFile, err)
+		}
+	}
+	key, err := keyfile.Parse(keyData, passphrase)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("%s: %w", entry.KeyFile, err)
+	}
+	if !publicKeyEqual(certificate.Leaf.PublicKey, key.Public()) {
+		return tls.Certificate{}, fmt.Errorf("%s does not hold the key of %s", entry.KeyFile, entry.CertFile)
+	}
+
+	certificate.PrivateKey = key
+	return certificate, nil
+}
+
+func parseOptionalTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+	return internal.ParseTLSVersion(version)
+}
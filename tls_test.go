@@ -0,0 +1,344 @@
+package go_yaml_to_x509
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rschoonheim/go-yaml-to-x509/keyfile"
+)
+
+const mtlsYaml = `
+segments:
+  leaf:
+    validity:
+      duration: 1d
+    key_usage: [digital_signature]
+certificates:
+  root:
+    config:
+      subject: {common_name: "Dev Root"}
+      validity: {duration: 1y}
+      is_ca: true
+      basic_constraints_valid: true
+      key_usage: [cert_sign]
+  intermediate:
+    config:
+      subject: {common_name: "Dev Intermediate"}
+      validity: {duration: 1y}
+      is_ca: true
+      basic_constraints_valid: true
+      key_usage: [cert_sign]
+  server:
+    merge: [leaf]
+    config:
+      subject: {common_name: "localhost"}
+      dns_names: [localhost]
+      ext_key_usage: [server_auth]
+  client:
+    merge: [leaf]
+    config:
+      subject: {common_name: "dev"}
+      ext_key_usage: [client_auth]
+tls:
+  server:
+    role: server
+    certificate: server
+    issuer: intermediate
+    roots: [root]
+    client_auth: require_and_verify
+    min_version: "1.2"
+    alpn: [h2, http/1.1]
+  client:
+    role: client
+    certificate: client
+    issuer: root
+    roots: [root]
+    server_name: localhost
+    alpn: [h2]
+  anonymous:
+    role: client
+    roots: [root]
+    server_name: localhost
+  intermediate:
+    role: client
+    certificate: intermediate
+    issuer: root
+`
+
+// tlsHandshake connects a client to a server over a pipe and returns the client's
+// error and the negotiated protocol
+func tlsHandshake(t *testing.T, server, client *tls.Config) (string, error) {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn := tls.Server(serverConn, server)
+		if conn.Handshake() == nil {
+			conn.Write([]byte{1})
+			io.Copy(io.Discard, conn)
+		}
+		conn.Close()
+	}()
+
+	conn := tls.Client(clientConn, client)
+	err := conn.Handshake()
+	protocol := conn.ConnectionState().NegotiatedProtocol
+	if err == nil {
+		// In TLS 1.3 the server checks the client certificate after the client's
+		// handshake is done, so read its acknowledgement or alert
+		_, err = conn.Read(make([]byte, 1))
+	}
+	conn.Close()
+	<-done
+	return protocol, err
+}
+
+func TestTLSConfigsFromYaml_MutualTLS(t *testing.T) {
+	configs, err := TLSConfigsFromYaml([]byte(mtlsYaml))
+	if err != nil {
+		t.Fatalf("TLSConfigsFromYaml: %v", err)
+	}
+	if len(configs) != 4 {
+		t.Fatalf("expected 4 configs, got %d", len(configs))
+	}
+
+	server := configs["server"]
+	if server.ClientAuth != tls.RequireAndVerifyClientCert || server.MinVersion != tls.VersionTLS12 {
+		t.Errorf("unexpected server settings: client auth %v, min version %x", server.ClientAuth, server.MinVersion)
+	}
+	chain := server.Certificates[0].Certificate
+	if len(chain) != 2 {
+		t.Fatalf("expected the server certificate and intermediate, got %d certificates", len(chain))
+	}
+	if server.Certificates[0].Leaf.Issuer.CommonName != "Dev Intermediate" {
+		t.Errorf("expected the server certificate to be issued by the intermediate, got %s", server.Certificates[0].Leaf.Issuer)
+	}
+
+	// The intermediate entry presents the same certificate that signed the server's
+	intermediate := configs["intermediate"].Certificates[0]
+	if string(intermediate.Certificate[0]) != string(chain[1]) {
+		t.Error("the intermediate was issued more than once")
+	}
+
+	protocol, err := tlsHandshake(t, server, configs["client"])
+	if err != nil {
+		t.Fatalf("mutual TLS handshake: %v", err)
+	}
+	if protocol != "h2" {
+		t.Errorf("expected ALPN to negotiate h2, got %q", protocol)
+	}
+
+	if _, err := tlsHandshake(t, server, configs["anonymous"]); err == nil {
+		t.Error("expected the server to reject a client without a certificate")
+	}
+}
+
+func TestTLSConfigsFromYaml_TLSOnlyDocument(t *testing.T) {
+	stream := `
+name: ca
+subject: {common_name: "Dev CA"}
+validity: {duration: 1y}
+is_ca: true
+basic_constraints_valid: true
+key_usage: [cert_sign]
+---
+name: www
+subject: {common_name: "localhost"}
+validity: {duration: 1d}
+dns_names: [localhost]
+ext_key_usage: [server_auth]
+---
+tls:
+  www:
+    role: server
+    certificate: www
+    issuer: ca
+`
+	certs, err := CertificatesFromYaml([]byte(stream))
+	if err != nil {
+		t.Fatalf("CertificatesFromYaml: %v", err)
+	}
+	if len(certs) != 2 {
+		t.Errorf("expected the tls document to define no certificate, got %d certificates", len(certs))
+	}
+
+	configs, err := TLSConfigsFromYaml([]byte(stream))
+	if err != nil {
+		t.Fatalf("TLSConfigsFromYaml: %v", err)
+	}
+	if configs["www"].Certificates[0].Leaf.Issuer.CommonName != "Dev CA" {
+		t.Errorf("unexpected issuer %s", configs["www"].Certificates[0].Leaf.Issuer)
+	}
+}
+
+func TestTLSConfigsFromYaml_Files(t *testing.T) {
+	ca, err := Issue(IssueRequest{Template: mustTemplate(t, `
+subject: {common_name: "File CA"}
+validity: {duration: 1y}
+is_ca: true
+basic_constraints_valid: true
+key_usage: [cert_sign]
+`)}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := Issue(IssueRequest{
+		Template: mustTemplate(t, `
+subject: {common_name: "localhost"}
+validity: {duration: 1d}
+dns_names: [localhost]
+ext_key_usage: [server_auth]
+`),
+		Parent: ca.Certificate,
+		Signer: ca.PrivateKey,
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	certFile := write("server.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate.Raw}))
+	rootFile := write("ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Certificate.Raw}))
+	keyPEM, err := keyfile.Marshal(server.PrivateKey, []byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := write("server-key.pem", keyPEM)
+
+	stream := `
+tls:
+  server:
+    role: server
+    cert_file: ` + certFile + `
+    key_file: ` + keyFile + `
+  client:
+    role: client
+    roots: [` + rootFile + `]
+    server_name: localhost
+`
+	if _, err := TLSConfigsFromYaml([]byte(stream)); err == nil || !strings.Contains(err.Error(), "passphrase") {
+		t.Errorf("expected an encrypted key without a passphrase to fail, got %v", err)
+	}
+
+	configs, err := TLSConfigsFromYaml([]byte(stream), WithPassphrase(func(name string) ([]byte, error) {
+		if name != "server" {
+			t.Errorf("expected the passphrase of 'server', got '%s'", name)
+		}
+		return []byte("secret"), nil
+	}))
+	if err != nil {
+		t.Fatalf("TLSConfigsFromYaml: %v", err)
+	}
+	if _, err := tlsHandshake(t, configs["server"], configs["client"]); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	// Relative paths resolve against the include filesystem, like includes
+	relative := strings.NewReplacer(dir+string(filepath.Separator), "").Replace(stream)
+	configs, err = TLSConfigsFromYaml([]byte(relative), WithIncludeFS(os.DirFS(dir)), WithPassphrase(func(string) ([]byte, error) {
+		return []byte("secret"), nil
+	}))
+	if err != nil {
+		t.Fatalf("TLSConfigsFromYaml with relative paths: %v", err)
+	}
+	if _, err := tlsHandshake(t, configs["server"], configs["client"]); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+}
+
+func TestTLSConfigsFromYaml_Errors(t *testing.T) {
+	const certificates = `
+certificates:
+  ca:
+    config: {subject: {common_name: "CA"}, is_ca: true, basic_constraints_valid: true}
+  www:
+    config: {subject: {common_name: "www"}}
+`
+	tests := []struct {
+		name string
+		tls  string
+		err  string
+	}{
+		{"missing role", "x: {certificate: www}", "tls 'x': role must be 'server' or 'client'"},
+		{"server without certificate", "x: {role: server}", "tls 'x': a server requires a certificate or cert_file"},
+		{"unknown certificate", "x: {role: server, certificate: api}", "tls 'x': certificate 'api' not found"},
+		{"unknown root", "x: {role: client, roots: [missing-ca]}", "tls 'x': root 'missing-ca' is neither a certificate of the stream nor a readable file"},
+		{"issuer without certificate", "x: {role: client, issuer: ca}", "tls 'x': issuer requires a certificate"},
+		{"conflicting issuers", "x: {role: server, certificate: www, issuer: ca}\n  y: {role: client, certificate: www, issuer: www}", "certificate 'www' is issued by both"},
+		{"issuer cycle", "x: {role: server, certificate: www, issuer: ca}\n  y: {role: client, certificate: ca, issuer: www}", "is its own issuer"},
+		{"client auth without roots", "x: {role: server, certificate: www, client_auth: require_and_verify}", "tls 'x': client_auth 'require_and_verify' requires roots"},
+		{"client auth on a client", "x: {role: client, client_auth: require}", "tls 'x': client_auth only applies to servers"},
+		{"unknown client auth", "x: {role: server, certificate: www, client_auth: always}", "tls 'x': unknown client_auth 'always'"},
+		{"unknown version", "x: {role: client, min_version: '1.4'}", "tls 'x': unknown TLS version '1.4'"},
+		{"inverted versions", "x: {role: client, min_version: '1.3', max_version: '1.2'}", "tls 'x': min_version '1.3' is above max_version '1.2'"},
+		{"insecure cipher suite", "x: {role: client, cipher_suites: [TLS_RSA_WITH_RC4_128_SHA]}", "tls 'x': cipher suite 'TLS_RSA_WITH_RC4_128_SHA' is insecure"},
+		{"half a key pair", "x: {role: server, cert_file: cert.pem}", "tls 'x': cert_file and key_file must be used together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := TLSConfigsFromYaml([]byte(certificates + "tls:\n  " + tt.tls + "\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestTLSConfigsFromYamlContext(t *testing.T) {
+	yamlData := []byte(`
+certificates:
+  ca:
+    config:
+      subject: {common_name: "CA"}
+      is_ca: true
+      basic_constraints_valid: true
+      signer: {type: test-context}
+  www:
+    config: {subject: {common_name: "www"}}
+tls:
+  server: {role: server, certificate: www, issuer: ca}
+`)
+
+	ctx := context.WithValue(context.Background(), testContextKey{}, "caller")
+	if _, err := TLSConfigsFromYamlContext(ctx, yamlData, Options{}); err == nil || !strings.Contains(err.Error(), "opened under 'caller'") {
+		t.Errorf("expected the signer to be opened under the caller's context, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := TLSConfigsFromYamlContext(ctx, []byte(mtlsYaml), Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestTLSConfigsFromYaml_Example(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(".examples", "local-mtls.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs, err := TLSConfigsFromYaml(data)
+	if err != nil {
+		t.Fatalf("TLSConfigsFromYaml: %v", err)
+	}
+	if _, err := tlsHandshake(t, configs["api"], configs["worker"]); err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+}
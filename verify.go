@@ -0,0 +1,423 @@
+package go_yaml_to_x509
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rschoonheim/go-yaml-to-x509/internal"
+)
+
+// VerifySpec is an entry of a document's 'verify' section
+type VerifySpec = internal.VerifySpec
+
+// Reasons of a VerificationFailure
+const (
+	ReasonExpired              = "expired"
+	ReasonNotYetValid          = "not_yet_valid"
+	ReasonHostnameMismatch     = "hostname_mismatch"
+	ReasonUnknownAuthority     = "unknown_authority"
+	ReasonNotAuthorizedToSign  = "not_authorized_to_sign"
+	ReasonIncompatibleUsage    = "incompatible_usage"
+	ReasonMissingKeyUsage      = "missing_key_usage"
+	ReasonTooManyIntermediates = "too_many_intermediates"
+	ReasonNameConstraints      = "name_constraints"
+	ReasonIssuerMismatch       = "issuer_mismatch"
+	ReasonInvalid              = "invalid"
+)
+
+// VerifyOptions configures VerifyCertificate, like x509.VerifyOptions
+type VerifyOptions struct {
+	// Roots are the trusted roots, the system roots when nil
+	Roots         *x509.CertPool
+	Intermediates *x509.CertPool
+
+	// DNSName is a DNS name or IP address the certificate must be valid for
+	DNSName string
+
+	// KeyUsage lists key usages the certificate must have. x509.Certificate.Verify
+	// does not check key usages.
+	KeyUsage x509.KeyUsage
+
+	// ExtKeyUsages are usages a chain must permit, any usage when empty
+	ExtKeyUsages []x509.ExtKeyUsage
+
+	// CurrentTime is the time to verify at, time.Now when zero
+	CurrentTime time.Time
+
+	// Profiles names certificates that may appear in a chain, so that failures are
+	// attributed to the profile they were issued from
+	Profiles map[string]*x509.Certificate
+}
+
+// VerificationFailure is a reason a certificate failed to verify, with the profile
+// fields responsible for it
+type VerificationFailure struct {
+	Reason string
+
+	// Profile is the name of the certificate the failure is about, when known
+	Profile string
+	// Fields are the profile fields that cause the failure, e.g. "not_after"
+	Fields  []string
+	Message string
+}
+
+// String returns the failure as "reason: profile: fields: message"
+func (f VerificationFailure) String() string {
+	var b strings.Builder
+	b.WriteString(f.Reason)
+	if f.Profile != "" {
+		fmt.Fprintf(&b, ": %s", f.Profile)
+	}
+	if len(f.Fields) > 0 {
+		fmt.Fprintf(&b, ": %s", strings.Join(f.Fields, ", "))
+	}
+	fmt.Fprintf(&b, ": %s", f.Message)
+	return b.String()
+}
+
+// VerificationResult is the outcome of verifying a certificate
+type VerificationResult struct {
+	// Chains are the verified chains, from the certificate to a root
+	Chains   [][]*x509.Certificate
+	Failures []VerificationFailure
+}
+
+// OK reports whether the certificate verified without failures
+func (r *VerificationResult) OK() bool {
+	return len(r.Failures) == 0
+}
+
+// Err returns the failures as a single error, or nil
+func (r *VerificationResult) Err() error {
+	if r.OK() {
+		return nil
+	}
+	messages := make([]string, len(r.Failures))
+	for i, f := range r.Failures {
+		messages[i] = f.String()
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// VerifyCertificate verifies cert with x509.Certificate.Verify and explains why it
+// fails. Besides the error Verify reports, the certificate's own validity period,
+// name and key usages are checked, so that every such problem is listed.
+func VerifyCertificate(cert *x509.Certificate, opts VerifyOptions) *VerificationResult {
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	usages := opts.ExtKeyUsages
+	if len(usages) == 0 {
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+
+	profile := func(c *x509.Certificate) string {
+		for name, p := range opts.Profiles {
+			if p.Equal(c) {
+				return name
+			}
+		}
+		return ""
+	}
+
+	result := &VerificationResult{}
+	chains, err := cert.Verify(x509.VerifyOptions{
+		Roots:         opts.Roots,
+		Intermediates: opts.Intermediates,
+		DNSName:       opts.DNSName,
+		KeyUsages:     usages,
+		CurrentTime:   now,
+	})
+	if err == nil {
+		result.Chains = chains
+	} else {
+		result.add(verifyFailure(err, cert, opts, now, profile))
+	}
+
+	name := profile(cert)
+	if f, ok := validityFailure(cert, now); ok {
+		f.Profile = name
+		result.add(f)
+	}
+	if opts.DNSName != "" {
+		if err := cert.VerifyHostname(opts.DNSName); err != nil {
+			result.add(VerificationFailure{
+				Reason:  ReasonHostnameMismatch,
+				Profile: name,
+				Fields:  hostnameFields(opts.DNSName),
+				Message: err.Error(),
+			})
+		}
+	}
+	if missing := opts.KeyUsage &^ cert.KeyUsage; missing != 0 {
+		result.add(VerificationFailure{
+			Reason:  ReasonMissingKeyUsage,
+			Profile: name,
+			Fields:  []string{"key_usage"},
+			Message: fmt.Sprintf("certificate lacks the key usages %s", strings.Join(internal.FormatKeyUsage(missing), ", ")),
+		})
+	}
+
+	if !result.OK() {
+		result.Chains = nil
+	}
+	return result
+}
+
+// add appends a failure unless one with the same reason and profile is listed
+func (r *VerificationResult) add(f VerificationFailure) {
+	for _, existing := range r.Failures {
+		if existing.Reason == f.Reason && existing.Profile == f.Profile {
+			return
+		}
+	}
+	r.Failures = append(r.Failures, f)
+}
+
+// verifyFailure maps an error of x509.Certificate.Verify to the fields responsible
+func verifyFailure(err error, leaf *x509.Certificate, opts VerifyOptions, now time.Time, profile func(*x509.Certificate) string) VerificationFailure {
+	f := VerificationFailure{Reason: ReasonInvalid, Profile: profile(leaf), Message: err.Error()}
+
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var unknown x509.UnknownAuthorityError
+	switch {
+	case errors.As(err, &invalid):
+		if invalid.Cert != nil {
+			f.Profile = profile(invalid.Cert)
+		}
+		switch invalid.Reason {
+		case x509.Expired:
+			if validity, ok := validityFailure(invalid.Cert, now); ok {
+				validity.Profile = f.Profile
+				return validity
+			}
+			f.Reason, f.Fields = ReasonExpired, []string{"not_before", "not_after", "validity"}
+		case x509.NotAuthorizedToSign:
+			f.Reason, f.Fields = ReasonNotAuthorizedToSign, []string{"is_ca", "basic_constraints_valid", "key_usage"}
+		case x509.TooManyIntermediates:
+			f.Reason, f.Fields = ReasonTooManyIntermediates, []string{"max_path_len", "max_path_len_zero"}
+		case x509.IncompatibleUsage:
+			f.Reason, f.Fields = ReasonIncompatibleUsage, []string{"ext_key_usage"}
+		case x509.CANotAuthorizedForThisName:
+			f.Reason, f.Fields = ReasonNameConstraints, []string{"dns_names", "ip_addresses", "email_addresses", "uris"}
+		case x509.NameMismatch:
+			f.Reason, f.Fields = ReasonIssuerMismatch, []string{"issuer"}
+		case x509.CANotAuthorizedForExtKeyUsage:
+			f.Reason, f.Fields = ReasonIncompatibleUsage, []string{"ext_key_usage"}
+		}
+	case errors.As(err, &hostname):
+		f.Reason, f.Fields = ReasonHostnameMismatch, hostnameFields(opts.DNSName)
+	case errors.As(err, &unknown):
+		if unknown.Cert == nil {
+			f.Reason, f.Fields = ReasonUnknownAuthority, []string{"issuer"}
+			break
+		}
+
+		// Verify reports an issuer that is not a CA as an unknown authority, so look
+		// for a known certificate with the issuer's name that may not sign
+		for name, candidate := range opts.Profiles {
+			var constraint x509.ConstraintViolationError
+			if bytes.Equal(candidate.RawSubject, unknown.Cert.RawIssuer) && errors.As(unknown.Cert.CheckSignatureFrom(candidate), &constraint) {
+				f.Reason, f.Profile, f.Fields = ReasonNotAuthorizedToSign, name, []string{"is_ca", "basic_constraints_valid", "key_usage"}
+				f.Message = fmt.Sprintf("'%s' signed %s but is not a CA", name, unknown.Cert.Subject)
+				return f
+			}
+		}
+		f.Reason, f.Profile, f.Fields = ReasonUnknownAuthority, profile(unknown.Cert), []string{"issuer"}
+	}
+	return f
+}
+
+// validityFailure reports a certificate that is not valid at now
+func validityFailure(cert *x509.Certificate, now time.Time) (VerificationFailure, bool) {
+	switch {
+	case now.Before(cert.NotBefore):
+		return VerificationFailure{
+			Reason:  ReasonNotYetValid,
+			Fields:  []string{"not_before", "validity"},
+			Message: fmt.Sprintf("certificate is valid from %s, after %s", cert.NotBefore.UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339)),
+		}, true
+	case now.After(cert.NotAfter):
+		return VerificationFailure{
+			Reason:  ReasonExpired,
+			Fields:  []string{"not_after", "validity"},
+			Message: fmt.Sprintf("certificate expired at %s, before %s", cert.NotAfter.UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339)),
+		}, true
+	default:
+		return VerificationFailure{}, false
+	}
+}
+
+// hostnameFields returns the fields a DNS name or IP address is matched against
+func hostnameFields(name string) []string {
+	if net.ParseIP(strings.Trim(name, "[]")) != nil {
+		return []string{"ip_addresses"}
+	}
+	return []string{"dns_names"}
+}
+
+// VerifyFromYaml runs every entry of the 'verify' sections in a YAML stream, keyed
+// by name:
+//
+//	verify:
+//	  www:
+//	    certificate: www
+//	    issuer: intermediate
+//	    dns_name: www.example.com
+//	    ext_key_usage: [server_auth]
+//
+// Certificates of the stream are issued once per call like in TLSConfigsFromYaml,
+// and verified against the top of their issuers unless 'roots' are listed, with
+// their issuers as intermediates. Failures name the certificate and profile fields
+// responsible. The error is only set when the stream cannot be built.
+func VerifyFromYaml(yamlData []byte, opts ...Option) (map[string]*VerificationResult, error) {
+	return VerifyFromYamlWithOptions(yamlData, NewOptions(opts...))
+}
+
+// VerifyFromYamlWithOptions runs verifications like VerifyFromYaml
+func VerifyFromYamlWithOptions(yamlData []byte, opts Options) (map[string]*VerificationResult, error) {
+	return VerifyFromYamlContext(context.Background(), yamlData, opts)
+}
+
+// VerifyFromYamlContext runs verifications like VerifyFromYaml, opening signers
+// under ctx and returning ctx.Err() once it is done
+func VerifyFromYamlContext(ctx context.Context, yamlData []byte, opts Options) (map[string]*VerificationResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	templates, specs, docs, err := buildStream(yamlData, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*VerifySpec)
+	for _, doc := range docs {
+		for name, entry := range doc.Verify {
+			if _, exists := entries[name]; exists {
+				return nil, fmt.Errorf("verify '%s' is defined more than once", name)
+			}
+			if entry == nil {
+				entry = &VerifySpec{}
+			}
+			entries[name] = entry
+		}
+	}
+
+	h, err := streamHierarchy(docs, templates, specs, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer h.close()
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make(map[string]*VerificationResult, len(entries))
+	for _, name := range names {
+		result, err := verifyEntry(ctx, h, entries[name])
+		if err != nil {
+			return nil, fmt.Errorf("verify '%s': %w", name, err)
+		}
+		results[name] = result
+	}
+
+	return results, nil
+}
+
+// verifyEntry verifies the certificate of a single entry
+func verifyEntry(ctx context.Context, h *hierarchy, entry *VerifySpec) (*VerificationResult, error) {
+	if (entry.Certificate == "") == (entry.CertFile == "") {
+		return nil, errors.New("exactly one of certificate and cert_file is required")
+	}
+	if entry.Issuer != "" && entry.Certificate == "" {
+		return nil, errors.New("issuer requires a certificate")
+	}
+	if err := checkValues("key_usage", entry.KeyUsage, internal.KeyUsages); err != nil {
+		return nil, err
+	}
+	if err := checkValues("ext_key_usage", entry.ExtKeyUsage, internal.ExtKeyUsages); err != nil {
+		return nil, err
+	}
+
+	opts := VerifyOptions{
+		DNSName:      entry.DNSName,
+		KeyUsage:     internal.ParseKeyUsage(entry.KeyUsage),
+		ExtKeyUsages: internal.ParseExtKeyUsage(entry.ExtKeyUsage),
+		CurrentTime:  h.opts.now(),
+	}
+	if entry.Time != "" {
+		t, err := time.Parse(time.RFC3339, entry.Time)
+		if err != nil {
+			return nil, fmt.Errorf("time '%s' is not an RFC3339 timestamp", entry.Time)
+		}
+		opts.CurrentTime = t
+	}
+
+	var err error
+	if len(entry.Roots) > 0 {
+		if opts.Roots, err = h.pool(ctx, "root", entry.Roots); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Intermediates, err = h.pool(ctx, "intermediate", entry.Intermediates); err != nil {
+		return nil, err
+	}
+
+	var cert *x509.Certificate
+	if entry.Certificate != "" {
+		issued, err := h.issue(ctx, entry.Certificate, nil)
+		if err != nil {
+			return nil, err
+		}
+		cert = issued.cert
+		for _, intermediate := range issued.chain[1:] {
+			opts.Intermediates.AddCert(intermediate)
+		}
+		if opts.Roots == nil {
+			top, err := h.top(ctx, entry.Certificate)
+			if err != nil {
+				return nil, err
+			}
+			opts.Roots = x509.NewCertPool()
+			opts.Roots.AddCert(top.cert)
+		}
+	} else {
+		certs, err := readCertificates(h.opts.IncludeFS, entry.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		cert = certs[0]
+		for _, intermediate := range certs[1:] {
+			opts.Intermediates.AddCert(intermediate)
+		}
+	}
+
+	opts.Profiles = make(map[string]*x509.Certificate, len(h.issued))
+	for name, issued := range h.issued {
+		opts.Profiles[name] = issued.cert
+	}
+	return VerifyCertificate(cert, opts), nil
+}
+
+// checkValues rejects values that are not in known
+func checkValues(field string, values, known []string) error {
+	for _, value := range values {
+		if !slices.Contains(known, value) {
+			return fmt.Errorf("unknown %s '%s'", field, value)
+		}
+	}
+	return nil
+}
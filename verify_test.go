@@ -0,0 +1,323 @@
+package go_yaml_to_x509
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const verifyYaml = `
+segments:
+  ca:
+    validity: {duration: 1y, anchor: "2025-01-01T00:00:00Z"}
+    key_usage: [cert_sign]
+  leaf:
+    validity: {duration: 30d, anchor: "2025-01-01T00:00:00Z"}
+    key_usage: [digital_signature]
+certificates:
+  root:
+    merge: [ca]
+    config:
+      subject: {common_name: "Root"}
+      is_ca: true
+      basic_constraints_valid: true
+  intermediate:
+    merge: [ca]
+    config:
+      subject: {common_name: "Intermediate"}
+      is_ca: true
+      basic_constraints_valid: true
+  not-a-ca:
+    merge: [leaf]
+    config:
+      subject: {common_name: "Not a CA"}
+  www:
+    merge: [leaf]
+    config:
+      subject: {common_name: "www.example.com"}
+      dns_names: [www.example.com]
+      ext_key_usage: [server_auth]
+  signed-by-leaf:
+    merge: [leaf]
+    config:
+      subject: {common_name: "api.example.com"}
+      dns_names: [api.example.com]
+verify:
+  valid:
+    certificate: www
+    issuer: intermediate
+    dns_name: www.example.com
+    key_usage: [digital_signature]
+    ext_key_usage: [server_auth]
+    time: "2025-01-15T00:00:00Z"
+  chain-from-pools:
+    certificate: www
+    roots: [root]
+    intermediates: [intermediate]
+    time: "2025-01-15T00:00:00Z"
+  expired:
+    certificate: www
+    dns_name: www.example.com
+    time: "2025-03-01T00:00:00Z"
+  not-yet-valid:
+    certificate: www
+    time: "2024-12-01T00:00:00Z"
+  wrong-host:
+    certificate: www
+    dns_name: api.example.com
+    time: "2025-01-15T00:00:00Z"
+  wrong-usage:
+    certificate: www
+    ext_key_usage: [client_auth]
+    key_usage: [key_encipherment]
+    time: "2025-01-15T00:00:00Z"
+  unknown-root:
+    certificate: www
+    roots: [not-a-ca]
+    time: "2025-01-15T00:00:00Z"
+  leaf-as-issuer:
+    certificate: signed-by-leaf
+    issuer: not-a-ca
+    time: "2025-01-15T00:00:00Z"
+---
+verify:
+  intermediate:
+    certificate: intermediate
+    issuer: root
+    time: "2025-01-15T00:00:00Z"
+`
+
+func TestVerifyFromYaml(t *testing.T) {
+	results, err := VerifyFromYaml([]byte(verifyYaml))
+	if err != nil {
+		t.Fatalf("VerifyFromYaml: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		failures []VerificationFailure
+	}{
+		{"valid", nil},
+		{"chain-from-pools", nil},
+		{"intermediate", nil},
+		{"expired", []VerificationFailure{
+			{Reason: ReasonExpired, Profile: "www", Fields: []string{"not_after", "validity"}},
+		}},
+		{"not-yet-valid", []VerificationFailure{
+			{Reason: ReasonNotYetValid, Profile: "www", Fields: []string{"not_before", "validity"}},
+		}},
+		{"wrong-host", []VerificationFailure{
+			{Reason: ReasonHostnameMismatch, Profile: "www", Fields: []string{"dns_names"}},
+		}},
+		{"wrong-usage", []VerificationFailure{
+			{Reason: ReasonIncompatibleUsage, Profile: "www", Fields: []string{"ext_key_usage"}},
+			{Reason: ReasonMissingKeyUsage, Profile: "www", Fields: []string{"key_usage"}},
+		}},
+		{"unknown-root", []VerificationFailure{
+			{Reason: ReasonUnknownAuthority, Profile: "intermediate", Fields: []string{"issuer"}},
+		}},
+		{"leaf-as-issuer", []VerificationFailure{
+			{Reason: ReasonNotAuthorizedToSign, Profile: "not-a-ca", Fields: []string{"is_ca", "basic_constraints_valid", "key_usage"}},
+		}},
+	}
+
+	if len(results) != len(tests) {
+		t.Errorf("expected %d results, got %d", len(tests), len(results))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := results[tt.name]
+			if !ok {
+				t.Fatal("no result")
+			}
+			if result.OK() != (len(tt.failures) == 0) {
+				t.Fatalf("expected OK() = %v, got failures: %v", len(tt.failures) == 0, result.Err())
+			}
+			if result.OK() && len(result.Chains) == 0 {
+				t.Error("expected verified chains")
+			}
+
+			if len(result.Failures) != len(tt.failures) {
+				t.Fatalf("expected %d failures, got %v", len(tt.failures), result.Failures)
+			}
+			for i, expected := range tt.failures {
+				got := result.Failures[i]
+				if got.Reason != expected.Reason || got.Profile != expected.Profile || !reflect.DeepEqual(got.Fields, expected.Fields) {
+					t.Errorf("failure %d: expected %s on '%s' for %v, got %s on '%s' for %v (%s)",
+						i, expected.Reason, expected.Profile, expected.Fields, got.Reason, got.Profile, got.Fields, got.Message)
+				}
+			}
+		})
+	}
+
+	// The chain of the valid entry runs through the intermediate to the root
+	chain := results["valid"].Chains[0]
+	if len(chain) != 3 || chain[1].Subject.CommonName != "Intermediate" || chain[2].Subject.CommonName != "Root" {
+		t.Errorf("unexpected chain %v", chain)
+	}
+}
+
+func TestVerifyFromYaml_CertFile(t *testing.T) {
+	root, err := Issue(IssueRequest{Template: mustTemplate(t, `
+subject: {common_name: "File Root"}
+validity: {duration: 1y}
+is_ca: true
+basic_constraints_valid: true
+key_usage: [cert_sign]
+`)}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := Issue(IssueRequest{
+		Template: mustTemplate(t, `
+subject: {common_name: "www.example.com"}
+validity: {duration: 1d}
+dns_names: [www.example.com]
+`),
+		Parent: root.Certificate,
+		Signer: root.PrivateKey,
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "www.pem")
+	rootFile := filepath.Join(dir, "root.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Certificate.Raw}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rootFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Certificate.Raw}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stream := `
+verify:
+  www:
+    cert_file: ` + certFile + `
+    roots: [` + rootFile + `]
+    dns_name: www.example.com
+  other-host:
+    cert_file: ` + certFile + `
+    roots: [` + rootFile + `]
+    dns_name: 192.0.2.1
+`
+	results, err := VerifyFromYaml([]byte(stream))
+	if err != nil {
+		t.Fatalf("VerifyFromYaml: %v", err)
+	}
+	if err := results["www"].Err(); err != nil {
+		t.Errorf("expected the file to verify, got %v", err)
+	}
+
+	failures := results["other-host"].Failures
+	if len(failures) != 1 || failures[0].Reason != ReasonHostnameMismatch || failures[0].Fields[0] != "ip_addresses" || failures[0].Profile != "" {
+		t.Errorf("unexpected failures %v", failures)
+	}
+}
+
+func TestVerifyFromYaml_Errors(t *testing.T) {
+	const certificates = `
+certificates:
+  ca:
+    config: {subject: {common_name: "CA"}, is_ca: true, basic_constraints_valid: true}
+  www:
+    config: {subject: {common_name: "www"}}
+`
+	tests := []struct {
+		name   string
+		verify string
+		err    string
+	}{
+		{"no certificate", "x: {dns_name: www}", "verify 'x': exactly one of certificate and cert_file is required"},
+		{"unknown certificate", "x: {certificate: api}", "verify 'x': certificate 'api' not found"},
+		{"issuer without certificate", "x: {cert_file: www.pem, issuer: ca}", "verify 'x': issuer requires a certificate"},
+		{"unknown intermediate", "x: {certificate: www, intermediates: [missing]}", "verify 'x': intermediate 'missing' is neither a certificate of the stream nor a readable file"},
+		{"unknown usage", "x: {certificate: www, ext_key_usage: [server]}", "verify 'x': unknown ext_key_usage 'server'"},
+		{"bad time", "x: {certificate: www, time: yesterday}", "verify 'x': time 'yesterday' is not an RFC3339 timestamp"},
+		{"issuer cycle", "x: {certificate: www, issuer: ca}\n  y: {certificate: ca, issuer: www}", "is its own issuer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyFromYaml([]byte(certificates + "verify:\n  " + tt.verify + "\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyCertificate_ReportsEveryLeafProblem(t *testing.T) {
+	issued, err := Issue(IssueRequest{Template: mustTemplate(t, `
+subject: {common_name: "www.example.com"}
+not_before: "2020-01-01T00:00:00Z"
+not_after: "2021-01-01T00:00:00Z"
+dns_names: [www.example.com]
+`)}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(issued.Certificate)
+
+	result := VerifyCertificate(issued.Certificate, VerifyOptions{
+		Roots:       roots,
+		DNSName:     "api.example.com",
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		CurrentTime: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		Profiles:    map[string]*x509.Certificate{"www": issued.Certificate},
+	})
+
+	var reasons []string
+	for _, f := range result.Failures {
+		reasons = append(reasons, f.Reason)
+		if f.Profile != "www" {
+			t.Errorf("expected %s to be attributed to 'www', got '%s'", f.Reason, f.Profile)
+		}
+	}
+	expected := []string{ReasonExpired, ReasonHostnameMismatch, ReasonMissingKeyUsage}
+	if !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("expected reasons %v, got %v", expected, reasons)
+	}
+	if result.Chains != nil {
+		t.Error("expected no chains for a failed verification")
+	}
+	if err := result.Err(); err == nil || !strings.HasPrefix(err.Error(), "expired: www: not_after, validity: certificate expired at 2021-01-01T00:00:00Z") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestVerifyFromYamlContext(t *testing.T) {
+	yamlData := []byte(`
+certificates:
+  ca:
+    config:
+      subject: {common_name: "CA"}
+      is_ca: true
+      basic_constraints_valid: true
+      signer: {type: test-context}
+  www:
+    config: {subject: {common_name: "www"}}
+verify:
+  www: {certificate: www, issuer: ca}
+`)
+
+	ctx := context.WithValue(context.Background(), testContextKey{}, "caller")
+	if _, err := VerifyFromYamlContext(ctx, yamlData, Options{}); err == nil || !strings.Contains(err.Error(), "opened under 'caller'") {
+		t.Errorf("expected the signer to be opened under the caller's context, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := VerifyFromYamlContext(ctx, yamlData, Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
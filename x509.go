@@ -2,12 +2,15 @@ package go_yaml_to_x509
 
 import (
 	"crypto/x509"
-	"math/big"
+	"errors"
+	"fmt"
 	"net"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/rschoonheim/go-yaml-to-x509/internal"
+	"github.com/rschoonheim/go-yaml-to-x509/lint"
 
 	"gopkg.in/yaml.v3"
 )
@@ -41,39 +44,55 @@ import (
 //
 // When using segments, the 'merge' list specifies which segments to combine,
 // and 'config' provides the final overrides. Later segments and config override earlier ones.
-func X509FromYaml(yamlData []byte) (*x509.Certificate, error) {
-	// Parse YAML into ConfigDocument
-	doc := &internal.ConfigDocument{}
-	if err := yaml.Unmarshal(yamlData, doc); err != nil {
+//
+// Options such as WithVar, WithClock and WithStrict control resolution and building.
+func X509FromYaml(yamlData []byte, opts ...Option) (*x509.Certificate, error) {
+	return X509FromYamlWithOptions(yamlData, NewOptions(opts...))
+}
+
+// X509FromYamlWithOptions parses YAML data like X509FromYaml, using opts to control
+// how segments are resolved. It is ParseDocument, ResolveConfig and BuildCertificate
+// followed by a check against Options.Policy.
+func X509FromYamlWithOptions(yamlData []byte, opts Options) (*x509.Certificate, error) {
+	name, spec, err := resolveProfile(yamlData, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := BuildCertificate(spec, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.checkPolicy(name, cert, opts.testSeed(spec)); err != nil {
 		return nil, err
 	}
 
-	var spec *internal.CertificateSpec
+	return cert, nil
+}
 
-	switch {
-	default:
-		// Handle simple format
-		spec = &internal.CertificateSpec{}
-		if err := yaml.Unmarshal(yamlData, spec); err != nil {
+// BuildCertificate converts a resolved spec to a certificate template, using opts
+// for serial numbers and relative validity periods. Options.Policy is not checked
+// here, as a policy applies to named profiles; Issue checks it before signing.
+func BuildCertificate(spec *CertificateSpec, opts Options) (*x509.Certificate, error) {
+	if opts.Strict {
+		if err := checkStrict(spec, opts); err != nil {
 			return nil, err
 		}
-	case doc.Segments != nil || doc.Merge != nil:
-		// Handle segments-based config
-		resolvedSpec, err := internal.ResolveConfig(doc)
+	}
+
+	// Seeded builds draw serial numbers from the seed and anchor validity to a fixed time
+	seed := opts.testSeed(spec)
+	if seed != nil {
+		label, err := yaml.Marshal(spec)
 		if err != nil {
 			return nil, err
 		}
-		spec = resolvedSpec
-	case doc.Config != nil:
-		// Handle 'config' field only
-		spec = doc.Config
+		opts.Rand = internal.SeededReader(seed, "serial:"+string(label))
+		if opts.Now == nil {
+			opts.Now = func() time.Time { return internal.TestSeedEpoch }
+		}
 	}
 
-	return buildCertificate(spec)
-}
-
-// buildCertificate converts a CertificateSpec to an x509.Certificate
-func buildCertificate(spec *internal.CertificateSpec) (*x509.Certificate, error) {
 	cert := &x509.Certificate{
 		Subject:               internal.ParsePkixName(spec.Subject),
 		Issuer:                internal.ParsePkixName(spec.Issuer),
@@ -87,22 +106,47 @@ func buildCertificate(spec *internal.CertificateSpec) (*x509.Certificate, error)
 
 	// Parse serial number
 	if spec.SerialNumber != "" {
-		serialNum := new(big.Int)
-		serialNum.SetString(spec.SerialNumber, 10)
+		serialNum, err := parseSerialNumber(spec.SerialNumber, opts)
+		if err != nil {
+			return nil, err
+		}
+		cert.SerialNumber = serialNum
+	} else if seed != nil {
+		// Issue derives the key from the serial number, so seeded templates always have one
+		serialNum, err := internal.RandomSerialNumber(opts.Rand)
+		if err != nil {
+			return nil, err
+		}
 		cert.SerialNumber = serialNum
 	}
 
 	// Parse dates
+	if spec.Validity != nil {
+		if spec.NotBefore != "" || spec.NotAfter != "" {
+			return nil, fmt.Errorf("validity cannot be combined with not_before or not_after")
+		}
+		notBefore, notAfter, err := spec.Validity.Period(opts.now())
+		if err != nil {
+			return nil, err
+		}
+		cert.NotBefore = notBefore
+		cert.NotAfter = notAfter
+	}
+
 	if spec.NotBefore != "" {
-		if notBefore, err := time.Parse(time.RFC3339, spec.NotBefore); err == nil {
-			cert.NotBefore = notBefore
+		notBefore, err := time.Parse(time.RFC3339, spec.NotBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid not_before '%s', expected an RFC3339 timestamp", spec.NotBefore)
 		}
+		cert.NotBefore = notBefore
 	}
 
 	if spec.NotAfter != "" {
-		if notAfter, err := time.Parse(time.RFC3339, spec.NotAfter); err == nil {
-			cert.NotAfter = notAfter
+		notAfter, err := time.Parse(time.RFC3339, spec.NotAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid not_after '%s', expected an RFC3339 timestamp", spec.NotAfter)
 		}
+		cert.NotAfter = notAfter
 	}
 
 	// Parse key usage flags
@@ -127,5 +171,31 @@ func buildCertificate(spec *internal.CertificateSpec) (*x509.Certificate, error)
 	cert.SignatureAlgorithm = internal.ParseSignatureAlgorithm(spec.SignatureAlgorithm)
 	cert.PublicKeyAlgorithm = internal.ParsePublicKeyAlgorithm(spec.PublicKeyAlgorithm)
 
+	// Load an existing key to certify
+	if spec.PublicKey != "" {
+		if err := applyPublicKey(cert, spec, opts); err != nil {
+			return nil, err
+		}
+	}
+
 	return cert, nil
 }
+
+// checkStrict rejects the unknown and unparsable values that BuildCertificate
+// otherwise ignores, and insecure_test_seed unless WithInsecureTestSeed is set
+func checkStrict(spec *CertificateSpec, opts Options) error {
+	rules := lint.Profile()
+	if opts.InsecureTestSeed != nil {
+		rules = rules.Without(lint.InsecureTestSeedRule)
+	}
+	findings := rules.Run(lint.Target{Spec: spec})
+	if len(findings) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(findings))
+	for i, f := range findings {
+		messages[i] = fmt.Sprintf("%s: %s", f.Field, f.Message)
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"testing"
+	"time"
 
 	go_yaml_to_x509 "github.com/rschoonheim/go-yaml-to-x509"
 )
@@ -84,6 +85,46 @@ public_key_algorithm: "RSA"
 	fmt.Printf("Successfully parsed certificate for: %s\n", cert.Subject.CommonName)
 }
 
+func TestX509FromYaml_RelativeValidity(t *testing.T) {
+	yamlData := []byte(`
+subject:
+  common_name: "example.com"
+validity:
+  duration: "90d"
+  backdate: "1h"
+`)
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	cert, err := go_yaml_to_x509.X509FromYamlWithOptions(yamlData, go_yaml_to_x509.Options{
+		Now: func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse YAML: %v", err)
+	}
+
+	if !cert.NotBefore.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Expected NotBefore one hour before now, got %v", cert.NotBefore)
+	}
+	if !cert.NotAfter.Equal(now.Add(90 * 24 * time.Hour)) {
+		t.Errorf("Expected NotAfter 90 days after now, got %v", cert.NotAfter)
+	}
+}
+
+func TestX509FromYaml_InvalidDates(t *testing.T) {
+	tests := map[string]string{
+		"not_before: \"2025-01-01\"":                                     "invalid not_before '2025-01-01', expected an RFC3339 timestamp",
+		"not_after: \"next year\"":                                       "invalid not_after 'next year', expected an RFC3339 timestamp",
+		"validity: {duration: 90d}\nnot_after: \"2026-01-01T00:00:00Z\"": "validity cannot be combined with not_before or not_after",
+	}
+
+	for yamlData, expected := range tests {
+		_, err := go_yaml_to_x509.X509FromYaml([]byte(yamlData))
+		if err == nil || err.Error() != expected {
+			t.Errorf("Expected error '%s', got %v", expected, err)
+		}
+	}
+}
+
 func ExampleX509FromYaml() {
 	yamlData := []byte(`
 serial_number: "123456"
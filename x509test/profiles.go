@@ -0,0 +1,94 @@
+package x509test
+
+// Ready-made profiles for MustIssue. Each is valid for a day around the time it is
+// issued unless its name says otherwise.
+const (
+	// Server is a TLS server certificate for localhost, 127.0.0.1 and ::1
+	Server = `
+subject:
+  common_name: "localhost"
+validity:
+  duration: 24h
+  backdate: 1h
+key_usage: [digital_signature]
+ext_key_usage: [server_auth]
+dns_names: ["localhost"]
+ip_addresses: ["127.0.0.1", "::1"]
+`
+
+	// Client is a TLS client certificate
+	Client = `
+subject:
+  common_name: "client"
+  organization: "x509test"
+validity:
+  duration: 24h
+  backdate: 1h
+key_usage: [digital_signature]
+ext_key_usage: [client_auth]
+email_addresses: ["client@example.com"]
+`
+
+	// Expired is a server certificate whose validity ended in 2001
+	Expired = `
+subject:
+  common_name: "localhost"
+not_before: "2000-01-01T00:00:00Z"
+not_after: "2001-01-01T00:00:00Z"
+key_usage: [digital_signature]
+ext_key_usage: [server_auth]
+dns_names: ["localhost"]
+ip_addresses: ["127.0.0.1", "::1"]
+`
+
+	// NotYetValid is a server certificate whose validity starts in 2099
+	NotYetValid = `
+subject:
+  common_name: "localhost"
+not_before: "2099-01-01T00:00:00Z"
+not_after: "2100-01-01T00:00:00Z"
+key_usage: [digital_signature]
+ext_key_usage: [server_auth]
+dns_names: ["localhost"]
+ip_addresses: ["127.0.0.1", "::1"]
+`
+
+	// WrongHost is a server certificate for a name no test connects to
+	WrongHost = `
+subject:
+  common_name: "wrong-host.invalid"
+validity:
+  duration: 24h
+  backdate: 1h
+key_usage: [digital_signature]
+ext_key_usage: [server_auth]
+dns_names: ["wrong-host.invalid"]
+`
+
+	// Revoked is a server certificate for localhost, meant for MustIssueRevoked
+	Revoked = `
+subject:
+  common_name: "localhost"
+  organizational_unit: "revoked"
+validity:
+  duration: 24h
+  backdate: 1h
+key_usage: [digital_signature]
+ext_key_usage: [server_auth]
+dns_names: ["localhost"]
+ip_addresses: ["127.0.0.1", "::1"]
+`
+
+	// CAProfile is the profile of the shared test CA
+	CAProfile = `
+subject:
+  common_name: "x509test CA"
+  organization: "x509test"
+validity:
+  duration: 1y
+  backdate: 1h
+is_ca: true
+basic_constraints_valid: true
+key_usage: [cert_sign, crl_sign]
+`
+)
@@ -0,0 +1,289 @@
+// Package x509test issues certificates from YAML profiles in Go tests.
+//
+//	func TestServer(t *testing.T) {
+//		server := x509test.MustIssue(t, x509test.Server)
+//		srv := httptest.NewUnstartedServer(handler)
+//		srv.TLS = &tls.Config{Certificates: []tls.Certificate{server.TLSCertificate}}
+//		srv.StartTLS()
+//		client := &http.Client{Transport: &http.Transport{
+//			TLSClientConfig: &tls.Config{RootCAs: server.CAPool},
+//		}}
+//		...
+//	}
+//
+// Certificates are signed by a CA shared by every test in the binary, and leaf
+// key pairs are generated once per algorithm and reused, so issuing stays cheap
+// in large test suites. Options apply to building and issuing, but not to cached
+// leaf keys: a profile's public_key is certified as is, and a test seed derives
+// the key instead. The keys are for tests only.
+package x509test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+	"github.com/rschoonheim/go-yaml-to-x509/keyfile"
+	"github.com/rschoonheim/go-yaml-to-x509/store"
+)
+
+// Issued is a certificate issued for a test, with everything needed to use it in TLS
+type Issued struct {
+	Certificate *x509.Certificate
+
+	// PrivateKey is nil, like KeyPEM, when the profile certifies its own public_key
+	PrivateKey crypto.Signer
+
+	// TLSCertificate holds the certificate, its issuing CA and the private key
+	TLSCertificate tls.Certificate
+
+	// CAPool contains the issuing CA, for RootCAs or ClientCAs
+	CAPool *x509.CertPool
+
+	// CertPEM and KeyPEM are the certificate and unencrypted PKCS#8 key as PEM
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// CA is a certificate authority for tests. It records the certificates it issues,
+// so that they can be revoked and listed in a CRL.
+type CA struct {
+	Issued
+
+	mu        sync.Mutex
+	store     *store.MemoryStore
+	crlNumber int64
+}
+
+var (
+	defaultCA    *CA
+	defaultCAErr error
+	defaultOnce  sync.Once
+
+	keysMu sync.Mutex
+	keys   = make(map[x509.PublicKeyAlgorithm]crypto.Signer)
+)
+
+// DefaultCA returns the CA shared by every test in the binary, created on first use
+// from CAProfile
+func DefaultCA(t testing.TB) *CA {
+	t.Helper()
+
+	defaultOnce.Do(func() {
+		defaultCA, defaultCAErr = newCA(CAProfile)
+	})
+	if defaultCAErr != nil {
+		t.Fatalf("x509test: creating default CA: %v", defaultCAErr)
+	}
+	return defaultCA
+}
+
+// NewCA creates a self-signed CA from a YAML profile, for tests that need a CA of
+// their own
+func NewCA(t testing.TB, profile string, opts ...yamltox509.Option) *CA {
+	t.Helper()
+
+	ca, err := newCA(profile, opts...)
+	if err != nil {
+		t.Fatalf("x509test: creating CA: %v", err)
+	}
+	return ca
+}
+
+// MustIssue issues a certificate from a YAML profile, signed by the default CA.
+// The test fails when the profile cannot be built or issued.
+func MustIssue(t testing.TB, profile string, opts ...yamltox509.Option) *Issued {
+	t.Helper()
+	return DefaultCA(t).MustIssue(t, profile, opts...)
+}
+
+// MustIssueRevoked issues a certificate like MustIssue and revokes it at the default CA
+func MustIssueRevoked(t testing.TB, profile string, opts ...yamltox509.Option) *Issued {
+	t.Helper()
+
+	ca := DefaultCA(t)
+	issued := ca.MustIssue(t, profile, opts...)
+	ca.Revoke(t, issued.Certificate)
+	return issued
+}
+
+// MustIssue issues a certificate from a YAML profile, signed by ca
+func (ca *CA) MustIssue(t testing.TB, profile string, opts ...yamltox509.Option) *Issued {
+	t.Helper()
+
+	issued, err := ca.issue(profile, opts)
+	if err != nil {
+		t.Fatalf("x509test: issuing certificate: %v", err)
+	}
+	return issued
+}
+
+// Revoke marks a certificate issued by ca as revoked
+func (ca *CA) Revoke(t testing.TB, cert *x509.Certificate) {
+	t.Helper()
+
+	if err := ca.store.Revoke(cert.SerialNumber, time.Now(), 0); err != nil {
+		t.Fatalf("x509test: revoking certificate: %v", err)
+	}
+}
+
+// CRL returns a certificate revocation list signed by ca, listing every certificate
+// revoked so far. It is valid for a day.
+func (ca *CA) CRL(t testing.TB) *x509.RevocationList {
+	t.Helper()
+
+	ca.mu.Lock()
+	ca.crlNumber++
+	number := big.NewInt(ca.crlNumber)
+	ca.mu.Unlock()
+
+	now := time.Now()
+	der, err := store.CreateCRL(rand.Reader, ca.store, ca.Certificate, ca.PrivateKey, number, now.Add(-time.Hour), now.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("x509test: creating CRL: %v", err)
+	}
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatalf("x509test: parsing CRL: %v", err)
+	}
+	return crl
+}
+
+// newCA issues a self-signed CA certificate from a profile for a new key
+func newCA(profile string, opts ...yamltox509.Option) (*CA, error) {
+	o := yamltox509.NewOptions(opts...)
+	req, err := build(profile, o)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every CA gets a key of its own, generated by Issue with the options' randomness
+	// or seed, so that certificates of one CA never verify against another with the
+	// same subject
+	issued, err := yamltox509.Issue(req, o)
+	if err != nil {
+		return nil, err
+	}
+
+	ca := &CA{store: store.NewMemoryStore()}
+	ca.Issued, err = newIssued(issued.Certificate, issued.PrivateKey, nil)
+	return ca, err
+}
+
+// issue issues a certificate, recording it in the CA's store. Leaf keys come from
+// the per-algorithm cache, unless the profile certifies its own public_key or keys
+// are derived from a test seed.
+func (ca *CA) issue(profile string, opts []yamltox509.Option) (*Issued, error) {
+	o := yamltox509.NewOptions(opts...)
+	req, err := build(profile, o)
+	if err != nil {
+		return nil, err
+	}
+	req.Parent = ca.Certificate
+	req.Signer = ca.PrivateKey
+
+	var key crypto.Signer
+	if req.Template.PublicKey == nil && req.InsecureTestSeed == nil && o.InsecureTestSeed == nil {
+		if key, err = cachedKey(req.Template.PublicKeyAlgorithm); err != nil {
+			return nil, err
+		}
+		req.PublicKey = key.Public()
+	}
+
+	o.Store = ca.store
+	issued, err := yamltox509.Issue(req, o)
+	if err != nil {
+		return nil, err
+	}
+	if issued.PrivateKey != nil {
+		key = issued.PrivateKey
+	}
+
+	result, err := newIssued(issued.Certificate, key, ca.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// build resolves a profile that defines a single certificate and returns the
+// request to issue it, named after the profile and with the profile's test seed
+func build(profile string, o yamltox509.Options) (yamltox509.IssueRequest, error) {
+	doc, err := yamltox509.ParseDocumentWithOptions([]byte(profile), o)
+	if err != nil {
+		return yamltox509.IssueRequest{}, err
+	}
+	specs, err := yamltox509.ResolveCertificates(doc, o)
+	if err != nil {
+		return yamltox509.IssueRequest{}, err
+	}
+	if len(specs) != 1 {
+		return yamltox509.IssueRequest{}, fmt.Errorf("profile defines %d certificates, expected one", len(specs))
+	}
+
+	var req yamltox509.IssueRequest
+	for name, spec := range specs {
+		if req.Template, err = yamltox509.BuildCertificate(spec, o); err != nil {
+			return yamltox509.IssueRequest{}, err
+		}
+		req.Profile = name
+		if spec.InsecureTestSeed != "" {
+			req.InsecureTestSeed = []byte(spec.InsecureTestSeed)
+		}
+	}
+	return req, nil
+}
+
+// newIssued completes an Issued for a certificate and key, chained to parent when
+// set. key is nil when the profile certifies its own public_key.
+func newIssued(cert *x509.Certificate, key crypto.Signer, parent *x509.Certificate) (Issued, error) {
+	var keyPEM []byte
+	if key != nil {
+		var err error
+		if keyPEM, err = keyfile.Marshal(key, nil, nil); err != nil {
+			return Issued{}, err
+		}
+	}
+
+	root := cert
+	chain := [][]byte{cert.Raw}
+	if parent != nil {
+		root = parent
+		chain = append(chain, parent.Raw)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(root)
+
+	return Issued{
+		Certificate:    cert,
+		PrivateKey:     key,
+		TLSCertificate: tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: cert},
+		CAPool:         pool,
+		CertPEM:        pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
+		KeyPEM:         keyPEM,
+	}, nil
+}
+
+// cachedKey returns the leaf key pair for an algorithm, generating it on first use
+func cachedKey(alg x509.PublicKeyAlgorithm) (crypto.Signer, error) {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+
+	if key, ok := keys[alg]; ok {
+		return key, nil
+	}
+	key, err := yamltox509.GenerateKey(alg, nil)
+	if err != nil {
+		return nil, err
+	}
+	keys[alg] = key
+	return key, nil
+}
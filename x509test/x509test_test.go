@@ -0,0 +1,220 @@
+package x509test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	yamltox509 "github.com/rschoonheim/go-yaml-to-x509"
+	"github.com/rschoonheim/go-yaml-to-x509/policy"
+)
+
+// handshake runs a TLS handshake between a server and a client over a pipe and
+// returns the client's error
+func handshake(t *testing.T, server, client *tls.Config) error {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn := tls.Server(serverConn, server)
+		if conn.Handshake() == nil {
+			io.Copy(io.Discard, conn)
+		}
+		conn.Close()
+	}()
+
+	conn := tls.Client(clientConn, client)
+	err := conn.Handshake()
+	conn.Close()
+	<-done
+	return err
+}
+
+func TestMustIssue_Server(t *testing.T) {
+	server := MustIssue(t, Server)
+
+	err := handshake(t,
+		&tls.Config{Certificates: []tls.Certificate{server.TLSCertificate}},
+		&tls.Config{RootCAs: server.CAPool, ServerName: "localhost"},
+	)
+	if err != nil {
+		t.Fatalf("handshake: %v", err)
+	}
+
+	if server.TLSCertificate.Leaf != server.Certificate {
+		t.Error("TLSCertificate.Leaf is not the issued certificate")
+	}
+	if len(server.TLSCertificate.Certificate) != 2 {
+		t.Errorf("expected the leaf and CA in the chain, got %d certificates", len(server.TLSCertificate.Certificate))
+	}
+	if _, err := tls.X509KeyPair(server.CertPEM, server.KeyPEM); err != nil {
+		t.Errorf("CertPEM and KeyPEM are not a key pair: %v", err)
+	}
+}
+
+func TestMustIssue_Client(t *testing.T) {
+	client := MustIssue(t, Client)
+
+	_, err := client.Certificate.Verify(x509.VerifyOptions{
+		Roots:     client.CAPool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+}
+
+func TestMustIssue_InvalidProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		check   func(error) bool
+	}{
+		{"expired", Expired, func(err error) bool {
+			var invalid x509.CertificateInvalidError
+			return errors.As(err, &invalid) && invalid.Reason == x509.Expired
+		}},
+		{"not yet valid", NotYetValid, func(err error) bool {
+			var invalid x509.CertificateInvalidError
+			return errors.As(err, &invalid) && invalid.Reason == x509.Expired
+		}},
+		{"wrong host", WrongHost, func(err error) bool {
+			var hostname x509.HostnameError
+			return errors.As(err, &hostname)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issued := MustIssue(t, tt.profile)
+
+			_, err := issued.Certificate.Verify(x509.VerifyOptions{
+				DNSName:     "localhost",
+				Roots:       issued.CAPool,
+				CurrentTime: time.Now(),
+			})
+			if !tt.check(err) {
+				t.Errorf("unexpected verify error: %v", err)
+			}
+		})
+	}
+}
+
+func TestMustIssueRevoked(t *testing.T) {
+	revoked := MustIssueRevoked(t, Revoked)
+	valid := MustIssue(t, Server)
+
+	crl := DefaultCA(t).CRL(t)
+	if err := crl.CheckSignatureFrom(DefaultCA(t).Certificate); err != nil {
+		t.Fatalf("CRL signature: %v", err)
+	}
+
+	listed := func(cert *x509.Certificate) bool {
+		for _, entry := range crl.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	if !listed(revoked.Certificate) {
+		t.Error("revoked certificate is not in the CRL")
+	}
+	if listed(valid.Certificate) {
+		t.Error("valid certificate is in the CRL")
+	}
+}
+
+func TestMustIssue_ReusesKeys(t *testing.T) {
+	first := MustIssue(t, Server)
+	second := MustIssue(t, Client)
+
+	if first.Certificate.SerialNumber.Cmp(second.Certificate.SerialNumber) == 0 {
+		t.Error("certificates share a serial number")
+	}
+	if !first.PrivateKey.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(second.PrivateKey.Public()) {
+		t.Error("expected the key pair to be reused for the same algorithm")
+	}
+
+	rsa := MustIssue(t, Server+"public_key_algorithm: RSA\n")
+	if rsa.Certificate.PublicKeyAlgorithm != x509.RSA {
+		t.Errorf("expected an RSA key, got %s", rsa.Certificate.PublicKeyAlgorithm)
+	}
+}
+
+func TestNewCA(t *testing.T) {
+	ca := NewCA(t, CAProfile)
+	if ca.Certificate.Equal(DefaultCA(t).Certificate) {
+		t.Fatal("NewCA returned the default CA")
+	}
+
+	issued := ca.MustIssue(t, Server)
+	if _, err := issued.Certificate.Verify(x509.VerifyOptions{Roots: DefaultCA(t).CAPool}); err == nil {
+		t.Error("certificate of a new CA verified against the default CA")
+	}
+	if _, err := issued.Certificate.Verify(x509.VerifyOptions{Roots: issued.CAPool}); err != nil {
+		t.Errorf("verify: %v", err)
+	}
+}
+
+func TestMustIssue_OptionsApplyAtIssuance(t *testing.T) {
+	p, err := policy.Parse([]byte("rules:\n  - field: key_size\n    min: \"384\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// key_size is only known once the key is, so the policy must reach Issue
+	var denied *policy.DeniedError
+	if _, err := DefaultCA(t).issue(Server, []yamltox509.Option{yamltox509.WithPolicy(p, nil)}); !errors.As(err, &denied) || denied.Results[0].Field != "key_size" {
+		t.Errorf("expected the key_size rule to deny issuance, got %v", err)
+	}
+	if _, err := newCA(CAProfile, yamltox509.WithPolicy(p, nil)); !errors.As(err, &denied) {
+		t.Errorf("expected the key_size rule to deny the CA, got %v", err)
+	}
+}
+
+func TestMustIssue_SeedAndPublicKey(t *testing.T) {
+	seed := yamltox509.WithInsecureTestSeed([]byte("x509test"))
+	first := NewCA(t, CAProfile).MustIssue(t, Server, seed)
+	second := NewCA(t, CAProfile).MustIssue(t, Server, seed)
+	if !first.Certificate.PublicKey.(*ecdsa.PublicKey).Equal(second.Certificate.PublicKey) {
+		t.Error("expected seeded issuance to derive the same leaf key")
+	}
+	if cached := MustIssue(t, Server); cached.PrivateKey == first.PrivateKey {
+		t.Error("expected the seeded key not to come from the cache")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "device.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	issued := MustIssue(t, Server+"public_key: "+path+"\n")
+	if !key.PublicKey.Equal(issued.Certificate.PublicKey) {
+		t.Error("expected the profile's public_key to be certified")
+	}
+	if issued.PrivateKey != nil || issued.KeyPEM != nil {
+		t.Error("expected no private key for a profile's public_key")
+	}
+}